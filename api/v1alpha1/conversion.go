@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	deprecatedv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &Cluster{}
+var _ conversion.Convertible = &ClusterList{}
+var _ conversion.Convertible = &Machine{}
+var _ conversion.Convertible = &MachineList{}
+var _ conversion.Convertible = &MachineSet{}
+var _ conversion.Convertible = &MachineSetList{}
+var _ conversion.Convertible = &MachineDeployment{}
+var _ conversion.Convertible = &MachineDeploymentList{}
+
+// ConvertTo converts this Cluster to the Hub version (v1alpha2).
+func (src *Cluster) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*clusterv1.Cluster)
+	return clusterv1.Convert_v1alpha1_Cluster_To_v1alpha2_Cluster((*deprecatedv1alpha1.Cluster)(src), dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *Cluster) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*clusterv1.Cluster)
+	return clusterv1.Convert_v1alpha2_Cluster_To_v1alpha1_Cluster(src, (*deprecatedv1alpha1.Cluster)(dst), nil)
+}
+
+// ConvertTo converts this ClusterList to the Hub version (v1alpha2).
+func (src *ClusterList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*clusterv1.ClusterList)
+	return clusterv1.Convert_v1alpha1_ClusterList_To_v1alpha2_ClusterList((*deprecatedv1alpha1.ClusterList)(src), dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *ClusterList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*clusterv1.ClusterList)
+	return clusterv1.Convert_v1alpha2_ClusterList_To_v1alpha1_ClusterList(src, (*deprecatedv1alpha1.ClusterList)(dst), nil)
+}
+
+// ConvertTo converts this Machine to the Hub version (v1alpha2).
+func (src *Machine) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*clusterv1.Machine)
+	return clusterv1.Convert_v1alpha1_Machine_To_v1alpha2_Machine((*deprecatedv1alpha1.Machine)(src), dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *Machine) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*clusterv1.Machine)
+	return clusterv1.Convert_v1alpha2_Machine_To_v1alpha1_Machine(src, (*deprecatedv1alpha1.Machine)(dst), nil)
+}
+
+// ConvertTo converts this MachineList to the Hub version (v1alpha2).
+func (src *MachineList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*clusterv1.MachineList)
+	return clusterv1.Convert_v1alpha1_MachineList_To_v1alpha2_MachineList((*deprecatedv1alpha1.MachineList)(src), dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *MachineList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*clusterv1.MachineList)
+	return clusterv1.Convert_v1alpha2_MachineList_To_v1alpha1_MachineList(src, (*deprecatedv1alpha1.MachineList)(dst), nil)
+}
+
+// ConvertTo converts this MachineSet to the Hub version (v1alpha2).
+func (src *MachineSet) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*clusterv1.MachineSet)
+	return clusterv1.Convert_v1alpha1_MachineSet_To_v1alpha2_MachineSet((*deprecatedv1alpha1.MachineSet)(src), dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *MachineSet) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*clusterv1.MachineSet)
+	return clusterv1.Convert_v1alpha2_MachineSet_To_v1alpha1_MachineSet(src, (*deprecatedv1alpha1.MachineSet)(dst), nil)
+}
+
+// ConvertTo converts this MachineSetList to the Hub version (v1alpha2).
+func (src *MachineSetList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*clusterv1.MachineSetList)
+	return clusterv1.Convert_v1alpha1_MachineSetList_To_v1alpha2_MachineSetList((*deprecatedv1alpha1.MachineSetList)(src), dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *MachineSetList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*clusterv1.MachineSetList)
+	return clusterv1.Convert_v1alpha2_MachineSetList_To_v1alpha1_MachineSetList(src, (*deprecatedv1alpha1.MachineSetList)(dst), nil)
+}
+
+// ConvertTo converts this MachineDeployment to the Hub version (v1alpha2).
+func (src *MachineDeployment) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*clusterv1.MachineDeployment)
+	return clusterv1.Convert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment((*deprecatedv1alpha1.MachineDeployment)(src), dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *MachineDeployment) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*clusterv1.MachineDeployment)
+	return clusterv1.Convert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(src, (*deprecatedv1alpha1.MachineDeployment)(dst), nil)
+}
+
+// ConvertTo converts this MachineDeploymentList to the Hub version (v1alpha2).
+func (src *MachineDeploymentList) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*clusterv1.MachineDeploymentList)
+	return clusterv1.Convert_v1alpha1_MachineDeploymentList_To_v1alpha2_MachineDeploymentList((*deprecatedv1alpha1.MachineDeploymentList)(src), dst, nil)
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version.
+func (dst *MachineDeploymentList) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*clusterv1.MachineDeploymentList)
+	return clusterv1.Convert_v1alpha2_MachineDeploymentList_To_v1alpha1_MachineDeploymentList(src, (*deprecatedv1alpha1.MachineDeploymentList)(dst), nil)
+}
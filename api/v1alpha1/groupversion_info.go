@@ -0,0 +1,41 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 serves the deprecated v1alpha1 types of sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1
+// as the v1alpha1 version of the cluster.x-k8s.io API group, converted to and from v1alpha2 by the conversion webhook,
+// so that clients still using the v1alpha1 types can read and write v1alpha2 objects.
+//
+// The existing v1alpha1 objects live in the cluster.k8s.io API group. A conversion webhook only converts between
+// the versions of a single group and never sees them, they have to be migrated with `clusterctl alpha migrate`.
+// +kubebuilder:object:generate=false
+// +groupName=cluster.x-k8s.io
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects
+	GroupVersion = schema.GroupVersion{Group: "cluster.x-k8s.io", Version: "v1alpha1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
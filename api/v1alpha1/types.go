@@ -0,0 +1,124 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	deprecatedv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=clusters,shortName=cl,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+
+// Cluster is the deprecated v1alpha1 Cluster.
+type Cluster deprecatedv1alpha1.Cluster
+
+// +kubebuilder:object:root=true
+
+// ClusterList contains a list of Cluster.
+type ClusterList deprecatedv1alpha1.ClusterList
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=machines,shortName=ma,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+
+// Machine is the deprecated v1alpha1 Machine.
+type Machine deprecatedv1alpha1.Machine
+
+// +kubebuilder:object:root=true
+
+// MachineList contains a list of Machine.
+type MachineList deprecatedv1alpha1.MachineList
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=machinesets,shortName=ms,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+
+// MachineSet is the deprecated v1alpha1 MachineSet.
+type MachineSet deprecatedv1alpha1.MachineSet
+
+// +kubebuilder:object:root=true
+
+// MachineSetList contains a list of MachineSet.
+type MachineSetList deprecatedv1alpha1.MachineSetList
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=machinedeployments,shortName=md,scope=Namespaced,categories=cluster-api
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+
+// MachineDeployment is the deprecated v1alpha1 MachineDeployment.
+type MachineDeployment deprecatedv1alpha1.MachineDeployment
+
+// +kubebuilder:object:root=true
+
+// MachineDeploymentList contains a list of MachineDeployment.
+type MachineDeploymentList deprecatedv1alpha1.MachineDeploymentList
+
+func init() {
+	SchemeBuilder.Register(
+		&Cluster{}, &ClusterList{},
+		&Machine{}, &MachineList{},
+		&MachineSet{}, &MachineSetList{},
+		&MachineDeployment{}, &MachineDeploymentList{},
+	)
+}
+
+// The types share their underlying structs with the deprecated types, the deep copy functions
+// are delegated to them.
+
+// DeepCopyObject implements runtime.Object.
+func (in *Cluster) DeepCopyObject() runtime.Object {
+	return (*Cluster)((*deprecatedv1alpha1.Cluster)(in).DeepCopy())
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *ClusterList) DeepCopyObject() runtime.Object {
+	return (*ClusterList)((*deprecatedv1alpha1.ClusterList)(in).DeepCopy())
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *Machine) DeepCopyObject() runtime.Object {
+	return (*Machine)((*deprecatedv1alpha1.Machine)(in).DeepCopy())
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *MachineList) DeepCopyObject() runtime.Object {
+	return (*MachineList)((*deprecatedv1alpha1.MachineList)(in).DeepCopy())
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *MachineSet) DeepCopyObject() runtime.Object {
+	return (*MachineSet)((*deprecatedv1alpha1.MachineSet)(in).DeepCopy())
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *MachineSetList) DeepCopyObject() runtime.Object {
+	return (*MachineSetList)((*deprecatedv1alpha1.MachineSetList)(in).DeepCopy())
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *MachineDeployment) DeepCopyObject() runtime.Object {
+	return (*MachineDeployment)((*deprecatedv1alpha1.MachineDeployment)(in).DeepCopy())
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *MachineDeploymentList) DeepCopyObject() runtime.Object {
+	return (*MachineDeploymentList)((*deprecatedv1alpha1.MachineDeploymentList)(in).DeepCopy())
+}
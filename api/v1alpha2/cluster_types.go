@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"sort"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	capierrors "sigs.k8s.io/cluster-api/errors"
@@ -24,6 +26,14 @@ import (
 
 const (
 	ClusterFinalizer = "cluster.cluster.x-k8s.io"
+
+	// PausedAnnotation is an annotation that can be applied to any Cluster API object to prevent
+	// the controllers from processing it.
+	PausedAnnotation = "cluster.x-k8s.io/paused"
+
+	// WatchLabel is a label that can be applied to any Cluster API object. Controller managers started
+	// with a watch filter only reconcile the objects whose label value matches their filter.
+	WatchLabel = "cluster.x-k8s.io/watch-filter"
 )
 
 /// [ClusterSpec]
@@ -37,6 +47,17 @@ type ClusterSpec struct {
 	// for provisioning infrastructure for a cluster in said provider.
 	// +optional
 	InfrastructureRef *corev1.ObjectReference `json:"infrastructureRef,omitempty"`
+
+	// ControlPlaneRef is an optional reference to a provider-specific resource that holds
+	// the details for provisioning the Control Plane for a Cluster.
+	// The referenced object is expected to report status.ready, status.initialized,
+	// status.replicas and status.version.
+	// +optional
+	ControlPlaneRef *corev1.ObjectReference `json:"controlPlaneRef,omitempty"`
+
+	// Paused can be used to prevent controllers from processing the Cluster and all its associated objects.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
 }
 
 /// [ClusterSpec]
@@ -103,6 +124,19 @@ type ClusterStatus struct {
 	// ControlPlaneInitialized defines if the control plane has been initialized.
 	// +optional
 	ControlPlaneInitialized bool `json:"controlPlaneInitialized"`
+
+	// ControlPlaneReady defines if the control plane is ready.
+	// +optional
+	ControlPlaneReady bool `json:"controlPlaneReady,omitempty"`
+
+	// FailureDomains is a map of failure domain names to the attributes of each failure domain,
+	// as reported by the infrastructure provider.
+	// +optional
+	FailureDomains FailureDomains `json:"failureDomains,omitempty"`
+
+	// Conditions defines current service state of the Cluster.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 // SetTypedPhase sets the Phase field to the string representation of ClusterPhase.
@@ -140,6 +174,48 @@ type APIEndpoint struct {
 
 /// [APIEndpoint]
 
+/// [FailureDomains]
+// FailureDomains is a map of failure domain names to the attributes of each failure domain.
+type FailureDomains map[string]FailureDomainSpec
+
+// FilterControlPlane returns the failure domains which are suitable for control plane Machines.
+func (in FailureDomains) FilterControlPlane() FailureDomains {
+	res := make(FailureDomains)
+	for id, spec := range in {
+		if spec.ControlPlane {
+			res[id] = spec
+		}
+	}
+	return res
+}
+
+// GetIDs returns the sorted names of the failure domains.
+func (in FailureDomains) GetIDs() []string {
+	ids := make([]string, 0, len(in))
+	for id := range in {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+/// [FailureDomains]
+
+/// [FailureDomainSpec]
+// FailureDomainSpec is the Schema for Cluster API failure domains.
+// It allows controllers to understand how many failure domains a cluster can optionally span across.
+type FailureDomainSpec struct {
+	// ControlPlane determines if this failure domain is suitable for use by control plane machines.
+	// +optional
+	ControlPlane bool `json:"controlPlane"`
+
+	// Attributes is a free form map of attributes an infrastructure provider might use or require.
+	// +optional
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+/// [FailureDomainSpec]
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:path=clusters,shortName=cl,scope=Namespaced,categories=cluster-api
 // +kubebuilder:storageversion
@@ -155,6 +231,16 @@ type Cluster struct {
 	Status ClusterStatus `json:"status,omitempty"`
 }
 
+// GetConditions returns the set of conditions for this object.
+func (c *Cluster) GetConditions() Conditions {
+	return c.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (c *Cluster) SetConditions(conditions Conditions) {
+	c.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // ClusterList contains a list of Cluster
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"net"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks for Clusters with the manager.
+func (c *Cluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(c).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha2-cluster,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=clusters,versions=v1alpha2,name=validation.cluster.cluster.x-k8s.io
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-x-k8s-io-v1alpha2-cluster,mutating=true,failurePolicy=fail,groups=cluster.x-k8s.io,resources=clusters,versions=v1alpha2,name=default.cluster.cluster.x-k8s.io
+
+var _ webhook.Defaulter = &Cluster{}
+var _ webhook.Validator = &Cluster{}
+
+// Default satisfies the defaulting webhook interface.
+func (c *Cluster) Default() {
+	if c.Spec.InfrastructureRef != nil && len(c.Spec.InfrastructureRef.Namespace) == 0 {
+		c.Spec.InfrastructureRef.Namespace = c.Namespace
+	}
+
+	if c.Spec.ControlPlaneRef != nil && len(c.Spec.ControlPlaneRef.Namespace) == 0 {
+		c.Spec.ControlPlaneRef.Namespace = c.Namespace
+	}
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (c *Cluster) ValidateCreate() error {
+	return c.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (c *Cluster) ValidateUpdate(old runtime.Object) error {
+	oldCluster, ok := old.(*Cluster)
+	if !ok {
+		return apierrors.NewBadRequest("expected a Cluster")
+	}
+	return c.validate(oldCluster)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (c *Cluster) ValidateDelete() error {
+	return nil
+}
+
+func (c *Cluster) validate(old *Cluster) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if c.Spec.InfrastructureRef != nil && c.Spec.InfrastructureRef.Namespace != c.Namespace {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("infrastructureRef", "namespace"),
+			c.Spec.InfrastructureRef.Namespace, "must match metadata.namespace"))
+	}
+
+	if c.Spec.ControlPlaneRef != nil && c.Spec.ControlPlaneRef.Namespace != c.Namespace {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("controlPlaneRef", "namespace"),
+			c.Spec.ControlPlaneRef.Namespace, "must match metadata.namespace"))
+	}
+
+	if c.Spec.ClusterNetwork != nil {
+		networkPath := specPath.Child("clusterNetwork")
+		allErrs = append(allErrs, validateNetworkRanges(c.Spec.ClusterNetwork.Pods, networkPath.Child("pods"))...)
+		allErrs = append(allErrs, validateNetworkRanges(c.Spec.ClusterNetwork.Services, networkPath.Child("services"))...)
+	}
+
+	if old != nil {
+		// The references can be set once, but can't be changed afterwards.
+		if old.Spec.InfrastructureRef != nil && !reflect.DeepEqual(old.Spec.InfrastructureRef, c.Spec.InfrastructureRef) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("infrastructureRef"),
+				c.Spec.InfrastructureRef, "field is immutable"))
+		}
+
+		if old.Spec.ControlPlaneRef != nil && !reflect.DeepEqual(old.Spec.ControlPlaneRef, c.Spec.ControlPlaneRef) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("controlPlaneRef"),
+				c.Spec.ControlPlaneRef, "field is immutable"))
+		}
+
+		// The network ranges are baked into the cluster when it's provisioned.
+		var oldPods, oldServices, pods, services *NetworkRanges
+		if old.Spec.ClusterNetwork != nil {
+			oldPods, oldServices = old.Spec.ClusterNetwork.Pods, old.Spec.ClusterNetwork.Services
+		}
+		if c.Spec.ClusterNetwork != nil {
+			pods, services = c.Spec.ClusterNetwork.Pods, c.Spec.ClusterNetwork.Services
+		}
+		if oldPods != nil && !reflect.DeepEqual(oldPods, pods) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("clusterNetwork", "pods"), pods, "field is immutable"))
+		}
+		if oldServices != nil && !reflect.DeepEqual(oldServices, services) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("clusterNetwork", "services"), services, "field is immutable"))
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Cluster").GroupKind(), c.Name, allErrs)
+}
+
+func validateNetworkRanges(ranges *NetworkRanges, fldPath *field.Path) field.ErrorList {
+	if ranges == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	for i, cidr := range ranges.CIDRBlocks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cidrBlocks").Index(i), cidr, "must be a valid CIDR block"))
+		}
+	}
+	return allErrs
+}
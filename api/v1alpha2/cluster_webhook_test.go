@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterDefault(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	c := &Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "foobar"},
+		Spec: ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{Name: "infra"},
+			ControlPlaneRef:   &corev1.ObjectReference{Name: "control-plane"},
+		},
+	}
+	c.Default()
+
+	g.Expect(c.Spec.InfrastructureRef.Namespace).To(gomega.Equal("foobar"))
+	g.Expect(c.Spec.ControlPlaneRef.Namespace).To(gomega.Equal("foobar"))
+}
+
+func TestClusterValidation(t *testing.T) {
+	newCluster := func(infraName string, pods ...string) *Cluster {
+		c := &Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "foobar"},
+			Spec: ClusterSpec{
+				ClusterNetwork: &ClusterNetwork{Pods: &NetworkRanges{CIDRBlocks: pods}},
+			},
+		}
+		if infraName != "" {
+			c.Spec.InfrastructureRef = &corev1.ObjectReference{Name: infraName, Namespace: "foobar"}
+		}
+		return c
+	}
+
+	testCases := []struct {
+		name      string
+		old       *Cluster
+		cluster   *Cluster
+		expectErr bool
+	}{
+		{
+			name:    "valid cluster",
+			cluster: newCluster("infra", "192.168.0.0/16"),
+		},
+		{
+			name:      "invalid CIDR block",
+			cluster:   newCluster("infra", "192.168.0.0"),
+			expectErr: true,
+		},
+		{
+			name: "infrastructure reference in another namespace",
+			cluster: func() *Cluster {
+				c := newCluster("infra")
+				c.Spec.InfrastructureRef.Namespace = "other"
+				return c
+			}(),
+			expectErr: true,
+		},
+		{
+			name:    "setting the infrastructure reference",
+			old:     newCluster("", "192.168.0.0/16"),
+			cluster: newCluster("infra", "192.168.0.0/16"),
+		},
+		{
+			name:      "changing the infrastructure reference",
+			old:       newCluster("infra", "192.168.0.0/16"),
+			cluster:   newCluster("other-infra", "192.168.0.0/16"),
+			expectErr: true,
+		},
+		{
+			name:      "changing the pod CIDR blocks",
+			old:       newCluster("infra", "192.168.0.0/16"),
+			cluster:   newCluster("infra", "10.0.0.0/16"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			var err error
+			if tc.old == nil {
+				err = tc.cluster.ValidateCreate()
+			} else {
+				err = tc.cluster.ValidateUpdate(tc.old)
+			}
+
+			if tc.expectErr {
+				g.Expect(err).To(gomega.HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(gomega.HaveOccurred())
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -126,3 +127,149 @@ type ObjectMeta struct {
 	// +patchStrategy=merge
 	OwnerReferences []metav1.OwnerReference `json:"ownerReferences,omitempty" patchStrategy:"merge" patchMergeKey:"uid" protobuf:"bytes,13,rep,name=ownerReferences"`
 }
+
+// ConditionType is a valid value for Condition.Type.
+type ConditionType string
+
+// ConditionSeverity expresses the severity of a Condition Type failing.
+type ConditionSeverity string
+
+const (
+	// ConditionSeverityError specifies that a condition with `Status=False` is an error.
+	ConditionSeverityError ConditionSeverity = "Error"
+
+	// ConditionSeverityWarning specifies that a condition with `Status=False` is a warning.
+	ConditionSeverityWarning ConditionSeverity = "Warning"
+
+	// ConditionSeverityInfo specifies that a condition with `Status=False` is informative.
+	ConditionSeverityInfo ConditionSeverity = "Info"
+
+	// ConditionSeverityNone should apply only to conditions with `Status=True`.
+	ConditionSeverityNone ConditionSeverity = ""
+)
+
+const (
+	// ReadyCondition summarizes the operational state of a Cluster API object.
+	ReadyCondition ConditionType = "Ready"
+
+	// BootstrapReadyCondition reports whether the bootstrap data of a Machine is available.
+	BootstrapReadyCondition ConditionType = "BootstrapReady"
+
+	// InfrastructureReadyCondition reports whether the infrastructure of a Cluster or a Machine is ready.
+	InfrastructureReadyCondition ConditionType = "InfrastructureReady"
+
+	// NodeRefAssignedCondition reports whether a Machine has been linked to its Node.
+	NodeRefAssignedCondition ConditionType = "NodeRefAssigned"
+
+	// KubeconfigReadyCondition reports whether the kubeconfig Secret of a Cluster has been generated.
+	KubeconfigReadyCondition ConditionType = "KubeconfigReady"
+
+	// CertificatesAvailableCondition reports whether the CAs and the service account key pair of a Cluster
+	// have been looked up or generated.
+	CertificatesAvailableCondition ConditionType = "CertificatesAvailable"
+
+	// ControlPlaneInitializedCondition reports whether the control plane of a Cluster has been initialized.
+	ControlPlaneInitializedCondition ConditionType = "ControlPlaneInitialized"
+
+	// ControlPlaneReadyCondition reports whether the control plane referenced by a Cluster is ready.
+	ControlPlaneReadyCondition ConditionType = "ControlPlaneReady"
+
+	// MachinesReadyCondition reports whether all the Machines of a MachineSet or MachineDeployment are ready.
+	MachinesReadyCondition ConditionType = "MachinesReady"
+
+	// MachinesCreatedCondition reports whether a MachineSet managed to create the Machines it needs.
+	MachinesCreatedCondition ConditionType = "MachinesCreated"
+
+	// AvailableCondition reports whether a MachineDeployment has the minimum number of available Machines
+	// required by its rollout strategy.
+	AvailableCondition ConditionType = "Available"
+
+	// ProgressingCondition reports whether the rollout of a MachineDeployment is progressing, or if it failed
+	// to make progress within the deadline set in spec.progressDeadlineSeconds.
+	ProgressingCondition ConditionType = "Progressing"
+
+	// RemediationAllowedCondition reports whether a MachineHealthCheck is allowed to remediate unhealthy Machines.
+	RemediationAllowedCondition ConditionType = "RemediationAllowed"
+)
+
+const (
+	// ExternalObjectNotFoundReason is used when a referenced provider object can't be found.
+	ExternalObjectNotFoundReason = "ExternalObjectNotFound"
+
+	// ExternalObjectErrorReason is used when a referenced provider object can't be reconciled.
+	ExternalObjectErrorReason = "ExternalObjectError"
+
+	// InvalidBootstrapConfigurationReason is used when a Machine has neither a bootstrap ConfigRef nor bootstrap data.
+	InvalidBootstrapConfigurationReason = "InvalidBootstrapConfiguration"
+
+	// WaitingForBootstrapDataReason is used when the bootstrap provider hasn't generated the bootstrap data yet.
+	WaitingForBootstrapDataReason = "WaitingForBootstrapData"
+
+	// WaitingForInfrastructureReason is used when the infrastructure provider isn't ready yet.
+	WaitingForInfrastructureReason = "WaitingForInfrastructure"
+
+	// WaitingForProviderIDReason is used when a Machine doesn't have a ProviderID yet.
+	WaitingForProviderIDReason = "WaitingForProviderID"
+
+	// NodeNotFoundReason is used when no Node matches the ProviderID of a Machine.
+	NodeNotFoundReason = "NodeNotFound"
+
+	// NodeRefFailedReason is used when the Node of a Machine can't be looked up.
+	NodeRefFailedReason = "NodeRefFailed"
+
+	// WaitingForAPIEndpointsReason is used when a Cluster doesn't have any API endpoint yet.
+	WaitingForAPIEndpointsReason = "WaitingForAPIEndpoints"
+
+	// KubeconfigSecretFailedReason is used when the kubeconfig Secret of a Cluster can't be created.
+	KubeconfigSecretFailedReason = "KubeconfigSecretFailed"
+
+	// CertificatesGenerationFailedReason is used when the certificates of a Cluster can't be looked up or generated.
+	CertificatesGenerationFailedReason = "CertificatesGenerationFailed"
+
+	// CertificatesMissingReason is used when some certificates of a Cluster with an initialized control plane
+	// don't exist, they aren't generated since they wouldn't match the ones of the control plane.
+	CertificatesMissingReason = "CertificatesMissing"
+
+	// WaitingForControlPlaneReason is used when a Cluster doesn't have an initialized control plane yet.
+	WaitingForControlPlaneReason = "WaitingForControlPlane"
+
+	// WaitingForReadyReplicasReason is used when some of the replicas aren't ready yet.
+	WaitingForReadyReplicasReason = "WaitingForReadyReplicas"
+
+	// MachineCreationFailedReason is used when a MachineSet fails to create a Machine.
+	MachineCreationFailedReason = "MachineCreationFailed"
+
+	// TooManyUnhealthyReason is used when more Machines than allowed by a MachineHealthCheck are unhealthy.
+	TooManyUnhealthyReason = "TooManyUnhealthy"
+)
+
+// Condition defines an observation of a Cluster API resource operational state.
+type Condition struct {
+	// Type of condition in CamelCase or in foo.example.com/CamelCase.
+	Type ConditionType `json:"type"`
+
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Severity provides an explicit classification of Reason code, so the users or machines can immediately
+	// understand the current situation and act accordingly.
+	// The Severity field MUST be set only when Status=False.
+	// +optional
+	Severity ConditionSeverity `json:"severity,omitempty"`
+
+	// LastTransitionTime is the last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// The reason for the condition's last transition in CamelCase.
+	// The specific API may choose whether or not this field is considered a guaranteed API.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human readable message indicating details about the transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Conditions provide observations of the operational state of a Cluster API resource.
+type Conditions []Condition
@@ -17,15 +17,182 @@ limitations under the License.
 package v1alpha2
 
 import (
-	"errors"
+	"reflect"
 
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	v1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
 )
 
+// Hub marks Cluster as a conversion hub.
+func (*Cluster) Hub() {}
+
+// Hub marks ClusterList as a conversion hub.
+func (*ClusterList) Hub() {}
+
+// Hub marks Machine as a conversion hub.
+func (*Machine) Hub() {}
+
+// Hub marks MachineList as a conversion hub.
+func (*MachineList) Hub() {}
+
+// Hub marks MachineSet as a conversion hub.
+func (*MachineSet) Hub() {}
+
+// Hub marks MachineSetList as a conversion hub.
+func (*MachineSetList) Hub() {}
+
+// Hub marks MachineDeployment as a conversion hub.
+func (*MachineDeployment) Hub() {}
+
+// Hub marks MachineDeploymentList as a conversion hub.
+func (*MachineDeploymentList) Hub() {}
+
+// The conversion functions of the top-level objects keep the fields that don't exist in the
+// target version in the utilconversion.DataAnnotation, and restore them when converting back.
+// The annotation only holds these fields, and is removed once they have been restored.
+
+//nolint
+func Convert_v1alpha2_Cluster_To_v1alpha1_Cluster(in *Cluster, out *v1alpha1.Cluster, s conversion.Scope) error {
+	if err := autoConvert_v1alpha2_Cluster_To_v1alpha1_Cluster(in, out, s); err != nil {
+		return err
+	}
+
+	restored := &v1alpha1.Cluster{}
+	if ok, err := utilconversion.UnmarshalData(in, restored); err != nil {
+		return err
+	} else if ok {
+		out.Spec.ProviderSpec = restored.Spec.ProviderSpec
+		out.Status.ProviderStatus = restored.Status.ProviderStatus
+	}
+	utilconversion.RemoveData(out)
+
+	return utilconversion.MarshalData(&Cluster{
+		Spec: ClusterSpec{
+			ClusterNetwork:    lostClusterNetwork(in.Spec.ClusterNetwork),
+			InfrastructureRef: in.Spec.InfrastructureRef,
+			ControlPlaneRef:   in.Spec.ControlPlaneRef,
+			Paused:            in.Spec.Paused,
+		},
+		Status: ClusterStatus{
+			Phase:                   in.Status.Phase,
+			InfrastructureReady:     in.Status.InfrastructureReady,
+			ControlPlaneInitialized: in.Status.ControlPlaneInitialized,
+			ControlPlaneReady:       in.Status.ControlPlaneReady,
+			FailureDomains:          in.Status.FailureDomains,
+			Conditions:              in.Status.Conditions,
+		},
+	}, out)
+}
+
+//nolint
+func Convert_v1alpha1_Cluster_To_v1alpha2_Cluster(in *v1alpha1.Cluster, out *Cluster, s conversion.Scope) error {
+	if err := autoConvert_v1alpha1_Cluster_To_v1alpha2_Cluster(in, out, s); err != nil {
+		return err
+	}
+
+	restored := &Cluster{}
+	if ok, err := utilconversion.UnmarshalData(in, restored); err != nil {
+		return err
+	} else if ok {
+		out.Spec.InfrastructureRef = restored.Spec.InfrastructureRef
+		out.Spec.ControlPlaneRef = restored.Spec.ControlPlaneRef
+		out.Spec.Paused = restored.Spec.Paused
+		restoreClusterNetwork(&in.Spec.ClusterNetwork, restored.Spec.ClusterNetwork, &out.Spec.ClusterNetwork)
+
+		out.Status.Phase = restored.Status.Phase
+		out.Status.InfrastructureReady = restored.Status.InfrastructureReady
+		out.Status.ControlPlaneInitialized = restored.Status.ControlPlaneInitialized
+		out.Status.ControlPlaneReady = restored.Status.ControlPlaneReady
+		out.Status.FailureDomains = restored.Status.FailureDomains
+		out.Status.Conditions = restored.Status.Conditions
+	}
+	utilconversion.RemoveData(out)
+
+	lost := &v1alpha1.Cluster{
+		Spec:   v1alpha1.ClusterSpec{ProviderSpec: in.Spec.ProviderSpec},
+		Status: v1alpha1.ClusterStatus{ProviderStatus: in.Status.ProviderStatus},
+	}
+	if reflect.DeepEqual(lost, &v1alpha1.Cluster{}) {
+		return nil
+	}
+	return utilconversion.MarshalData(lost, out)
+}
+
+// lostClusterNetwork returns the fields of the ClusterNetwork that v1alpha1 can't represent: the API Server
+// port, and whether the network ranges are set. The network ranges themselves are converted.
+func lostClusterNetwork(in *ClusterNetwork) *ClusterNetwork {
+	if in == nil {
+		return nil
+	}
+
+	lost := &ClusterNetwork{APIServerPort: in.APIServerPort}
+	if in.Pods != nil {
+		lost.Pods = &NetworkRanges{}
+	}
+	if in.Services != nil {
+		lost.Services = &NetworkRanges{}
+	}
+	return lost
+}
+
+// restoreClusterNetwork restores the fields of the ClusterNetwork that v1alpha1 can't represent:
+// the API Server port, and unset network ranges.
+func restoreClusterNetwork(in *v1alpha1.ClusterNetworkingConfig, restored *ClusterNetwork, out **ClusterNetwork) {
+	if restored == nil {
+		if reflect.DeepEqual(*in, v1alpha1.ClusterNetworkingConfig{}) {
+			*out = nil
+		}
+		return
+	}
+
+	(*out).APIServerPort = restored.APIServerPort
+	if restored.Pods == nil && len(in.Pods.CIDRBlocks) == 0 {
+		(*out).Pods = nil
+	}
+	if restored.Services == nil && len(in.Services.CIDRBlocks) == 0 {
+		(*out).Services = nil
+	}
+}
+
 //nolint
 func Convert_v1alpha2_ClusterSpec_To_v1alpha1_ClusterSpec(in *ClusterSpec, out *v1alpha1.ClusterSpec, s conversion.Scope) error {
-	return errors.New("Not implemented")
+	if err := autoConvert_v1alpha2_ClusterSpec_To_v1alpha1_ClusterSpec(in, out, s); err != nil {
+		return err
+	}
+
+	if in.ClusterNetwork != nil {
+		if err := Convert_v1alpha2_ClusterNetwork_To_v1alpha1_ClusterNetworkingConfig(in.ClusterNetwork, &out.ClusterNetwork, s); err != nil {
+			return err
+		}
+	}
+	// DISCARDS:
+	// InfrastructureRef
+	// ControlPlaneRef
+	// Paused
+
+	return nil
+}
+
+//nolint
+func Convert_v1alpha2_ClusterNetwork_To_v1alpha1_ClusterNetworkingConfig(in *ClusterNetwork, out *v1alpha1.ClusterNetworkingConfig, s conversion.Scope) error {
+	if in.Services != nil {
+		if err := Convert_v1alpha2_NetworkRanges_To_v1alpha1_NetworkRanges(in.Services, &out.Services, s); err != nil {
+			return err
+		}
+	}
+
+	if in.Pods != nil {
+		if err := Convert_v1alpha2_NetworkRanges_To_v1alpha1_NetworkRanges(in.Pods, &out.Pods, s); err != nil {
+			return err
+		}
+	}
+
+	out.ServiceDomain = in.ServiceDomain
+	// DISCARDS:
+	// APIServerPort
+
+	return nil
 }
 
 //nolint
@@ -45,6 +212,7 @@ func Convert_v1alpha1_ClusterNetworkingConfig_To_v1alpha2_ClusterNetwork(in *v1a
 
 	out.Pods = &pods
 	out.Services = &services
+	out.ServiceDomain = in.ServiceDomain
 
 	return nil
 }
@@ -66,7 +234,22 @@ func Convert_v1alpha1_ClusterSpec_To_v1alpha2_ClusterSpec(in *v1alpha1.ClusterSp
 
 //nolint
 func Convert_v1alpha2_ClusterStatus_To_v1alpha1_ClusterStatus(in *ClusterStatus, out *v1alpha1.ClusterStatus, s conversion.Scope) error {
-	return errors.New("Not implemented")
+	if err := autoConvert_v1alpha2_ClusterStatus_To_v1alpha1_ClusterStatus(in, out, s); err != nil {
+		return err
+	}
+
+	if in.ErrorReason != nil {
+		out.ErrorReason = *in.ErrorReason
+	}
+	// DISCARDS:
+	// Phase
+	// InfrastructureReady
+	// ControlPlaneInitialized
+	// ControlPlaneReady
+	// FailureDomains
+	// Conditions
+
+	return nil
 }
 
 //nolint
@@ -75,16 +258,150 @@ func Convert_v1alpha1_ClusterStatus_To_v1alpha2_ClusterStatus(in *v1alpha1.Clust
 		return err
 	}
 
-	out.ErrorReason = &in.ErrorReason
+	if in.ErrorReason != "" {
+		out.ErrorReason = &in.ErrorReason
+	}
+	if in.ErrorMessage == "" {
+		out.ErrorMessage = nil
+	}
 	// DISCARDS:
 	// ProviderStatus
 
 	return nil
 }
 
+//nolint
+func Convert_v1alpha2_Machine_To_v1alpha1_Machine(in *Machine, out *v1alpha1.Machine, s conversion.Scope) error {
+	if err := autoConvert_v1alpha2_Machine_To_v1alpha1_Machine(in, out, s); err != nil {
+		return err
+	}
+
+	restored := &v1alpha1.Machine{}
+	if ok, err := utilconversion.UnmarshalData(in, restored); err != nil {
+		return err
+	} else if ok {
+		restoreV1alpha1MachineSpec(&restored.Spec, &out.Spec)
+		if restored.Status.Versions != nil && out.Status.Versions != nil &&
+			machineVersion(restored.Status.Versions) == out.Status.Versions.Kubelet {
+			out.Status.Versions = restored.Status.Versions
+		}
+		out.Status.ProviderStatus = restored.Status.ProviderStatus
+		out.Status.Conditions = restored.Status.Conditions
+		out.Status.LastOperation = restored.Status.LastOperation
+	}
+	utilconversion.RemoveData(out)
+
+	return utilconversion.MarshalData(&Machine{
+		Spec: lostMachineSpec(&in.Spec),
+		Status: MachineStatus{
+			BootstrapReady:      in.Status.BootstrapReady,
+			InfrastructureReady: in.Status.InfrastructureReady,
+			Conditions:          in.Status.Conditions,
+		},
+	}, out)
+}
+
+//nolint
+func Convert_v1alpha1_Machine_To_v1alpha2_Machine(in *v1alpha1.Machine, out *Machine, s conversion.Scope) error {
+	if err := autoConvert_v1alpha1_Machine_To_v1alpha2_Machine(in, out, s); err != nil {
+		return err
+	}
+
+	restored := &Machine{}
+	if ok, err := utilconversion.UnmarshalData(in, restored); err != nil {
+		return err
+	} else if ok {
+		restoreMachineSpec(&restored.Spec, &out.Spec)
+		out.Status.BootstrapReady = restored.Status.BootstrapReady
+		out.Status.InfrastructureReady = restored.Status.InfrastructureReady
+		out.Status.Conditions = restored.Status.Conditions
+	}
+	utilconversion.RemoveData(out)
+
+	lost := &v1alpha1.Machine{
+		Spec: lostV1alpha1MachineSpec(&in.Spec),
+		Status: v1alpha1.MachineStatus{
+			ProviderStatus: in.Status.ProviderStatus,
+			Conditions:     in.Status.Conditions,
+			LastOperation:  in.Status.LastOperation,
+		},
+	}
+	if in.Status.Versions != nil && in.Status.Versions.ControlPlane != "" {
+		lost.Status.Versions = in.Status.Versions
+	}
+	if reflect.DeepEqual(lost, &v1alpha1.Machine{}) {
+		return nil
+	}
+	return utilconversion.MarshalData(lost, out)
+}
+
+// lostMachineSpec returns the fields of a MachineSpec that v1alpha1 can't represent.
+func lostMachineSpec(in *MachineSpec) MachineSpec {
+	return MachineSpec{
+		Bootstrap:         in.Bootstrap,
+		InfrastructureRef: in.InfrastructureRef,
+		FailureDomain:     in.FailureDomain,
+		NodeDrainTimeout:  in.NodeDrainTimeout,
+	}
+}
+
+// lostV1alpha1MachineSpec returns the fields of a v1alpha1 MachineSpec that v1alpha2 can't represent.
+// The versions are only lost if the control plane version is set, v1alpha2 only keeps one of them.
+func lostV1alpha1MachineSpec(in *v1alpha1.MachineSpec) v1alpha1.MachineSpec {
+	lost := v1alpha1.MachineSpec{
+		ProviderSpec: in.ProviderSpec,
+		ConfigSource: in.ConfigSource,
+	}
+	if in.Versions.ControlPlane != "" {
+		lost.Versions = in.Versions
+	}
+	return lost
+}
+
+// restoreMachineSpec restores the fields of a MachineSpec that v1alpha1 can't represent.
+func restoreMachineSpec(restored *MachineSpec, out *MachineSpec) {
+	out.Bootstrap = restored.Bootstrap
+	out.InfrastructureRef = restored.InfrastructureRef
+	out.FailureDomain = restored.FailureDomain
+	out.NodeDrainTimeout = restored.NodeDrainTimeout
+}
+
+// restoreV1alpha1MachineSpec restores the fields of a v1alpha1 MachineSpec that v1alpha2 can't represent.
+func restoreV1alpha1MachineSpec(restored *v1alpha1.MachineSpec, out *v1alpha1.MachineSpec) {
+	out.ProviderSpec = restored.ProviderSpec
+	out.ConfigSource = restored.ConfigSource
+
+	// v1alpha2 only has the Kubelet version, the control plane version is restored unless the version has changed.
+	if machineVersion(&restored.Versions) == out.Versions.Kubelet {
+		out.Versions = restored.Versions
+	}
+}
+
+// machineVersion returns the version of a v1alpha1 Machine as converted to v1alpha2.
+func machineVersion(versions *v1alpha1.MachineVersionInfo) string {
+	if versions.ControlPlane != "" {
+		return versions.ControlPlane
+	}
+	return versions.Kubelet
+}
+
 //nolint
 func Convert_v1alpha2_MachineSpec_To_v1alpha1_MachineSpec(in *MachineSpec, out *v1alpha1.MachineSpec, s conversion.Scope) error {
-	return errors.New("not implemented")
+	if err := autoConvert_v1alpha2_MachineSpec_To_v1alpha1_MachineSpec(in, out, s); err != nil {
+		return err
+	}
+
+	if in.Version != nil {
+		out.Versions.Kubelet = *in.Version
+	}
+
+	// DISCARDS:
+	// Bootstrap
+	// InfrastructureRef
+	// FailureDomain
+	// NodeDrainTimeout
+
+	return nil
 }
 
 //nolint
@@ -100,7 +417,6 @@ func Convert_v1alpha1_MachineSpec_To_v1alpha2_MachineSpec(in *v1alpha1.MachineSp
 	}
 
 	// DISCARDS:
-	// Taints
 	// ProviderSpec
 	// ConfigSource
 
@@ -109,7 +425,22 @@ func Convert_v1alpha1_MachineSpec_To_v1alpha2_MachineSpec(in *v1alpha1.MachineSp
 
 //nolint
 func Convert_v1alpha2_MachineStatus_To_v1alpha1_MachineStatus(in *MachineStatus, out *v1alpha1.MachineStatus, s conversion.Scope) error {
-	return errors.New("not implemented")
+	if err := autoConvert_v1alpha2_MachineStatus_To_v1alpha1_MachineStatus(in, out, s); err != nil {
+		return err
+	}
+
+	if in.Version != nil {
+		out.Versions = &v1alpha1.MachineVersionInfo{Kubelet: *in.Version}
+	}
+	if in.Phase == "" {
+		out.Phase = nil
+	}
+
+	// DISCARDS:
+	// BootstrapReady
+	// InfrastructureReady
+	// Conditions
+	return nil
 }
 
 //nolint
@@ -135,12 +466,116 @@ func Convert_v1alpha1_MachineStatus_To_v1alpha2_MachineStatus(in *v1alpha1.Machi
 	return nil
 }
 
+//nolint
+func Convert_v1alpha2_MachineSet_To_v1alpha1_MachineSet(in *MachineSet, out *v1alpha1.MachineSet, s conversion.Scope) error {
+	if err := autoConvert_v1alpha2_MachineSet_To_v1alpha1_MachineSet(in, out, s); err != nil {
+		return err
+	}
+
+	restored := &v1alpha1.MachineSet{}
+	if ok, err := utilconversion.UnmarshalData(in, restored); err != nil {
+		return err
+	} else if ok {
+		restoreV1alpha1MachineSpec(&restored.Spec.Template.Spec, &out.Spec.Template.Spec)
+	}
+	utilconversion.RemoveData(out)
+
+	lost := &MachineSet{
+		Status: MachineSetStatus{
+			Selector:   in.Status.Selector,
+			Conditions: in.Status.Conditions,
+		},
+	}
+	lost.Spec.Template.Spec = lostMachineSpec(&in.Spec.Template.Spec)
+	return utilconversion.MarshalData(lost, out)
+}
+
+//nolint
+func Convert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(in *v1alpha1.MachineSet, out *MachineSet, s conversion.Scope) error {
+	if err := autoConvert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(in, out, s); err != nil {
+		return err
+	}
+
+	restored := &MachineSet{}
+	if ok, err := utilconversion.UnmarshalData(in, restored); err != nil {
+		return err
+	} else if ok {
+		restoreMachineSpec(&restored.Spec.Template.Spec, &out.Spec.Template.Spec)
+		out.Status.Selector = restored.Status.Selector
+		out.Status.Conditions = restored.Status.Conditions
+	}
+	utilconversion.RemoveData(out)
+
+	lost := &v1alpha1.MachineSet{}
+	lost.Spec.Template.Spec = lostV1alpha1MachineSpec(&in.Spec.Template.Spec)
+	if reflect.DeepEqual(lost, &v1alpha1.MachineSet{}) {
+		return nil
+	}
+	return utilconversion.MarshalData(lost, out)
+}
+
 //nolint
 func Convert_v1alpha2_MachineSetStatus_To_v1alpha1_MachineSetStatus(in *MachineSetStatus, out *v1alpha1.MachineSetStatus, s conversion.Scope) error {
-	return errors.New("Not implemented")
+	// DISCARDS:
+	// Selector
+	// Conditions
+	return autoConvert_v1alpha2_MachineSetStatus_To_v1alpha1_MachineSetStatus(in, out, s)
+}
+
+//nolint
+func Convert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(in *MachineDeployment, out *v1alpha1.MachineDeployment, s conversion.Scope) error {
+	if err := autoConvert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(in, out, s); err != nil {
+		return err
+	}
+
+	restored := &v1alpha1.MachineDeployment{}
+	if ok, err := utilconversion.UnmarshalData(in, restored); err != nil {
+		return err
+	} else if ok {
+		restoreV1alpha1MachineSpec(&restored.Spec.Template.Spec, &out.Spec.Template.Spec)
+	}
+	utilconversion.RemoveData(out)
+
+	lost := &MachineDeployment{
+		Spec: MachineDeploymentSpec{RollbackTo: in.Spec.RollbackTo},
+		Status: MachineDeploymentStatus{
+			Selector:   in.Status.Selector,
+			Conditions: in.Status.Conditions,
+		},
+	}
+	lost.Spec.Template.Spec = lostMachineSpec(&in.Spec.Template.Spec)
+	return utilconversion.MarshalData(lost, out)
+}
+
+//nolint
+func Convert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(in *v1alpha1.MachineDeployment, out *MachineDeployment, s conversion.Scope) error {
+	if err := autoConvert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(in, out, s); err != nil {
+		return err
+	}
+
+	restored := &MachineDeployment{}
+	if ok, err := utilconversion.UnmarshalData(in, restored); err != nil {
+		return err
+	} else if ok {
+		restoreMachineSpec(&restored.Spec.Template.Spec, &out.Spec.Template.Spec)
+		out.Spec.RollbackTo = restored.Spec.RollbackTo
+		out.Status.Selector = restored.Status.Selector
+		out.Status.Conditions = restored.Status.Conditions
+	}
+	utilconversion.RemoveData(out)
+
+	lost := &v1alpha1.MachineDeployment{}
+	lost.Spec.Template.Spec = lostV1alpha1MachineSpec(&in.Spec.Template.Spec)
+	if reflect.DeepEqual(lost, &v1alpha1.MachineDeployment{}) {
+		return nil
+	}
+	return utilconversion.MarshalData(lost, out)
 }
 
 //nolint
 func Convert_v1alpha2_MachineDeploymentStatus_To_v1alpha1_MachineDeploymentStatus(in *MachineDeploymentStatus, out *v1alpha1.MachineDeploymentStatus, s conversion.Scope) error {
-	return errors.New("Not implemented")
+	// DISCARDS:
+	// Selector
+	// Conditions
+	return autoConvert_v1alpha2_MachineDeploymentStatus_To_v1alpha1_MachineDeploymentStatus(in, out, s)
 }
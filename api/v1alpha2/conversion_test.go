@@ -0,0 +1,218 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	v1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+)
+
+func TestConvertCluster(t *testing.T) {
+	t.Run("v1alpha2 round trip", func(t *testing.T) {
+		g := gomega.NewGomegaWithT(t)
+
+		errorReason := capierrors.InvalidConfigurationClusterError
+		in := &Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+			Spec: ClusterSpec{
+				ClusterNetwork: &ClusterNetwork{
+					APIServerPort: pointer.Int32Ptr(6443),
+					Pods:          &NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+					ServiceDomain: "cluster.local",
+				},
+				InfrastructureRef: &corev1.ObjectReference{Kind: "InfraCluster", Name: "infra", Namespace: "default"},
+			},
+			Status: ClusterStatus{
+				ErrorReason:         &errorReason,
+				Phase:               string(ClusterPhaseProvisioned),
+				InfrastructureReady: true,
+			},
+		}
+
+		converted := &v1alpha1.Cluster{}
+		g.Expect(Convert_v1alpha2_Cluster_To_v1alpha1_Cluster(in, converted, nil)).To(gomega.Succeed())
+		g.Expect(converted.Spec.ClusterNetwork.Pods.CIDRBlocks).To(gomega.Equal([]string{"192.168.0.0/16"}))
+		g.Expect(converted.Spec.ClusterNetwork.ServiceDomain).To(gomega.Equal("cluster.local"))
+		g.Expect(converted.Status.ErrorReason).To(gomega.Equal(errorReason))
+		g.Expect(converted.Annotations).To(gomega.HaveKey(utilconversion.DataAnnotation))
+
+		out := &Cluster{}
+		g.Expect(Convert_v1alpha1_Cluster_To_v1alpha2_Cluster(converted, out, nil)).To(gomega.Succeed())
+		g.Expect(out.Annotations).ToNot(gomega.HaveKey(utilconversion.DataAnnotation))
+		g.Expect(out).To(gomega.Equal(in))
+	})
+
+	t.Run("v1alpha1 round trip", func(t *testing.T) {
+		g := gomega.NewGomegaWithT(t)
+
+		in := &v1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+			Spec: v1alpha1.ClusterSpec{
+				ClusterNetwork: v1alpha1.ClusterNetworkingConfig{
+					Services:      v1alpha1.NetworkRanges{CIDRBlocks: []string{"10.96.0.0/12"}},
+					Pods:          v1alpha1.NetworkRanges{CIDRBlocks: []string{"192.168.0.0/16"}},
+					ServiceDomain: "cluster.local",
+				},
+				ProviderSpec: v1alpha1.ProviderSpec{Value: &runtime.RawExtension{Raw: []byte(`{"region":"us-east-1"}`)}},
+			},
+			Status: v1alpha1.ClusterStatus{
+				ProviderStatus: &runtime.RawExtension{Raw: []byte(`{"ready":true}`)},
+			},
+		}
+
+		out := &Cluster{}
+		g.Expect(Convert_v1alpha1_Cluster_To_v1alpha2_Cluster(in, out, nil)).To(gomega.Succeed())
+		g.Expect(out.Status.ErrorReason).To(gomega.BeNil())
+
+		// Only the fields that v1alpha2 can't represent are kept.
+		data := &v1alpha1.Cluster{}
+		expectConversionData(g, out, data)
+		g.Expect(data.Spec).To(gomega.Equal(v1alpha1.ClusterSpec{ProviderSpec: in.Spec.ProviderSpec}))
+		g.Expect(data.Status).To(gomega.Equal(v1alpha1.ClusterStatus{ProviderStatus: in.Status.ProviderStatus}))
+
+		restored := &v1alpha1.Cluster{}
+		g.Expect(Convert_v1alpha2_Cluster_To_v1alpha1_Cluster(out, restored, nil)).To(gomega.Succeed())
+		g.Expect(restored.Spec).To(gomega.Equal(in.Spec))
+		g.Expect(restored.Status).To(gomega.Equal(in.Status))
+	})
+}
+
+func TestConvertMachine(t *testing.T) {
+	t.Run("v1alpha2 round trip", func(t *testing.T) {
+		g := gomega.NewGomegaWithT(t)
+
+		in := &Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "default"},
+			Spec: MachineSpec{
+				Bootstrap: Bootstrap{
+					ConfigRef:      &corev1.ObjectReference{Kind: "BootstrapConfig", Name: "bootstrap", Namespace: "default"},
+					DataSecretName: pointer.StringPtr("bootstrap-data"),
+				},
+				InfrastructureRef: corev1.ObjectReference{Kind: "InfraMachine", Name: "infra", Namespace: "default"},
+				Version:           pointer.StringPtr("v1.16.3"),
+				FailureDomain:     pointer.StringPtr("us-east-1a"),
+			},
+			Status: MachineStatus{
+				Version:        pointer.StringPtr("v1.16.3"),
+				BootstrapReady: true,
+				Phase:          string(MachinePhaseRunning),
+			},
+		}
+
+		converted := &v1alpha1.Machine{}
+		g.Expect(Convert_v1alpha2_Machine_To_v1alpha1_Machine(in, converted, nil)).To(gomega.Succeed())
+		g.Expect(converted.Spec.Versions.Kubelet).To(gomega.Equal("v1.16.3"))
+
+		out := &Machine{}
+		g.Expect(Convert_v1alpha1_Machine_To_v1alpha2_Machine(converted, out, nil)).To(gomega.Succeed())
+		g.Expect(out.Annotations).ToNot(gomega.HaveKey(utilconversion.DataAnnotation))
+		g.Expect(out).To(gomega.Equal(in))
+	})
+
+	t.Run("v1alpha1 round trip", func(t *testing.T) {
+		g := gomega.NewGomegaWithT(t)
+
+		in := &v1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "default"},
+			Spec: v1alpha1.MachineSpec{
+				ProviderSpec: v1alpha1.ProviderSpec{Value: &runtime.RawExtension{Raw: []byte(`{"instanceType":"m5.large"}`)}},
+				Versions:     v1alpha1.MachineVersionInfo{Kubelet: "v1.15.3", ControlPlane: "v1.15.3"},
+			},
+			Status: v1alpha1.MachineStatus{
+				ProviderStatus: &runtime.RawExtension{Raw: []byte(`{"instanceID":"i-1234"}`)},
+			},
+		}
+
+		out := &Machine{}
+		g.Expect(Convert_v1alpha1_Machine_To_v1alpha2_Machine(in, out, nil)).To(gomega.Succeed())
+		g.Expect(out.Spec.Version).To(gomega.Equal(pointer.StringPtr("v1.15.3")))
+
+		// Only the fields that v1alpha2 can't represent are kept.
+		data := &v1alpha1.Machine{}
+		expectConversionData(g, out, data)
+		g.Expect(data.Spec).To(gomega.Equal(v1alpha1.MachineSpec{ProviderSpec: in.Spec.ProviderSpec, Versions: in.Spec.Versions}))
+		g.Expect(data.Status).To(gomega.Equal(v1alpha1.MachineStatus{ProviderStatus: in.Status.ProviderStatus}))
+
+		restored := &v1alpha1.Machine{}
+		g.Expect(Convert_v1alpha2_Machine_To_v1alpha1_Machine(out, restored, nil)).To(gomega.Succeed())
+		g.Expect(restored.Spec).To(gomega.Equal(in.Spec))
+		g.Expect(restored.Status).To(gomega.Equal(in.Status))
+
+		// Changing the version in v1alpha2 doesn't restore the old control plane version.
+		out.Spec.Version = pointer.StringPtr("v1.16.3")
+		restored = &v1alpha1.Machine{}
+		g.Expect(Convert_v1alpha2_Machine_To_v1alpha1_Machine(out, restored, nil)).To(gomega.Succeed())
+		g.Expect(restored.Spec.Versions).To(gomega.Equal(v1alpha1.MachineVersionInfo{Kubelet: "v1.16.3"}))
+	})
+}
+
+func TestConvertMachineDeployment(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	in := &MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "md-1", Namespace: "default"},
+		Spec: MachineDeploymentSpec{
+			Replicas:   pointer.Int32Ptr(3),
+			RollbackTo: &RollbackConfig{Revision: 2},
+			Template: MachineTemplateSpec{
+				Spec: MachineSpec{
+					Bootstrap:         Bootstrap{ConfigRef: &corev1.ObjectReference{Kind: "BootstrapConfigTemplate", Name: "bootstrap"}},
+					InfrastructureRef: corev1.ObjectReference{Kind: "InfraMachineTemplate", Name: "infra"},
+				},
+			},
+		},
+		Status: MachineDeploymentStatus{Selector: "foo=bar", Replicas: 3},
+	}
+
+	converted := &v1alpha1.MachineDeployment{}
+	g.Expect(Convert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(in, converted, nil)).To(gomega.Succeed())
+
+	out := &MachineDeployment{}
+	g.Expect(Convert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(converted, out, nil)).To(gomega.Succeed())
+	g.Expect(out.Annotations).ToNot(gomega.HaveKey(utilconversion.DataAnnotation))
+	g.Expect(out).To(gomega.Equal(in))
+}
+
+func TestConvertMachineWithoutLostFields(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	in := &v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "default"},
+		Spec: v1alpha1.MachineSpec{
+			Versions: v1alpha1.MachineVersionInfo{Kubelet: "v1.15.3"},
+		},
+	}
+
+	out := &Machine{}
+	g.Expect(Convert_v1alpha1_Machine_To_v1alpha2_Machine(in, out, nil)).To(gomega.Succeed())
+	g.Expect(out.Annotations).ToNot(gomega.HaveKey(utilconversion.DataAnnotation))
+}
+
+// expectConversionData unmarshals the conversion data annotation of the given object, which must be set.
+func expectConversionData(g *gomega.WithT, o metav1.Object, data interface{}) {
+	ok, err := utilconversion.UnmarshalData(o, data)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(ok).To(gomega.BeTrue())
+}
@@ -21,8 +21,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-// PopulateDefaultsMachineDeployment fills in default field values
-// Currently it is called after reading objects, but it could be called in an admission webhook also
+// PopulateDefaultsMachineDeployment fills in default field values.
+// It's called by the defaulting webhook, and after reading objects that were admitted without it.
 func PopulateDefaultsMachineDeployment(d *MachineDeployment) {
 	if d.Spec.Replicas == nil {
 		d.Spec.Replicas = new(int32)
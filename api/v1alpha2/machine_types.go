@@ -31,6 +31,18 @@ const (
 
 	// MachineControlPlaneLabelName is the label set on machines part of a control plane.
 	MachineControlPlaneLabelName = "cluster.x-k8s.io/control-plane"
+
+	// ExcludeNodeDrainingAnnotation annotation explicitly skips node draining if set.
+	ExcludeNodeDrainingAnnotation = "machine.cluster.x-k8s.io/exclude-node-draining"
+
+	// LabelsFromMachineAnnotation is set on Nodes to track the labels that are managed by the Machine controller.
+	LabelsFromMachineAnnotation = "cluster.x-k8s.io/labels-from-machine"
+
+	// AnnotationsFromMachineAnnotation is set on Nodes to track the annotations that are managed by the Machine controller.
+	AnnotationsFromMachineAnnotation = "cluster.x-k8s.io/annotations-from-machine"
+
+	// TaintsFromMachineAnnotation is set on Nodes to track the taints that are managed by the Machine controller.
+	TaintsFromMachineAnnotation = "cluster.x-k8s.io/taints-from-machine"
 )
 
 /// [MachineSpec]
@@ -42,6 +54,11 @@ type MachineSpec struct {
 	// +optional
 	ObjectMeta `json:"metadata,omitempty"`
 
+	// Taints are the taints to apply to the Node of the Machine.
+	// Taints added to the Node by other components are left untouched.
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
+
 	// Bootstrap is a reference to a local struct which encapsulates
 	// fields to configure the Machine’s bootstrapping mechanism.
 	Bootstrap Bootstrap `json:"bootstrap"`
@@ -67,6 +84,18 @@ type MachineSpec struct {
 	// be interfacing with cluster-api as generic provider.
 	// +optional
 	ProviderID *string `json:"providerID,omitempty"`
+
+	// FailureDomain is the failure domain the machine will be created in.
+	// Must match a key in the FailureDomains map stored on the cluster object.
+	// +optional
+	FailureDomain *string `json:"failureDomain,omitempty"`
+
+	// NodeDrainTimeout is the total amount of time that the controller will spend on draining a node,
+	// measured from the time the Machine was marked for deletion.
+	// The default value is 0, meaning that the node can be drained without any time limitations.
+	// Draining can be skipped entirely by setting the ExcludeNodeDrainingAnnotation on the Machine.
+	// +optional
+	NodeDrainTimeout *metav1.Duration `json:"nodeDrainTimeout,omitempty"`
 }
 
 /// [MachineSpec]
@@ -144,6 +173,10 @@ type MachineStatus struct {
 	// InfrastructureReady is the state of the infrastructure provider.
 	// +optional
 	InfrastructureReady bool `json:"infrastructureReady"`
+
+	// Conditions defines current service state of the Machine.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 // SetTypedPhase sets the Phase field to the string representation of MachinePhase.
@@ -176,15 +209,23 @@ func (m *MachineStatus) GetTypedPhase() MachinePhase {
 type Bootstrap struct {
 	// ConfigRef is a reference to a bootstrap provider-specific resource
 	// that holds configuration details. The reference is optional to
-	// allow users/operators to specify Bootstrap.Data without
+	// allow users/operators to specify Bootstrap.DataSecretName without
 	// the need of a controller.
 	// +optional
 	ConfigRef *corev1.ObjectReference `json:"configRef,omitempty"`
 
 	// Data contains the bootstrap data, such as cloud-init details scripts.
 	// If nil, the Machine should remain in the Pending state.
+	//
+	// Deprecated: This field has been deprecated in favor of DataSecretName and will be removed
+	// in a future version. Inline data is moved to a Secret by the controller.
 	// +optional
 	Data *string `json:"data,omitempty"`
+
+	// DataSecretName is the name of the Secret, in the same namespace, that stores the bootstrap data
+	// under the "value" key. If nil and Data is nil, the Machine should remain in the Pending state.
+	// +optional
+	DataSecretName *string `json:"dataSecretName,omitempty"`
 }
 
 /// [Bootstrap]
@@ -209,6 +250,16 @@ type Machine struct {
 
 /// [Machine]
 
+// GetConditions returns the set of conditions for this object.
+func (m *Machine) GetConditions() Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *Machine) SetConditions(conditions Conditions) {
+	m.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MachineList contains a list of Machine
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestMachineDefault(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	m := &Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "foobar"},
+		Spec: MachineSpec{
+			Bootstrap:         Bootstrap{ConfigRef: &corev1.ObjectReference{Name: "bootstrap"}},
+			InfrastructureRef: corev1.ObjectReference{Name: "infra"},
+		},
+	}
+	m.Default()
+
+	g.Expect(m.Spec.Bootstrap.ConfigRef.Namespace).To(gomega.Equal("foobar"))
+	g.Expect(m.Spec.InfrastructureRef.Namespace).To(gomega.Equal("foobar"))
+}
+
+func TestMachineValidation(t *testing.T) {
+	newMachine := func(infraName string, bootstrap Bootstrap) *Machine {
+		return &Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "machine-1",
+				Namespace: "foobar",
+				Labels:    map[string]string{MachineClusterLabelName: "test-cluster"},
+			},
+			Spec: MachineSpec{
+				Bootstrap:         bootstrap,
+				InfrastructureRef: corev1.ObjectReference{Name: infraName, Namespace: "foobar"},
+			},
+		}
+	}
+	withData := Bootstrap{DataSecretName: pointer.StringPtr("bootstrap-data")}
+
+	testCases := []struct {
+		name      string
+		old       *Machine
+		machine   *Machine
+		expectErr bool
+	}{
+		{
+			name:    "valid machine",
+			machine: newMachine("infra", withData),
+		},
+		{
+			name:      "missing bootstrap configuration",
+			machine:   newMachine("infra", Bootstrap{}),
+			expectErr: true,
+		},
+		{
+			name:    "setting the bootstrap data",
+			old:     newMachine("infra", Bootstrap{ConfigRef: &corev1.ObjectReference{Name: "bootstrap", Namespace: "foobar"}}),
+			machine: newMachine("infra", Bootstrap{ConfigRef: &corev1.ObjectReference{Name: "bootstrap", Namespace: "foobar"}, DataSecretName: pointer.StringPtr("bootstrap-data")}),
+		},
+		{
+			name:      "changing the infrastructure reference",
+			old:       newMachine("infra", withData),
+			machine:   newMachine("other-infra", withData),
+			expectErr: true,
+		},
+		{
+			name: "changing the cluster",
+			old:  newMachine("infra", withData),
+			machine: func() *Machine {
+				m := newMachine("infra", withData)
+				m.Labels[MachineClusterLabelName] = "other-cluster"
+				return m
+			}(),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			var err error
+			if tc.old == nil {
+				err = tc.machine.ValidateCreate()
+			} else {
+				err = tc.machine.ValidateUpdate(tc.old)
+			}
+
+			if tc.expectErr {
+				g.Expect(err).To(gomega.HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(gomega.HaveOccurred())
+			}
+		})
+	}
+}
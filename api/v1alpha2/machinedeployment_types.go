@@ -27,6 +27,10 @@ const (
 	// Replace the old MachineSet by new one using rolling update
 	// i.e. gradually scale down the old MachineSet and scale up the new one.
 	RollingUpdateMachineDeploymentStrategyType MachineDeploymentStrategyType = "RollingUpdate"
+
+	// Delete all the old MachineSet's machines before creating the new ones,
+	// i.e. scale down the old MachineSets to zero and scale up the new one once they're gone.
+	RecreateMachineDeploymentStrategyType MachineDeploymentStrategyType = "Recreate"
 )
 
 /// [MachineDeploymentSpec]
@@ -66,6 +70,10 @@ type MachineDeploymentSpec struct {
 	// +optional
 	Paused bool `json:"paused,omitempty"`
 
+	// The config this deployment is rolling back to. Will be cleared after rollback is done.
+	// +optional
+	RollbackTo *RollbackConfig `json:"rollbackTo,omitempty"`
+
 	// The maximum time in seconds for a deployment to make progress before it
 	// is considered to be failed. The deployment controller will continue to
 	// process failed deployments and a condition with a ProgressDeadlineExceeded
@@ -76,12 +84,21 @@ type MachineDeploymentSpec struct {
 
 /// [MachineDeploymentSpec]
 
+/// [RollbackConfig]
+// RollbackConfig describes the revision a MachineDeployment is rolled back to.
+type RollbackConfig struct {
+	// The revision to rollback to. If set to 0, rollback to the last revision.
+	// +optional
+	Revision int64 `json:"revision,omitempty"`
+}
+
+/// [RollbackConfig]
+
 /// [MachineDeploymentStrategy]
 // MachineDeploymentStrategy describes how to replace existing machines
 // with new ones.
 type MachineDeploymentStrategy struct {
-	// Type of deployment. Currently the only supported strategy is
-	// "RollingUpdate".
+	// Type of deployment. Can be "RollingUpdate" or "Recreate".
 	// Default is RollingUpdate.
 	// +optional
 	Type MachineDeploymentStrategyType `json:"type,omitempty"`
@@ -170,6 +187,10 @@ type MachineDeploymentStatus struct {
 	// that still have not been created.
 	// +optional
 	UnavailableReplicas int32 `json:"unavailableReplicas,omitempty" protobuf:"varint,5,opt,name=unavailableReplicas"`
+
+	// Conditions defines current service state of the MachineDeployment.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 /// [MachineDeploymentStatus]
@@ -192,6 +213,16 @@ type MachineDeployment struct {
 
 /// [MachineDeployment]
 
+// GetConditions returns the set of conditions for this object.
+func (m *MachineDeployment) GetConditions() Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *MachineDeployment) SetConditions(conditions Conditions) {
+	m.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MachineDeploymentList contains a list of MachineDeployment
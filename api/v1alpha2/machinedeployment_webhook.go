@@ -0,0 +1,78 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks for MachineDeployments with the manager.
+func (m *MachineDeployment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(m).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha2-machinedeployment,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=machinedeployments,versions=v1alpha2,name=validation.machinedeployment.cluster.x-k8s.io
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-x-k8s-io-v1alpha2-machinedeployment,mutating=true,failurePolicy=fail,groups=cluster.x-k8s.io,resources=machinedeployments,versions=v1alpha2,name=default.machinedeployment.cluster.x-k8s.io
+
+var _ webhook.Defaulter = &MachineDeployment{}
+var _ webhook.Validator = &MachineDeployment{}
+
+// Default satisfies the defaulting webhook interface.
+func (m *MachineDeployment) Default() {
+	PopulateDefaultsMachineDeployment(m)
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (m *MachineDeployment) ValidateCreate() error {
+	return m.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (m *MachineDeployment) ValidateUpdate(old runtime.Object) error {
+	oldMachineDeployment, ok := old.(*MachineDeployment)
+	if !ok {
+		return apierrors.NewBadRequest("expected a MachineDeployment")
+	}
+	return m.validate(oldMachineDeployment)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (m *MachineDeployment) ValidateDelete() error {
+	return nil
+}
+
+func (m *MachineDeployment) validate(old *MachineDeployment) error {
+	specPath := field.NewPath("spec")
+	allErrs := validateSelectorMatchesTemplate(&m.Spec.Selector, m.Spec.Template.Labels, specPath, "MachineDeployment")
+
+	if old != nil && !reflect.DeepEqual(old.Spec.Selector, m.Spec.Selector) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("selector"), m.Spec.Selector, "field is immutable"))
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("MachineDeployment").GroupKind(), m.Name, allErrs)
+}
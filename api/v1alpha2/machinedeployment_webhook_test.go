@@ -0,0 +1,60 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMachineDeploymentDefault(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	md := &MachineDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-md", Namespace: "foobar"}}
+	md.Default()
+
+	g.Expect(*md.Spec.Replicas).To(gomega.Equal(int32(1)))
+	g.Expect(*md.Spec.ProgressDeadlineSeconds).To(gomega.Equal(int32(600)))
+	g.Expect(md.Spec.Strategy.Type).To(gomega.Equal(RollingUpdateMachineDeploymentStrategyType))
+}
+
+func TestMachineDeploymentValidation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	md := &MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-md", Namespace: "foobar"},
+		Spec: MachineDeploymentSpec{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}},
+			Template: MachineTemplateSpec{
+				ObjectMeta: ObjectMeta{Labels: map[string]string{"foo": "bar"}},
+			},
+		},
+	}
+	g.Expect(md.ValidateCreate()).To(gomega.Succeed())
+
+	mismatch := md.DeepCopy()
+	mismatch.Spec.Template.Labels = map[string]string{"foo": "baz"}
+	g.Expect(mismatch.ValidateCreate()).ToNot(gomega.Succeed())
+
+	// The selector can't be changed once the MachineDeployment has been created.
+	updated := md.DeepCopy()
+	updated.Spec.Selector.MatchLabels = map[string]string{"baz": "qux"}
+	updated.Spec.Template.Labels = map[string]string{"baz": "qux"}
+	g.Expect(updated.ValidateUpdate(md)).ToNot(gomega.Succeed())
+}
@@ -17,11 +17,7 @@ limitations under the License.
 package v1alpha2
 
 import (
-	"log"
-
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 )
@@ -41,8 +37,9 @@ type MachineSetSpec struct {
 	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
 
 	// DeletePolicy defines the policy used to identify nodes to delete when downscaling.
-	// Defaults to "Random".  Valid values are "Random, "Newest", "Oldest"
-	// +kubebuilder:validation:Enum=Random;Newest;Oldest
+	// Defaults to "Random".  Valid values are "Random, "Newest", "Oldest", "UnreadyNode",
+	// "NoNodeRef", "FewestPods", "MostCrowdedFailureDomain", and the policies registered by
+	// the controller manager.
 	DeletePolicy string `json:"deletePolicy,omitempty"`
 
 	// Selector is a label query over machines that should match the replica count.
@@ -98,6 +95,30 @@ const (
 	// (Status.ErrorReason or Status.ErrorMessage are set to a non-empty value).
 	// It then prioritizes the oldest Machines for deletion based on the Machine's CreationTimestamp.
 	OldestMachineSetDeletePolicy MachineSetDeletePolicy = "Oldest"
+
+	// UnreadyNodeMachineSetDeletePolicy prioritizes both Machines that have the annotation
+	// "cluster.x-k8s.io/delete-machine=yes" and Machines that are unhealthy
+	// (Status.ErrorReason or Status.ErrorMessage are set to a non-empty value).
+	// It then prioritizes the Machines whose Node isn't ready.
+	UnreadyNodeMachineSetDeletePolicy MachineSetDeletePolicy = "UnreadyNode"
+
+	// NoNodeRefMachineSetDeletePolicy prioritizes both Machines that have the annotation
+	// "cluster.x-k8s.io/delete-machine=yes" and Machines that are unhealthy
+	// (Status.ErrorReason or Status.ErrorMessage are set to a non-empty value).
+	// It then prioritizes the Machines that don't have a Node yet (Status.NodeRef isn't set).
+	NoNodeRefMachineSetDeletePolicy MachineSetDeletePolicy = "NoNodeRef"
+
+	// FewestPodsMachineSetDeletePolicy prioritizes both Machines that have the annotation
+	// "cluster.x-k8s.io/delete-machine=yes" and Machines that are unhealthy
+	// (Status.ErrorReason or Status.ErrorMessage are set to a non-empty value).
+	// It then prioritizes the Machines whose Node runs the fewest pods.
+	FewestPodsMachineSetDeletePolicy MachineSetDeletePolicy = "FewestPods"
+
+	// MostCrowdedFailureDomainMachineSetDeletePolicy prioritizes both Machines that have the annotation
+	// "cluster.x-k8s.io/delete-machine=yes" and Machines that are unhealthy
+	// (Status.ErrorReason or Status.ErrorMessage are set to a non-empty value).
+	// It then prioritizes the Machines in the failure domain with the most Machines of the MachineSet.
+	MostCrowdedFailureDomainMachineSetDeletePolicy MachineSetDeletePolicy = "MostCrowdedFailureDomain"
 )
 
 /// [MachineSetStatus]
@@ -150,35 +171,19 @@ type MachineSetStatus struct {
 	ErrorReason *capierrors.MachineSetStatusError `json:"errorReason,omitempty"`
 	// +optional
 	ErrorMessage *string `json:"errorMessage,omitempty"`
+
+	// Conditions defines current service state of the MachineSet.
+	// +optional
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
 // Validate validates the MachineSet fields.
 func (m *MachineSet) Validate() field.ErrorList {
-	errors := field.ErrorList{}
-
-	// validate spec.selector and spec.template.labels
-	fldPath := field.NewPath("spec")
-	errors = append(errors, metav1validation.ValidateLabelSelector(&m.Spec.Selector, fldPath.Child("selector"))...)
-	if len(m.Spec.Selector.MatchLabels)+len(m.Spec.Selector.MatchExpressions) == 0 {
-		errors = append(errors, field.Invalid(fldPath.Child("selector"), m.Spec.Selector, "empty selector is not valid for MachineSet."))
-	}
-	selector, err := metav1.LabelSelectorAsSelector(&m.Spec.Selector)
-	if err != nil {
-		errors = append(errors, field.Invalid(fldPath.Child("selector"), m.Spec.Selector, "invalid label selector."))
-	} else {
-		labels := labels.Set(m.Spec.Template.Labels)
-		if !selector.Matches(labels) {
-			errors = append(errors, field.Invalid(fldPath.Child("template", "metadata", "labels"), m.Spec.Template.Labels, "`selector` does not match template `labels`"))
-		}
-	}
-
-	return errors
+	return validateSelectorMatchesTemplate(&m.Spec.Selector, m.Spec.Template.Labels, field.NewPath("spec"), "MachineSet")
 }
 
-// DefaultingFunction sets default MachineSet field values.
+// Default sets default MachineSet field values.
 func (m *MachineSet) Default() {
-	log.Printf("Defaulting fields for MachineSet %s\n", m.Name)
-
 	if m.Spec.Replicas == nil {
 		m.Spec.Replicas = new(int32)
 		*m.Spec.Replicas = 1
@@ -189,9 +194,7 @@ func (m *MachineSet) Default() {
 	}
 
 	if m.Spec.DeletePolicy == "" {
-		randomPolicy := string(RandomMachineSetDeletePolicy)
-		log.Printf("Defaulting to %s\n", randomPolicy)
-		m.Spec.DeletePolicy = randomPolicy
+		m.Spec.DeletePolicy = string(RandomMachineSetDeletePolicy)
 	}
 }
 
@@ -215,6 +218,16 @@ type MachineSet struct {
 
 /// [MachineSet]
 
+// GetConditions returns the set of conditions for this object.
+func (m *MachineSet) GetConditions() Conditions {
+	return m.Status.Conditions
+}
+
+// SetConditions sets the conditions on this object.
+func (m *MachineSet) SetConditions(conditions Conditions) {
+	m.Status.Conditions = conditions
+}
+
 // +kubebuilder:object:root=true
 
 // MachineSetList contains a list of MachineSet
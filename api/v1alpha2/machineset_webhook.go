@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks for MachineSets with the manager.
+func (m *MachineSet) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(m).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-x-k8s-io-v1alpha2-machineset,mutating=false,failurePolicy=fail,groups=cluster.x-k8s.io,resources=machinesets,versions=v1alpha2,name=validation.machineset.cluster.x-k8s.io
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-x-k8s-io-v1alpha2-machineset,mutating=true,failurePolicy=fail,groups=cluster.x-k8s.io,resources=machinesets,versions=v1alpha2,name=default.machineset.cluster.x-k8s.io
+
+var _ webhook.Defaulter = &MachineSet{}
+var _ webhook.Validator = &MachineSet{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (m *MachineSet) ValidateCreate() error {
+	return m.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (m *MachineSet) ValidateUpdate(old runtime.Object) error {
+	oldMachineSet, ok := old.(*MachineSet)
+	if !ok {
+		return apierrors.NewBadRequest("expected a MachineSet")
+	}
+	return m.validate(oldMachineSet)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (m *MachineSet) ValidateDelete() error {
+	return nil
+}
+
+func (m *MachineSet) validate(old *MachineSet) error {
+	allErrs := m.Validate()
+
+	if old != nil && !reflect.DeepEqual(old.Spec.Selector, m.Spec.Selector) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "selector"), m.Spec.Selector, "field is immutable"))
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("MachineSet").GroupKind(), m.Name, allErrs)
+}
+
+// validateSelectorMatchesTemplate checks that the selector is valid and non-empty, and that it matches
+// the labels of the Machine template.
+func validateSelectorMatchesTemplate(selector *metav1.LabelSelector, templateLabels map[string]string, fldPath *field.Path, kind string) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, metav1validation.ValidateLabelSelector(selector, fldPath.Child("selector"))...)
+	if len(selector.MatchLabels)+len(selector.MatchExpressions) == 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), selector, fmt.Sprintf("empty selector is not valid for %s.", kind)))
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), selector, "invalid label selector."))
+	} else if !s.Matches(labels.Set(templateLabels)) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("template", "metadata", "labels"), templateLabels, "`selector` does not match template `labels`"))
+	}
+
+	return allErrs
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMachineSetDefault(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ms := &MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "test-ms"}}
+	ms.Default()
+
+	g.Expect(ms.Namespace).To(gomega.Equal(metav1.NamespaceDefault))
+	g.Expect(*ms.Spec.Replicas).To(gomega.Equal(int32(1)))
+	g.Expect(ms.Spec.DeletePolicy).To(gomega.Equal(string(RandomMachineSetDeletePolicy)))
+}
+
+func TestMachineSetValidation(t *testing.T) {
+	newMachineSet := func(selector, template map[string]string) *MachineSet {
+		return &MachineSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ms", Namespace: "foobar"},
+			Spec: MachineSetSpec{
+				Selector: metav1.LabelSelector{MatchLabels: selector},
+				Template: MachineTemplateSpec{
+					ObjectMeta: ObjectMeta{Labels: template},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		old        *MachineSet
+		machineSet *MachineSet
+		expectErr  bool
+	}{
+		{
+			name:       "selector matching the template labels",
+			machineSet: newMachineSet(map[string]string{"foo": "bar"}, map[string]string{"foo": "bar", "baz": "qux"}),
+		},
+		{
+			name:       "selector not matching the template labels",
+			machineSet: newMachineSet(map[string]string{"foo": "bar"}, map[string]string{"foo": "baz"}),
+			expectErr:  true,
+		},
+		{
+			name:       "empty selector",
+			machineSet: newMachineSet(nil, map[string]string{"foo": "bar"}),
+			expectErr:  true,
+		},
+		{
+			name:       "changing the selector",
+			old:        newMachineSet(map[string]string{"foo": "bar"}, map[string]string{"foo": "bar", "baz": "qux"}),
+			machineSet: newMachineSet(map[string]string{"baz": "qux"}, map[string]string{"foo": "bar", "baz": "qux"}),
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			var err error
+			if tc.old == nil {
+				err = tc.machineSet.ValidateCreate()
+			} else {
+				err = tc.machineSet.ValidateUpdate(tc.old)
+			}
+
+			if tc.expectErr {
+				g.Expect(err).To(gomega.HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(gomega.HaveOccurred())
+			}
+		})
+	}
+}
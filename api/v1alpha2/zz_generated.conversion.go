@@ -49,16 +49,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*Cluster)(nil), (*v1alpha1.Cluster)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha2_Cluster_To_v1alpha1_Cluster(a.(*Cluster), b.(*v1alpha1.Cluster), scope)
-	}); err != nil {
-		return err
-	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha1.Cluster)(nil), (*Cluster)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha1_Cluster_To_v1alpha2_Cluster(a.(*v1alpha1.Cluster), b.(*Cluster), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*ClusterList)(nil), (*v1alpha1.ClusterList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_ClusterList_To_v1alpha1_ClusterList(a.(*ClusterList), b.(*v1alpha1.ClusterList), scope)
 	}); err != nil {
@@ -89,26 +79,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*Machine)(nil), (*v1alpha1.Machine)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha2_Machine_To_v1alpha1_Machine(a.(*Machine), b.(*v1alpha1.Machine), scope)
-	}); err != nil {
-		return err
-	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha1.Machine)(nil), (*Machine)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha1_Machine_To_v1alpha2_Machine(a.(*v1alpha1.Machine), b.(*Machine), scope)
-	}); err != nil {
-		return err
-	}
-	if err := s.AddGeneratedConversionFunc((*MachineDeployment)(nil), (*v1alpha1.MachineDeployment)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(a.(*MachineDeployment), b.(*v1alpha1.MachineDeployment), scope)
-	}); err != nil {
-		return err
-	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha1.MachineDeployment)(nil), (*MachineDeployment)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(a.(*v1alpha1.MachineDeployment), b.(*MachineDeployment), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*MachineDeploymentList)(nil), (*v1alpha1.MachineDeploymentList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_MachineDeploymentList_To_v1alpha1_MachineDeploymentList(a.(*MachineDeploymentList), b.(*v1alpha1.MachineDeploymentList), scope)
 	}); err != nil {
@@ -169,16 +139,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*MachineSet)(nil), (*v1alpha1.MachineSet)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha2_MachineSet_To_v1alpha1_MachineSet(a.(*MachineSet), b.(*v1alpha1.MachineSet), scope)
-	}); err != nil {
-		return err
-	}
-	if err := s.AddGeneratedConversionFunc((*v1alpha1.MachineSet)(nil), (*MachineSet)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(a.(*v1alpha1.MachineSet), b.(*MachineSet), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*MachineSetList)(nil), (*v1alpha1.MachineSetList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_MachineSetList_To_v1alpha1_MachineSetList(a.(*MachineSetList), b.(*v1alpha1.MachineSetList), scope)
 	}); err != nil {
@@ -259,6 +219,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1alpha1.Cluster)(nil), (*Cluster)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Cluster_To_v1alpha2_Cluster(a.(*v1alpha1.Cluster), b.(*Cluster), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*v1alpha1.ClusterNetworkingConfig)(nil), (*ClusterNetwork)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_ClusterNetworkingConfig_To_v1alpha2_ClusterNetwork(a.(*v1alpha1.ClusterNetworkingConfig), b.(*ClusterNetwork), scope)
 	}); err != nil {
@@ -274,6 +239,21 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1alpha1.Machine)(nil), (*Machine)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Machine_To_v1alpha2_Machine(a.(*v1alpha1.Machine), b.(*Machine), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1alpha1.MachineDeployment)(nil), (*MachineDeployment)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(a.(*v1alpha1.MachineDeployment), b.(*MachineDeployment), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1alpha1.MachineSet)(nil), (*MachineSet)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(a.(*v1alpha1.MachineSet), b.(*MachineSet), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*v1alpha1.MachineSpec)(nil), (*MachineSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_MachineSpec_To_v1alpha2_MachineSpec(a.(*v1alpha1.MachineSpec), b.(*MachineSpec), scope)
 	}); err != nil {
@@ -284,6 +264,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*Cluster)(nil), (*v1alpha1.Cluster)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_Cluster_To_v1alpha1_Cluster(a.(*Cluster), b.(*v1alpha1.Cluster), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*ClusterNetwork)(nil), (*v1alpha1.ClusterNetworkingConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ClusterNetwork_To_v1alpha1_ClusterNetworkingConfig(a.(*ClusterNetwork), b.(*v1alpha1.ClusterNetworkingConfig), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*ClusterSpec)(nil), (*v1alpha1.ClusterSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_ClusterSpec_To_v1alpha1_ClusterSpec(a.(*ClusterSpec), b.(*v1alpha1.ClusterSpec), scope)
 	}); err != nil {
@@ -294,11 +284,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*Machine)(nil), (*v1alpha1.Machine)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_Machine_To_v1alpha1_Machine(a.(*Machine), b.(*v1alpha1.Machine), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*MachineDeployment)(nil), (*v1alpha1.MachineDeployment)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(a.(*MachineDeployment), b.(*v1alpha1.MachineDeployment), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*MachineDeploymentStatus)(nil), (*v1alpha1.MachineDeploymentStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_MachineDeploymentStatus_To_v1alpha1_MachineDeploymentStatus(a.(*MachineDeploymentStatus), b.(*v1alpha1.MachineDeploymentStatus), scope)
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*MachineSet)(nil), (*v1alpha1.MachineSet)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_MachineSet_To_v1alpha1_MachineSet(a.(*MachineSet), b.(*v1alpha1.MachineSet), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*MachineSetStatus)(nil), (*v1alpha1.MachineSetStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_MachineSetStatus_To_v1alpha1_MachineSetStatus(a.(*MachineSetStatus), b.(*v1alpha1.MachineSetStatus), scope)
 	}); err != nil {
@@ -350,11 +355,6 @@ func autoConvert_v1alpha2_Cluster_To_v1alpha1_Cluster(in *Cluster, out *v1alpha1
 	return nil
 }
 
-// Convert_v1alpha2_Cluster_To_v1alpha1_Cluster is an autogenerated conversion function.
-func Convert_v1alpha2_Cluster_To_v1alpha1_Cluster(in *Cluster, out *v1alpha1.Cluster, s conversion.Scope) error {
-	return autoConvert_v1alpha2_Cluster_To_v1alpha1_Cluster(in, out, s)
-}
-
 func autoConvert_v1alpha1_Cluster_To_v1alpha2_Cluster(in *v1alpha1.Cluster, out *Cluster, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_ClusterSpec_To_v1alpha2_ClusterSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -366,11 +366,6 @@ func autoConvert_v1alpha1_Cluster_To_v1alpha2_Cluster(in *v1alpha1.Cluster, out
 	return nil
 }
 
-// Convert_v1alpha1_Cluster_To_v1alpha2_Cluster is an autogenerated conversion function.
-func Convert_v1alpha1_Cluster_To_v1alpha2_Cluster(in *v1alpha1.Cluster, out *Cluster, s conversion.Scope) error {
-	return autoConvert_v1alpha1_Cluster_To_v1alpha2_Cluster(in, out, s)
-}
-
 func autoConvert_v1alpha2_ClusterList_To_v1alpha1_ClusterList(in *ClusterList, out *v1alpha1.ClusterList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
@@ -416,6 +411,8 @@ func Convert_v1alpha1_ClusterList_To_v1alpha2_ClusterList(in *v1alpha1.ClusterLi
 func autoConvert_v1alpha2_ClusterSpec_To_v1alpha1_ClusterSpec(in *ClusterSpec, out *v1alpha1.ClusterSpec, s conversion.Scope) error {
 	// WARNING: in.ClusterNetwork requires manual conversion: inconvertible types (*sigs.k8s.io/cluster-api/api/v1alpha2.ClusterNetwork vs sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1.ClusterNetworkingConfig)
 	// WARNING: in.InfrastructureRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.ControlPlaneRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.Paused requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -434,6 +431,9 @@ func autoConvert_v1alpha2_ClusterStatus_To_v1alpha1_ClusterStatus(in *ClusterSta
 	// WARNING: in.Phase requires manual conversion: does not exist in peer-type
 	// WARNING: in.InfrastructureReady requires manual conversion: does not exist in peer-type
 	// WARNING: in.ControlPlaneInitialized requires manual conversion: does not exist in peer-type
+	// WARNING: in.ControlPlaneReady requires manual conversion: does not exist in peer-type
+	// WARNING: in.FailureDomains requires manual conversion: does not exist in peer-type
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -458,11 +458,6 @@ func autoConvert_v1alpha2_Machine_To_v1alpha1_Machine(in *Machine, out *v1alpha1
 	return nil
 }
 
-// Convert_v1alpha2_Machine_To_v1alpha1_Machine is an autogenerated conversion function.
-func Convert_v1alpha2_Machine_To_v1alpha1_Machine(in *Machine, out *v1alpha1.Machine, s conversion.Scope) error {
-	return autoConvert_v1alpha2_Machine_To_v1alpha1_Machine(in, out, s)
-}
-
 func autoConvert_v1alpha1_Machine_To_v1alpha2_Machine(in *v1alpha1.Machine, out *Machine, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_MachineSpec_To_v1alpha2_MachineSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -474,11 +469,6 @@ func autoConvert_v1alpha1_Machine_To_v1alpha2_Machine(in *v1alpha1.Machine, out
 	return nil
 }
 
-// Convert_v1alpha1_Machine_To_v1alpha2_Machine is an autogenerated conversion function.
-func Convert_v1alpha1_Machine_To_v1alpha2_Machine(in *v1alpha1.Machine, out *Machine, s conversion.Scope) error {
-	return autoConvert_v1alpha1_Machine_To_v1alpha2_Machine(in, out, s)
-}
-
 func autoConvert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(in *MachineDeployment, out *v1alpha1.MachineDeployment, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha2_MachineDeploymentSpec_To_v1alpha1_MachineDeploymentSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -490,11 +480,6 @@ func autoConvert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(in *Ma
 	return nil
 }
 
-// Convert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment is an autogenerated conversion function.
-func Convert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(in *MachineDeployment, out *v1alpha1.MachineDeployment, s conversion.Scope) error {
-	return autoConvert_v1alpha2_MachineDeployment_To_v1alpha1_MachineDeployment(in, out, s)
-}
-
 func autoConvert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(in *v1alpha1.MachineDeployment, out *MachineDeployment, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_MachineDeploymentSpec_To_v1alpha2_MachineDeploymentSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -506,11 +491,6 @@ func autoConvert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(in *v1
 	return nil
 }
 
-// Convert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment is an autogenerated conversion function.
-func Convert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(in *v1alpha1.MachineDeployment, out *MachineDeployment, s conversion.Scope) error {
-	return autoConvert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(in, out, s)
-}
-
 func autoConvert_v1alpha2_MachineDeploymentList_To_v1alpha1_MachineDeploymentList(in *MachineDeploymentList, out *v1alpha1.MachineDeploymentList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
@@ -563,6 +543,7 @@ func autoConvert_v1alpha2_MachineDeploymentSpec_To_v1alpha1_MachineDeploymentSpe
 	out.MinReadySeconds = (*int32)(unsafe.Pointer(in.MinReadySeconds))
 	out.RevisionHistoryLimit = (*int32)(unsafe.Pointer(in.RevisionHistoryLimit))
 	out.Paused = in.Paused
+	// WARNING: in.RollbackTo requires manual conversion: does not exist in peer-type
 	out.ProgressDeadlineSeconds = (*int32)(unsafe.Pointer(in.ProgressDeadlineSeconds))
 	return nil
 }
@@ -599,6 +580,7 @@ func autoConvert_v1alpha2_MachineDeploymentStatus_To_v1alpha1_MachineDeploymentS
 	out.ReadyReplicas = in.ReadyReplicas
 	out.AvailableReplicas = in.AvailableReplicas
 	out.UnavailableReplicas = in.UnavailableReplicas
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -714,11 +696,6 @@ func autoConvert_v1alpha2_MachineSet_To_v1alpha1_MachineSet(in *MachineSet, out
 	return nil
 }
 
-// Convert_v1alpha2_MachineSet_To_v1alpha1_MachineSet is an autogenerated conversion function.
-func Convert_v1alpha2_MachineSet_To_v1alpha1_MachineSet(in *MachineSet, out *v1alpha1.MachineSet, s conversion.Scope) error {
-	return autoConvert_v1alpha2_MachineSet_To_v1alpha1_MachineSet(in, out, s)
-}
-
 func autoConvert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(in *v1alpha1.MachineSet, out *MachineSet, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_MachineSetSpec_To_v1alpha2_MachineSetSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -730,11 +707,6 @@ func autoConvert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(in *v1alpha1.Machine
 	return nil
 }
 
-// Convert_v1alpha1_MachineSet_To_v1alpha2_MachineSet is an autogenerated conversion function.
-func Convert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(in *v1alpha1.MachineSet, out *MachineSet, s conversion.Scope) error {
-	return autoConvert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(in, out, s)
-}
-
 func autoConvert_v1alpha2_MachineSetList_To_v1alpha1_MachineSetList(in *MachineSetList, out *v1alpha1.MachineSetList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
@@ -818,6 +790,7 @@ func autoConvert_v1alpha2_MachineSetStatus_To_v1alpha1_MachineSetStatus(in *Mach
 	out.ObservedGeneration = in.ObservedGeneration
 	out.ErrorReason = (*errors.MachineSetStatusError)(unsafe.Pointer(in.ErrorReason))
 	out.ErrorMessage = (*string)(unsafe.Pointer(in.ErrorMessage))
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -841,10 +814,13 @@ func autoConvert_v1alpha2_MachineSpec_To_v1alpha1_MachineSpec(in *MachineSpec, o
 	if err := Convert_v1alpha2_ObjectMeta_To_v1alpha1_ObjectMeta(&in.ObjectMeta, &out.ObjectMeta, s); err != nil {
 		return err
 	}
+	out.Taints = *(*[]corev1.Taint)(unsafe.Pointer(&in.Taints))
 	// WARNING: in.Bootstrap requires manual conversion: does not exist in peer-type
 	// WARNING: in.InfrastructureRef requires manual conversion: does not exist in peer-type
 	// WARNING: in.Version requires manual conversion: does not exist in peer-type
 	out.ProviderID = (*string)(unsafe.Pointer(in.ProviderID))
+	// WARNING: in.FailureDomain requires manual conversion: does not exist in peer-type
+	// WARNING: in.NodeDrainTimeout requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -852,7 +828,7 @@ func autoConvert_v1alpha1_MachineSpec_To_v1alpha2_MachineSpec(in *v1alpha1.Machi
 	if err := Convert_v1alpha1_ObjectMeta_To_v1alpha2_ObjectMeta(&in.ObjectMeta, &out.ObjectMeta, s); err != nil {
 		return err
 	}
-	// WARNING: in.Taints requires manual conversion: does not exist in peer-type
+	out.Taints = *(*[]corev1.Taint)(unsafe.Pointer(&in.Taints))
 	// WARNING: in.ProviderSpec requires manual conversion: does not exist in peer-type
 	// WARNING: in.Versions requires manual conversion: does not exist in peer-type
 	// WARNING: in.ConfigSource requires manual conversion: does not exist in peer-type
@@ -872,6 +848,7 @@ func autoConvert_v1alpha2_MachineStatus_To_v1alpha1_MachineStatus(in *MachineSta
 	}
 	// WARNING: in.BootstrapReady requires manual conversion: does not exist in peer-type
 	// WARNING: in.InfrastructureReady requires manual conversion: does not exist in peer-type
+	// WARNING: in.Conditions requires manual conversion: does not exist in peer-type
 	return nil
 }
 
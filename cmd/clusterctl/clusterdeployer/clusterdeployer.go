@@ -62,9 +62,15 @@ func (d *ClusterDeployer) Create(resources *yaml.ParseOutput, kubeconfigOutput s
 	cluster := resources.Clusters[0]
 	machines := resources.Machines
 
-	controlPlaneMachines, nodes, err := clusterclient.ExtractControlPlaneMachines(machines)
-	if err != nil {
-		return errors.Wrap(err, "unable to separate control plane machines from node machines")
+	// When the Cluster references a control plane provider, the control plane is created along with
+	// the Cluster and every Machine is considered a node.
+	controlPlaneMachines, nodes := []*clusterv1.Machine{}, machines
+	if cluster.Spec.ControlPlaneRef == nil {
+		var err error
+		controlPlaneMachines, nodes, err = clusterclient.ExtractControlPlaneMachines(machines)
+		if err != nil {
+			return errors.Wrap(err, "unable to separate control plane machines from node machines")
+		}
 	}
 
 	bootstrapClient, cleanupBootstrapCluster, err := phases.CreateBootstrapCluster(d.bootstrapProvisioner, d.cleanupBootstrapCluster, d.clientFactory)
@@ -97,14 +103,16 @@ func (d *ClusterDeployer) Create(resources *yaml.ParseOutput, kubeconfigOutput s
 		cluster.Namespace = bootstrapClient.GetContextNamespace()
 	}
 
-	firstControlPlane := controlPlaneMachines[0]
-	klog.Infof("Creating control plane machine %q in namespace %q", firstControlPlane.Name, cluster.Namespace)
-	if err := phases.ApplyMachines(
-		bootstrapClient,
-		cluster.Namespace,
-		[]*clusterv1.Machine{firstControlPlane},
-		yaml.ExtractMachineReferences(resources, firstControlPlane)...); err != nil {
-		return errors.Wrap(err, "unable to create control plane machine")
+	if len(controlPlaneMachines) > 0 {
+		firstControlPlane := controlPlaneMachines[0]
+		klog.Infof("Creating control plane machine %q in namespace %q", firstControlPlane.Name, cluster.Namespace)
+		if err := phases.ApplyMachines(
+			bootstrapClient,
+			cluster.Namespace,
+			[]*clusterv1.Machine{firstControlPlane},
+			yaml.ExtractMachineReferences(resources, firstControlPlane)...); err != nil {
+			return errors.Wrap(err, "unable to create control plane machine")
+		}
 	}
 
 	klog.Info("Creating target cluster")
@@ -0,0 +1,137 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/migrate"
+	"sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type AlphaMigrateOptions struct {
+	File                     string
+	Kubeconfig               string
+	Namespace                string
+	Output                   string
+	InfrastructureAPIVersion string
+	InfrastructureProvider   string
+	BootstrapAPIVersion      string
+	BootstrapKind            string
+}
+
+var amo = &AlphaMigrateOptions{}
+
+var alphaMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate v1alpha1 objects to v1alpha2",
+	Long: `Migrate v1alpha1 Cluster, Machine, MachineSet, MachineDeployment and MachineClass objects to v1alpha2.
+
+The objects are read from a file or from a cluster, and are written as v1alpha2 objects along with stub
+infrastructure objects embedding their providerSpec and empty bootstrap objects, which must be reviewed
+before being applied. The fields that couldn't be mapped are reported on stderr.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if amo.File == "" && amo.Kubeconfig == "" {
+			exitWithHelp(cmd, "Please provide a file or a kubeconfig to read the v1alpha1 objects from.")
+		}
+		if amo.File != "" && amo.Kubeconfig != "" {
+			exitWithHelp(cmd, "Please provide either a file or a kubeconfig, not both.")
+		}
+
+		if err := RunAlphaMigrate(amo); err != nil {
+			klog.Exit(err)
+		}
+	},
+}
+
+func RunAlphaMigrate(amo *AlphaMigrateOptions) error {
+	objs, err := readV1alpha1Objects(amo)
+	if err != nil {
+		return err
+	}
+
+	result, err := migrate.Migrate(objs, migrate.Options{
+		InfrastructureAPIVersion: amo.InfrastructureAPIVersion,
+		InfrastructureProvider:   amo.InfrastructureProvider,
+		BootstrapAPIVersion:      amo.BootstrapAPIVersion,
+		BootstrapKind:            amo.BootstrapKind,
+	})
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if amo.Output != "" {
+		f, err := os.Create(amo.Output)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %q", amo.Output)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := result.WriteYAML(w); err != nil {
+		return err
+	}
+	return result.WriteReport(os.Stderr)
+}
+
+func readV1alpha1Objects(amo *AlphaMigrateOptions) (*migrate.Objects, error) {
+	if amo.File != "" {
+		f, err := os.Open(amo.File)
+		if err != nil {
+			return nil, err
+		}
+		return migrate.Read(f)
+	}
+
+	cfg, err := clientcmd.BuildConfigFromFlags("", amo.Kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client configuration")
+	}
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, errors.Wrap(err, "failed to add v1alpha1 APIs to scheme")
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client")
+	}
+	return migrate.List(context.TODO(), c, amo.Namespace)
+}
+
+func init() {
+	// Required flags, one of
+	alphaMigrateCmd.Flags().StringVarP(&amo.File, "file", "f", "", "Path to the YAML file with the v1alpha1 objects")
+	alphaMigrateCmd.Flags().StringVarP(&amo.Kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig of the cluster with the v1alpha1 objects")
+
+	// Optional flags
+	alphaMigrateCmd.Flags().StringVarP(&amo.Namespace, "namespace", "n", "", "Namespace of the v1alpha1 objects read from the cluster, if empty all namespaces are read")
+	alphaMigrateCmd.Flags().StringVarP(&amo.Output, "output", "o", "", "Path to write the v1alpha2 objects to, if empty they are written to stdout")
+	alphaMigrateCmd.Flags().StringVarP(&amo.InfrastructureAPIVersion, "infrastructure-api-version", "", migrate.DefaultInfrastructureAPIVersion, "API version of the infrastructure objects")
+	alphaMigrateCmd.Flags().StringVarP(&amo.InfrastructureProvider, "infrastructure-provider", "", "", "Prefix of the kinds of the infrastructure objects, e.g. AWS for AWSMachine; if empty it's derived from the kind of the providerSpec")
+	alphaMigrateCmd.Flags().StringVarP(&amo.BootstrapAPIVersion, "bootstrap-api-version", "", migrate.DefaultBootstrapAPIVersion, "API version of the bootstrap objects")
+	alphaMigrateCmd.Flags().StringVarP(&amo.BootstrapKind, "bootstrap-kind", "", migrate.DefaultBootstrapKind, "Kind of the bootstrap objects, if empty no bootstrap objects are created")
+	alphaCmd.AddCommand(alphaMigrateCmd)
+}
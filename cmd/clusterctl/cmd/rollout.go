@@ -0,0 +1,57 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var rolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "Manage the rollout of a MachineDeployment.",
+	Long:  `Manage the rollout of a MachineDeployment. See subcommands for supported operations.`,
+}
+
+func init() {
+	RootCmd.AddCommand(rolloutCmd)
+}
+
+func newRolloutClient() (client.Client, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client configuration")
+	}
+	mgr, err := manager.New(cfg, manager.Options{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create manager")
+	}
+	// Setup Scheme for all resources
+	if err := clusterv1.AddToScheme(mgr.GetScheme()); err != nil {
+		return nil, errors.Wrap(err, "failed to add APIs to manager")
+	}
+
+	c, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme(), Mapper: mgr.GetRESTMapper()})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client")
+	}
+	return c, nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/rollout"
+)
+
+type RolloutHistoryOptions struct {
+	Namespace string
+	Revision  int64
+}
+
+var rho = &RolloutHistoryOptions{}
+
+var rolloutHistoryCmd = &cobra.Command{
+	Use:   "history MACHINEDEPLOYMENT",
+	Short: "View the rollout history of a MachineDeployment.",
+	Long:  `View the revisions of a MachineDeployment, along with the changes to the machine template between revisions.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := RunRolloutHistory(args[0]); err != nil {
+			os.Stdout.Sync()
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rolloutHistoryCmd.Flags().StringVarP(&rho.Namespace, "namespace", "n", metav1.NamespaceDefault, "Namespace of the MachineDeployment.")
+	rolloutHistoryCmd.Flags().Int64Var(&rho.Revision, "revision", 0, "See the details of the machine template for the given revision, if empty all the revisions are listed.")
+	rolloutCmd.AddCommand(rolloutHistoryCmd)
+}
+
+func RunRolloutHistory(name string) error {
+	c, err := newRolloutClient()
+	if err != nil {
+		return err
+	}
+	return rollout.History(context.TODO(), os.Stdout, c, rho.Namespace, name, rho.Revision)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/rollout"
+)
+
+type RolloutUndoOptions struct {
+	Namespace  string
+	ToRevision int64
+}
+
+var ruo = &RolloutUndoOptions{}
+
+var rolloutUndoCmd = &cobra.Command{
+	Use:   "undo MACHINEDEPLOYMENT",
+	Short: "Rollback a MachineDeployment to a previous revision.",
+	Long:  `Rollback a MachineDeployment to a previous revision by copying the machine template of that revision back into the MachineDeployment.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := RunRolloutUndo(args[0]); err != nil {
+			os.Stdout.Sync()
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rolloutUndoCmd.Flags().StringVarP(&ruo.Namespace, "namespace", "n", metav1.NamespaceDefault, "Namespace of the MachineDeployment.")
+	rolloutUndoCmd.Flags().Int64Var(&ruo.ToRevision, "to-revision", 0, "The revision to rollback to, if empty the MachineDeployment is rolled back to the previous revision.")
+	rolloutCmd.AddCommand(rolloutUndoCmd)
+}
+
+func RunRolloutUndo(name string) error {
+	c, err := newRolloutClient()
+	if err != nil {
+		return err
+	}
+	return rollout.Undo(context.TODO(), os.Stdout, c, ruo.Namespace, name, ruo.ToRevision)
+}
@@ -0,0 +1,545 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrate converts Cluster API v1alpha1 objects to the v1alpha2 object model, where the
+// providerSpec of Clusters and Machines is replaced by references to infrastructure and bootstrap
+// provider objects.
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1"
+	utilconversion "sigs.k8s.io/cluster-api/util/conversion"
+)
+
+const (
+	// DefaultInfrastructureAPIVersion is the default apiVersion of the infrastructure objects.
+	DefaultInfrastructureAPIVersion = "infrastructure.cluster.x-k8s.io/v1alpha2"
+
+	// DefaultBootstrapAPIVersion is the default apiVersion of the bootstrap objects.
+	DefaultBootstrapAPIVersion = "bootstrap.cluster.x-k8s.io/v1alpha2"
+
+	// DefaultBootstrapKind is the default kind of the bootstrap objects.
+	DefaultBootstrapKind = "KubeadmConfig"
+)
+
+// Options configures the provider objects created by the migration.
+type Options struct {
+	// InfrastructureAPIVersion is the apiVersion of the infrastructure objects.
+	InfrastructureAPIVersion string
+
+	// InfrastructureProvider is the prefix of the kinds of the infrastructure objects, e.g. AWS for
+	// AWSCluster, AWSMachine and AWSMachineTemplate. When empty, it's derived from the kind of
+	// each providerSpec, e.g. AWS for AWSMachineProviderSpec.
+	InfrastructureProvider string
+
+	// BootstrapAPIVersion is the apiVersion of the bootstrap objects.
+	BootstrapAPIVersion string
+
+	// BootstrapKind is the kind of the bootstrap objects referenced by Machines, the bootstrap
+	// objects referenced by MachineSets and MachineDeployments use the kind with a Template suffix.
+	// When empty, no bootstrap objects are created.
+	BootstrapKind string
+}
+
+// Objects holds the v1alpha1 objects to migrate.
+type Objects struct {
+	Clusters           []*v1alpha1.Cluster
+	Machines           []*v1alpha1.Machine
+	MachineSets        []*v1alpha1.MachineSet
+	MachineDeployments []*v1alpha1.MachineDeployment
+	MachineClasses     []*v1alpha1.MachineClass
+
+	// UnstructuredObjects holds the objects that aren't Cluster API v1alpha1 objects,
+	// they are migrated unchanged.
+	UnstructuredObjects []*unstructured.Unstructured
+}
+
+// UnmappedField is a field of a v1alpha1 object that couldn't be mapped to a v1alpha2 field.
+type UnmappedField struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Field     string
+	Reason    string
+}
+
+// Result holds the objects and the report of a migration.
+type Result struct {
+	// Objects holds the v1alpha2 objects and the provider objects they reference,
+	// each provider object precedes the objects referencing it.
+	Objects []*unstructured.Unstructured
+
+	// Unmapped lists the v1alpha1 fields that couldn't be mapped.
+	Unmapped []UnmappedField
+}
+
+// WriteYAML writes the objects of the migration as YAML documents.
+func (r *Result) WriteYAML(w io.Writer) error {
+	serializer := json.NewYAMLSerializer(json.DefaultMetaFactory, nil, nil)
+	for i, obj := range r.Objects {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w, "---"); err != nil {
+				return err
+			}
+		}
+		if err := serializer.Encode(obj, w); err != nil {
+			return errors.Wrapf(err, "failed to encode %s %q", obj.GetKind(), obj.GetName())
+		}
+	}
+	return nil
+}
+
+// WriteReport writes the report of the fields that couldn't be mapped.
+func (r *Result) WriteReport(w io.Writer) error {
+	if len(r.Unmapped) == 0 {
+		_, err := fmt.Fprintln(w, "All fields have been migrated.")
+		return err
+	}
+	for _, f := range r.Unmapped {
+		if _, err := fmt.Fprintf(w, "%s %s/%s: %s: %s\n", f.Kind, f.Namespace, f.Name, f.Field, f.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrate converts the given v1alpha1 objects to v1alpha2 objects.
+// The providerSpec of each object, or of the MachineClass it references, is embedded in a stub
+// infrastructure object referenced by the v1alpha2 object; bootstrap objects are created empty.
+// The status of the objects isn't migrated, the v1alpha2 controllers rebuild it.
+func Migrate(objs *Objects, opts Options) (*Result, error) {
+	m := &migrator{
+		opts:           opts,
+		result:         &Result{},
+		machineClasses: map[string]*v1alpha1.MachineClass{},
+		classTemplates: map[string]*corev1.ObjectReference{},
+		names:          map[string]bool{},
+	}
+
+	m.result.Objects = append(m.result.Objects, objs.UnstructuredObjects...)
+
+	for _, class := range objs.MachineClasses {
+		m.machineClasses[objectKey(class.Namespace, class.Name)] = class
+	}
+	for _, class := range objs.MachineClasses {
+		if err := m.migrateMachineClass(class); err != nil {
+			return nil, err
+		}
+	}
+	for _, cluster := range objs.Clusters {
+		if err := m.migrateCluster(cluster); err != nil {
+			return nil, err
+		}
+	}
+	for _, md := range objs.MachineDeployments {
+		if err := m.migrateMachineDeployment(md); err != nil {
+			return nil, err
+		}
+	}
+	for _, ms := range objs.MachineSets {
+		if err := m.migrateMachineSet(ms); err != nil {
+			return nil, err
+		}
+	}
+	for _, machine := range objs.Machines {
+		if err := m.migrateMachine(machine); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.result, nil
+}
+
+type migrator struct {
+	opts   Options
+	result *Result
+
+	// machineClasses indexes the v1alpha1 MachineClasses by namespace and name.
+	machineClasses map[string]*v1alpha1.MachineClass
+
+	// classTemplates indexes the infrastructure templates created from MachineClasses by
+	// the namespace and name of the MachineClass.
+	classTemplates map[string]*corev1.ObjectReference
+
+	// names tracks the objects of the result, to detect conflicting provider objects.
+	names map[string]bool
+}
+
+func (m *migrator) migrateMachineClass(class *v1alpha1.MachineClass) error {
+	ref, err := m.newInfrastructureObject("MachineClass", class.ObjectMeta, "providerSpec", &class.ProviderSpec, "MachineTemplate", true, nil)
+	if err != nil {
+		return err
+	}
+	if ref != nil {
+		m.classTemplates[objectKey(class.Namespace, class.Name)] = ref
+	}
+	return nil
+}
+
+func (m *migrator) migrateCluster(in *v1alpha1.Cluster) error {
+	out := &clusterv1.Cluster{}
+	if err := clusterv1.Convert_v1alpha1_Cluster_To_v1alpha2_Cluster(in, out, nil); err != nil {
+		return errors.Wrapf(err, "failed to convert Cluster %q in namespace %q", in.Name, in.Namespace)
+	}
+	out.TypeMeta = metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Cluster"}
+	m.migrateObjectMeta("Cluster", &out.ObjectMeta)
+
+	ref, err := m.migrateProviderSpec("Cluster", in.ObjectMeta, "spec.providerSpec", &in.Spec.ProviderSpec, "Cluster", false, out.Labels)
+	if err != nil {
+		return err
+	}
+	out.Spec.InfrastructureRef = ref
+
+	if in.Status.ProviderStatus != nil {
+		m.reportUnmapped("Cluster", in.ObjectMeta, "status.providerStatus", "dropped, the infrastructure provider reports its own status")
+	}
+
+	return m.add(out)
+}
+
+func (m *migrator) migrateMachine(in *v1alpha1.Machine) error {
+	out := &clusterv1.Machine{}
+	if err := clusterv1.Convert_v1alpha1_Machine_To_v1alpha2_Machine(in, out, nil); err != nil {
+		return errors.Wrapf(err, "failed to convert Machine %q in namespace %q", in.Name, in.Namespace)
+	}
+	out.TypeMeta = metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "Machine"}
+	m.migrateObjectMeta("Machine", &out.ObjectMeta)
+
+	if err := m.migrateMachineSpec("Machine", in.ObjectMeta, "spec", &in.Spec, &out.Spec, false, out.Labels); err != nil {
+		return err
+	}
+
+	if in.Status.ProviderStatus != nil {
+		m.reportUnmapped("Machine", in.ObjectMeta, "status.providerStatus", "dropped, the infrastructure provider reports its own status")
+	}
+	if len(in.Status.Conditions) > 0 {
+		m.reportUnmapped("Machine", in.ObjectMeta, "status.conditions", "dropped, the conditions of the Node are reported on the Node")
+	}
+	if in.Status.LastOperation != nil {
+		m.reportUnmapped("Machine", in.ObjectMeta, "status.lastOperation", "dropped, v1alpha2 doesn't track the last operation")
+	}
+
+	return m.add(out)
+}
+
+func (m *migrator) migrateMachineSet(in *v1alpha1.MachineSet) error {
+	out := &clusterv1.MachineSet{}
+	if err := clusterv1.Convert_v1alpha1_MachineSet_To_v1alpha2_MachineSet(in, out, nil); err != nil {
+		return errors.Wrapf(err, "failed to convert MachineSet %q in namespace %q", in.Name, in.Namespace)
+	}
+	out.TypeMeta = metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineSet"}
+	m.migrateObjectMeta("MachineSet", &out.ObjectMeta)
+	out.Spec.Selector = migrateSelector(out.Spec.Selector)
+	out.Spec.Template.Labels = migrateLabels(out.Spec.Template.Labels)
+
+	if err := m.migrateMachineSpec("MachineSet", in.ObjectMeta, "spec.template.spec", &in.Spec.Template.Spec, &out.Spec.Template.Spec, true, out.Labels); err != nil {
+		return err
+	}
+
+	return m.add(out)
+}
+
+func (m *migrator) migrateMachineDeployment(in *v1alpha1.MachineDeployment) error {
+	out := &clusterv1.MachineDeployment{}
+	if err := clusterv1.Convert_v1alpha1_MachineDeployment_To_v1alpha2_MachineDeployment(in, out, nil); err != nil {
+		return errors.Wrapf(err, "failed to convert MachineDeployment %q in namespace %q", in.Name, in.Namespace)
+	}
+	out.TypeMeta = metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment"}
+	m.migrateObjectMeta("MachineDeployment", &out.ObjectMeta)
+	out.Spec.Selector = migrateSelector(out.Spec.Selector)
+	out.Spec.Template.Labels = migrateLabels(out.Spec.Template.Labels)
+
+	if err := m.migrateMachineSpec("MachineDeployment", in.ObjectMeta, "spec.template.spec", &in.Spec.Template.Spec, &out.Spec.Template.Spec, true, out.Labels); err != nil {
+		return err
+	}
+
+	return m.add(out)
+}
+
+// migrateMachineSpec sets the infrastructure and bootstrap references of a converted MachineSpec,
+// and reports the fields of the v1alpha1 MachineSpec that the conversion dropped.
+func (m *migrator) migrateMachineSpec(kind string, meta metav1.ObjectMeta, fieldPath string, in *v1alpha1.MachineSpec, out *clusterv1.MachineSpec, template bool, labels map[string]string) error {
+	infrastructureKind := "Machine"
+	if template {
+		infrastructureKind = "MachineTemplate"
+	}
+	ref, err := m.migrateProviderSpec(kind, meta, fieldPath+".providerSpec", &in.ProviderSpec, infrastructureKind, template, labels)
+	if err != nil {
+		return err
+	}
+	if ref != nil {
+		out.InfrastructureRef = *ref
+	}
+
+	if m.opts.BootstrapKind != "" {
+		bootstrapKind := m.opts.BootstrapKind
+		if template {
+			bootstrapKind += "Template"
+		}
+		ref, err := m.newObject(m.opts.BootstrapAPIVersion, bootstrapKind, meta, map[string]interface{}{}, template, labels)
+		if err != nil {
+			return err
+		}
+		out.Bootstrap.ConfigRef = ref
+	}
+
+	if in.ConfigSource != nil {
+		m.reportUnmapped(kind, meta, fieldPath+".configSource", "dropped, v1alpha2 doesn't support dynamic kubelet configuration")
+	}
+	if in.Versions.ControlPlane != "" && in.Versions.Kubelet != "" && in.Versions.ControlPlane != in.Versions.Kubelet {
+		m.reportUnmapped(kind, meta, fieldPath+".versions.kubelet",
+			fmt.Sprintf("dropped, v1alpha2 has a single version set to the control plane version %q", in.Versions.ControlPlane))
+	}
+	return nil
+}
+
+// migrateProviderSpec creates the infrastructure object of a v1alpha1 object from its providerSpec,
+// or from the MachineClass its providerSpec references, and returns a reference to it.
+// It returns nil if the providerSpec is empty or can't be resolved.
+func (m *migrator) migrateProviderSpec(kind string, meta metav1.ObjectMeta, fieldPath string, spec *v1alpha1.ProviderSpec, infrastructureKind string, template bool, labels map[string]string) (*corev1.ObjectReference, error) {
+	if spec.Value != nil {
+		return m.newInfrastructureObject(kind, meta, fieldPath, spec.Value, infrastructureKind, template, labels)
+	}
+
+	if spec.ValueFrom == nil || spec.ValueFrom.MachineClass == nil {
+		m.reportUnmapped(kind, meta, fieldPath, "empty, no infrastructure reference has been set")
+		return nil, nil
+	}
+
+	classRef := spec.ValueFrom.MachineClass
+	namespace := classRef.Namespace
+	if namespace == "" {
+		namespace = meta.Namespace
+	}
+	key := objectKey(namespace, classRef.Name)
+
+	// The MachineSets and MachineDeployments share the template created from the MachineClass.
+	if ref, ok := m.classTemplates[key]; ok && template {
+		return ref.DeepCopy(), nil
+	}
+
+	class, ok := m.machineClasses[key]
+	if !ok {
+		m.reportUnmapped(kind, meta, fieldPath+".valueFrom.machineClass",
+			fmt.Sprintf("MachineClass %q in namespace %q not found, no infrastructure reference has been set", classRef.Name, namespace))
+		return nil, nil
+	}
+	return m.newInfrastructureObject(kind, meta, fieldPath, &class.ProviderSpec, infrastructureKind, template, labels)
+}
+
+// newInfrastructureObject creates an infrastructure object named after the given object, with
+// the fields of the providerSpec value as spec, and returns a reference to it.
+func (m *migrator) newInfrastructureObject(kind string, meta metav1.ObjectMeta, fieldPath string, value *runtime.RawExtension, infrastructureKind string, template bool, labels map[string]string) (*corev1.ObjectReference, error) {
+	spec, err := providerSpecFields(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s of %s %q in namespace %q", fieldPath, kind, meta.Name, meta.Namespace)
+	}
+	if spec == nil {
+		m.reportUnmapped(kind, meta, fieldPath, "empty, no infrastructure reference has been set")
+		return nil, nil
+	}
+
+	provider := m.opts.InfrastructureProvider
+	if provider == "" {
+		provider = providerFromKind(spec["kind"])
+	}
+	if provider == "" {
+		return nil, errors.Errorf("unable to determine the infrastructure provider of %s %q in namespace %q from its providerSpec, it must be set explicitly",
+			kind, meta.Name, meta.Namespace)
+	}
+	delete(spec, "apiVersion")
+	delete(spec, "kind")
+
+	ref, err := m.newObject(m.opts.InfrastructureAPIVersion, provider+infrastructureKind, meta, spec, template, labels)
+	if err != nil {
+		return nil, err
+	}
+	m.reportUnmapped(kind, meta, fieldPath,
+		fmt.Sprintf("moved to the spec of %s %q, it must be updated to the v1alpha2 API of the provider", ref.Kind, ref.Name))
+	return ref, nil
+}
+
+// newObject adds a provider object named after the given object to the result, and returns a reference to it.
+func (m *migrator) newObject(apiVersion, kind string, meta metav1.ObjectMeta, spec map[string]interface{}, template bool, labels map[string]string) (*corev1.ObjectReference, error) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName(meta.Name)
+	u.SetNamespace(meta.Namespace)
+	if clusterName, ok := labels[clusterv1.MachineClusterLabelName]; ok {
+		u.SetLabels(map[string]string{clusterv1.MachineClusterLabelName: clusterName})
+	}
+
+	fields := []string{"spec"}
+	if template {
+		fields = []string{"spec", "template", "spec"}
+	}
+	if err := unstructured.SetNestedMap(u.Object, spec, fields...); err != nil {
+		return nil, errors.Wrapf(err, "failed to set the spec of %s %q in namespace %q", kind, meta.Name, meta.Namespace)
+	}
+
+	if err := m.addUnstructured(u); err != nil {
+		return nil, err
+	}
+	return &corev1.ObjectReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       meta.Name,
+		Namespace:  meta.Namespace,
+	}, nil
+}
+
+// migrateObjectMeta drops the server populated fields and the owner references, which point to
+// v1alpha1 objects, and migrates the v1alpha1 cluster label.
+func (m *migrator) migrateObjectMeta(kind string, meta *metav1.ObjectMeta) {
+	if len(meta.OwnerReferences) > 0 {
+		m.reportUnmapped(kind, *meta, "metadata.ownerReferences", "dropped, the v1alpha2 controllers set the owners again")
+	}
+
+	meta.OwnerReferences = nil
+	meta.Finalizers = nil
+	meta.UID = ""
+	meta.ResourceVersion = ""
+	meta.Generation = 0
+	meta.SelfLink = ""
+	meta.CreationTimestamp = metav1.Time{}
+	meta.ManagedFields = nil
+
+	meta.Labels = migrateLabels(meta.Labels)
+
+	// The conversion data is only needed to convert the objects back.
+	var annotations map[string]string
+	for k, v := range meta.Annotations {
+		if k == utilconversion.DataAnnotation {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[k] = v
+	}
+	meta.Annotations = annotations
+}
+
+func (m *migrator) reportUnmapped(kind string, meta metav1.ObjectMeta, field, reason string) {
+	m.result.Unmapped = append(m.result.Unmapped, UnmappedField{
+		Kind:      kind,
+		Namespace: meta.Namespace,
+		Name:      meta.Name,
+		Field:     field,
+		Reason:    reason,
+	})
+}
+
+// add adds a v1alpha2 object to the result, without its status.
+func (m *migrator) add(obj runtime.Object) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert object to unstructured")
+	}
+	u := &unstructured.Unstructured{Object: content}
+	unstructured.RemoveNestedField(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	return m.addUnstructured(u)
+}
+
+func (m *migrator) addUnstructured(u *unstructured.Unstructured) error {
+	key := strings.Join([]string{u.GetAPIVersion(), u.GetKind(), u.GetNamespace(), u.GetName()}, "/")
+	if m.names[key] {
+		return errors.Errorf("%s %q in namespace %q is created more than once by the migration", u.GetKind(), u.GetName(), u.GetNamespace())
+	}
+	m.names[key] = true
+	m.result.Objects = append(m.result.Objects, u)
+	return nil
+}
+
+// migrateLabels returns a copy of the labels with the v1alpha1 cluster label replaced by the v1alpha2 one.
+func migrateLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	res := make(map[string]string, len(labels))
+	for k, v := range labels {
+		res[migrateLabelKey(k)] = v
+	}
+	return res
+}
+
+// migrateSelector returns a copy of the selector with the v1alpha1 cluster label replaced by the v1alpha2 one.
+func migrateSelector(selector metav1.LabelSelector) metav1.LabelSelector {
+	res := metav1.LabelSelector{MatchLabels: migrateLabels(selector.MatchLabels)}
+	for _, r := range selector.MatchExpressions {
+		r = *r.DeepCopy()
+		r.Key = migrateLabelKey(r.Key)
+		res.MatchExpressions = append(res.MatchExpressions, r)
+	}
+	return res
+}
+
+func migrateLabelKey(key string) string {
+	if key == v1alpha1.MachineClusterLabelName {
+		return clusterv1.MachineClusterLabelName
+	}
+	return key
+}
+
+// providerSpecFields decodes the value of a providerSpec, it returns nil if the value is empty.
+func providerSpecFields(value *runtime.RawExtension) (map[string]interface{}, error) {
+	raw := value.Raw
+	if len(raw) == 0 && value.Object != nil {
+		var err error
+		if raw, err = utiljson.Marshal(value.Object); err != nil {
+			return nil, err
+		}
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	fields := map[string]interface{}{}
+	if err := utiljson.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// providerFromKind returns the provider prefix of a providerSpec kind,
+// e.g. AWS for AWSMachineProviderSpec or AWSClusterProviderSpec.
+func providerFromKind(kind interface{}) string {
+	s, _ := kind.(string)
+	for _, suffix := range []string{"MachineProviderSpec", "ClusterProviderSpec", "MachineProviderConfig", "ClusterProviderConfig", "ProviderSpec", "ProviderConfig"} {
+		if strings.HasSuffix(s, suffix) {
+			return strings.TrimSuffix(s, suffix)
+		}
+	}
+	return ""
+}
+
+func objectKey(namespace, name string) string {
+	return namespace + "/" + name
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+)
+
+const v1alpha1Objects = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: test
+---
+apiVersion: cluster.k8s.io/v1alpha1
+kind: Cluster
+metadata:
+  name: test-cluster
+  namespace: test
+spec:
+  clusterNetwork:
+    services:
+      cidrBlocks: ["10.96.0.0/12"]
+    pods:
+      cidrBlocks: ["192.168.0.0/16"]
+    serviceDomain: cluster.local
+  providerSpec:
+    value:
+      apiVersion: awsprovider/v1alpha1
+      kind: AWSClusterProviderSpec
+      region: us-east-1
+---
+apiVersion: cluster.k8s.io/v1alpha1
+kind: Machine
+metadata:
+  name: controlplane-0
+  namespace: test
+  labels:
+    cluster.k8s.io/cluster-name: test-cluster
+spec:
+  versions:
+    kubelet: v1.14.1
+    controlPlane: v1.14.2
+  configSource:
+    configMap:
+      name: kubelet-config
+      namespace: kube-system
+      kubeletConfigKey: kubelet
+  providerSpec:
+    value:
+      apiVersion: awsprovider/v1alpha1
+      kind: AWSMachineProviderSpec
+      instanceType: t2.medium
+      rootDeviceSize: 20
+---
+apiVersion: cluster.k8s.io/v1alpha1
+kind: MachineClass
+metadata:
+  name: worker
+  namespace: test
+providerSpec:
+  apiVersion: awsprovider/v1alpha1
+  kind: AWSMachineProviderSpec
+  instanceType: t2.large
+---
+apiVersion: cluster.k8s.io/v1alpha1
+kind: MachineDeployment
+metadata:
+  name: worker
+  namespace: test
+  labels:
+    cluster.k8s.io/cluster-name: test-cluster
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      cluster.k8s.io/cluster-name: test-cluster
+      set: worker
+  template:
+    metadata:
+      labels:
+        cluster.k8s.io/cluster-name: test-cluster
+        set: worker
+    spec:
+      versions:
+        kubelet: v1.14.1
+      providerSpec:
+        valueFrom:
+          machineClass:
+            name: worker
+`
+
+func TestMigrate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	objs, err := Read(ioutil.NopCloser(strings.NewReader(v1alpha1Objects)))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(objs.Clusters).To(gomega.HaveLen(1))
+	g.Expect(objs.Machines).To(gomega.HaveLen(1))
+	g.Expect(objs.MachineClasses).To(gomega.HaveLen(1))
+	g.Expect(objs.MachineDeployments).To(gomega.HaveLen(1))
+	g.Expect(objs.UnstructuredObjects).To(gomega.HaveLen(1))
+
+	result, err := Migrate(objs, Options{
+		InfrastructureAPIVersion: DefaultInfrastructureAPIVersion,
+		BootstrapAPIVersion:      DefaultBootstrapAPIVersion,
+		BootstrapKind:            DefaultBootstrapKind,
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	var kinds []string
+	for _, obj := range result.Objects {
+		kinds = append(kinds, obj.GetKind())
+	}
+	g.Expect(kinds).To(gomega.Equal([]string{
+		"Namespace",
+		"AWSMachineTemplate",
+		"AWSCluster",
+		"Cluster",
+		"KubeadmConfigTemplate",
+		"MachineDeployment",
+		"AWSMachine",
+		"KubeadmConfig",
+		"Machine",
+	}))
+
+	// The providerSpec is embedded in the infrastructure objects.
+	awsCluster := findObject(result, "AWSCluster", "test-cluster")
+	g.Expect(awsCluster.GetAPIVersion()).To(gomega.Equal(DefaultInfrastructureAPIVersion))
+	g.Expect(awsCluster.Object["spec"]).To(gomega.Equal(map[string]interface{}{"region": "us-east-1"}))
+
+	awsMachine := findObject(result, "AWSMachine", "controlplane-0")
+	g.Expect(awsMachine.GetLabels()).To(gomega.Equal(map[string]string{clusterv1.MachineClusterLabelName: "test-cluster"}))
+	g.Expect(awsMachine.Object["spec"]).To(gomega.Equal(map[string]interface{}{"instanceType": "t2.medium", "rootDeviceSize": int64(20)}))
+
+	instanceType, _, _ := unstructured.NestedString(findObject(result, "AWSMachineTemplate", "worker").Object, "spec", "template", "spec", "instanceType")
+	g.Expect(instanceType).To(gomega.Equal("t2.large"))
+
+	// The v1alpha2 objects reference the provider objects.
+	cluster := findObject(result, "Cluster", "test-cluster")
+	g.Expect(cluster.GetAPIVersion()).To(gomega.Equal(clusterv1.GroupVersion.String()))
+	g.Expect(cluster.Object).NotTo(gomega.HaveKey("status"))
+	g.Expect(nestedString(cluster, "spec", "infrastructureRef", "kind")).To(gomega.Equal("AWSCluster"))
+	g.Expect(nestedString(cluster, "spec", "clusterNetwork", "serviceDomain")).To(gomega.Equal("cluster.local"))
+
+	machine := findObject(result, "Machine", "controlplane-0")
+	g.Expect(machine.GetLabels()).To(gomega.Equal(map[string]string{clusterv1.MachineClusterLabelName: "test-cluster"}))
+	g.Expect(machine.GetAnnotations()).To(gomega.BeEmpty())
+	g.Expect(nestedString(machine, "spec", "version")).To(gomega.Equal("v1.14.2"))
+	g.Expect(nestedString(machine, "spec", "infrastructureRef", "name")).To(gomega.Equal("controlplane-0"))
+	g.Expect(nestedString(machine, "spec", "bootstrap", "configRef", "kind")).To(gomega.Equal("KubeadmConfig"))
+
+	md := findObject(result, "MachineDeployment", "worker")
+	matchLabels, _, _ := unstructured.NestedStringMap(md.Object, "spec", "selector", "matchLabels")
+	g.Expect(matchLabels).To(gomega.Equal(
+		map[string]string{clusterv1.MachineClusterLabelName: "test-cluster", "set": "worker"}))
+	g.Expect(nestedString(md, "spec", "template", "spec", "infrastructureRef", "kind")).To(gomega.Equal("AWSMachineTemplate"))
+	g.Expect(nestedString(md, "spec", "template", "spec", "bootstrap", "configRef", "kind")).To(gomega.Equal("KubeadmConfigTemplate"))
+
+	// The fields that couldn't be mapped are reported.
+	var unmapped []string
+	for _, f := range result.Unmapped {
+		unmapped = append(unmapped, f.Kind+"/"+f.Name+": "+f.Field)
+	}
+	g.Expect(unmapped).To(gomega.ConsistOf(
+		"MachineClass/worker: providerSpec",
+		"Cluster/test-cluster: spec.providerSpec",
+		"Machine/controlplane-0: spec.providerSpec",
+		"Machine/controlplane-0: spec.configSource",
+		"Machine/controlplane-0: spec.versions.kubelet",
+	))
+
+	buf := &bytes.Buffer{}
+	g.Expect(result.WriteYAML(buf)).To(gomega.Succeed())
+	g.Expect(strings.Count(buf.String(), "---\n")).To(gomega.Equal(len(result.Objects) - 1))
+
+	// The output can be read back.
+	migrated, err := Read(ioutil.NopCloser(buf))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(migrated.UnstructuredObjects).To(gomega.HaveLen(len(result.Objects)))
+}
+
+func TestMigrateUnknownProvider(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	objs, err := Read(ioutil.NopCloser(strings.NewReader(`
+apiVersion: cluster.k8s.io/v1alpha1
+kind: Machine
+metadata:
+  name: machine-0
+  namespace: test
+spec:
+  providerSpec:
+    value:
+      apiVersion: example/v1alpha1
+      kind: Config
+`)))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	_, err = Migrate(objs, Options{InfrastructureAPIVersion: DefaultInfrastructureAPIVersion})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	result, err := Migrate(objs, Options{InfrastructureAPIVersion: DefaultInfrastructureAPIVersion, InfrastructureProvider: "Example"})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(findObject(result, "ExampleMachine", "machine-0")).NotTo(gomega.BeNil())
+}
+
+func findObject(result *Result, kind, name string) *unstructured.Unstructured {
+	for _, obj := range result.Objects {
+		if obj.GetKind() == kind && obj.GetName() == name {
+			return obj
+		}
+	}
+	return nil
+}
+
+func nestedString(obj *unstructured.Unstructured, fields ...string) string {
+	s, _, _ := unstructured.NestedString(obj.Object, fields...)
+	return s
+}
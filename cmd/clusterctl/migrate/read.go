@@ -0,0 +1,129 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/pkg/apis/deprecated/v1alpha1"
+	"sigs.k8s.io/cluster-api/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Read decodes the v1alpha1 objects of the given YAML documents.
+func Read(r io.ReadCloser) (*Objects, error) {
+	decoder := yaml.NewYAMLDecoder(r)
+	defer decoder.Close()
+
+	objs := &Objects{}
+	for {
+		u := &unstructured.Unstructured{}
+		_, gvk, err := decoder.Decode(nil, u)
+		if err == io.EOF {
+			break
+		}
+		if runtime.IsNotRegisteredError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if gvk.GroupVersion() != v1alpha1.SchemeGroupVersion {
+			objs.UnstructuredObjects = append(objs.UnstructuredObjects, u)
+			continue
+		}
+
+		var obj runtime.Object
+		switch gvk.Kind {
+		case "Cluster":
+			c := &v1alpha1.Cluster{}
+			objs.Clusters = append(objs.Clusters, c)
+			obj = c
+		case "Machine":
+			m := &v1alpha1.Machine{}
+			objs.Machines = append(objs.Machines, m)
+			obj = m
+		case "MachineSet":
+			ms := &v1alpha1.MachineSet{}
+			objs.MachineSets = append(objs.MachineSets, ms)
+			obj = ms
+		case "MachineDeployment":
+			md := &v1alpha1.MachineDeployment{}
+			objs.MachineDeployments = append(objs.MachineDeployments, md)
+			obj = md
+		case "MachineClass":
+			mc := &v1alpha1.MachineClass{}
+			objs.MachineClasses = append(objs.MachineClasses, mc)
+			obj = mc
+		default:
+			return nil, errors.Errorf("unsupported kind %s of %s %q", gvk.Kind, gvk.GroupVersion(), u.GetName())
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+			return nil, errors.Wrapf(err, "cannot convert object to %s", gvk.Kind)
+		}
+	}
+
+	return objs, nil
+}
+
+// List lists the v1alpha1 objects of a cluster in the given namespace, or in all namespaces
+// if the namespace is empty. The client's scheme must have the v1alpha1 types registered.
+func List(ctx context.Context, c client.Client, namespace string) (*Objects, error) {
+	clusters := &v1alpha1.ClusterList{}
+	if err := c.List(ctx, clusters, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list v1alpha1 Clusters")
+	}
+	machines := &v1alpha1.MachineList{}
+	if err := c.List(ctx, machines, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list v1alpha1 Machines")
+	}
+	machineSets := &v1alpha1.MachineSetList{}
+	if err := c.List(ctx, machineSets, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list v1alpha1 MachineSets")
+	}
+	machineDeployments := &v1alpha1.MachineDeploymentList{}
+	if err := c.List(ctx, machineDeployments, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list v1alpha1 MachineDeployments")
+	}
+	machineClasses := &v1alpha1.MachineClassList{}
+	if err := c.List(ctx, machineClasses, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrap(err, "failed to list v1alpha1 MachineClasses")
+	}
+
+	objs := &Objects{}
+	for i := range clusters.Items {
+		objs.Clusters = append(objs.Clusters, &clusters.Items[i])
+	}
+	for i := range machines.Items {
+		objs.Machines = append(objs.Machines, &machines.Items[i])
+	}
+	for i := range machineSets.Items {
+		objs.MachineSets = append(objs.MachineSets, &machineSets.Items[i])
+	}
+	for i := range machineDeployments.Items {
+		objs.MachineDeployments = append(objs.MachineDeployments, &machineDeployments.Items[i])
+	}
+	for i := range machineClasses.Items {
+		objs.MachineClasses = append(objs.MachineClasses, &machineClasses.Items[i])
+	}
+	return objs, nil
+}
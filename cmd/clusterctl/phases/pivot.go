@@ -197,6 +197,14 @@ func moveCluster(from sourceClient, to targetClient, cluster *clusterv1.Cluster)
 		}
 	}
 
+	// Move control plane reference, if any.
+	if cluster.Spec.ControlPlaneRef != nil {
+		if err := moveReference(from, to, cluster.Spec.ControlPlaneRef); err != nil {
+			return errors.Wrapf(err, "error copying Cluster %s/%s control plane reference to target cluster",
+				cluster.Namespace, cluster.Name)
+		}
+	}
+
 	klog.V(4).Infof("Retrieving list of MachineDeployments to move for Cluster %s/%s", cluster.Namespace, cluster.Name)
 	machineDeployments, err := from.GetMachineDeploymentsForCluster(cluster)
 	if err != nil {
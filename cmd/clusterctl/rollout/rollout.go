@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// History prints the revisions of the given MachineDeployment, along with the changes
+// to the machine template between each revision and the previous one.
+func History(ctx context.Context, w io.Writer, c client.Client, namespace, name string, revision int64) error {
+	d, err := getMachineDeployment(ctx, c, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	msList, err := getMachineSets(ctx, c, d)
+	if err != nil {
+		return err
+	}
+
+	if revision > 0 {
+		for _, ms := range msList {
+			if v, _ := mdutil.Revision(ms); v == revision {
+				fmt.Fprintf(w, "MachineDeployment %q with revision #%d\n", d.Name, revision)
+				fmt.Fprintf(w, "%s\n", templateString(ms))
+				return nil
+			}
+		}
+		return errors.Errorf("unable to find the specified revision %d for MachineDeployment %q", revision, d.Name)
+	}
+
+	fmt.Fprintf(w, "MachineDeployment %q revisions:\n", d.Name)
+	if len(msList) == 0 {
+		fmt.Fprintf(w, "No rollout history found.\n")
+		return nil
+	}
+
+	var previous *clusterv1.MachineSet
+	for _, ms := range msList {
+		v, _ := mdutil.Revision(ms)
+		fmt.Fprintf(w, "REVISION %d\tMACHINESET %s\n", v, ms.Name)
+		if previous != nil {
+			fmt.Fprintf(w, "%s", templateDiff(previous, ms))
+		}
+		previous = ms
+	}
+	return nil
+}
+
+// Undo rolls the given MachineDeployment back to the given revision, or to the
+// previous revision if no revision is specified.
+func Undo(ctx context.Context, w io.Writer, c client.Client, namespace, name string, revision int64) error {
+	if revision < 0 {
+		return errors.Errorf("revision must be a positive integer, got %d", revision)
+	}
+
+	d, err := getMachineDeployment(ctx, c, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if d.Spec.Paused {
+		return errors.Errorf("cannot rollback paused MachineDeployment %q, resume it first", d.Name)
+	}
+
+	patch := client.MergeFrom(d.DeepCopy())
+	d.Spec.RollbackTo = &clusterv1.RollbackConfig{Revision: revision}
+	if err := c.Patch(ctx, d, patch); err != nil {
+		return errors.Wrapf(err, "failed to rollback MachineDeployment %q", d.Name)
+	}
+
+	fmt.Fprintf(w, "MachineDeployment %q rolled back\n", d.Name)
+	return nil
+}
+
+func getMachineDeployment(ctx context.Context, c client.Client, namespace, name string) (*clusterv1.MachineDeployment, error) {
+	d := &clusterv1.MachineDeployment{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, d); err != nil {
+		return nil, errors.Wrapf(err, "failed to get MachineDeployment %q in namespace %q", name, namespace)
+	}
+	return d, nil
+}
+
+// getMachineSets returns the MachineSets owned by the given MachineDeployment, sorted by revision.
+func getMachineSets(ctx context.Context, c client.Client, d *clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error) {
+	machineSets := &clusterv1.MachineSetList{}
+	if err := c.List(ctx, machineSets, client.InNamespace(d.Namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list MachineSets in namespace %q", d.Namespace)
+	}
+
+	var msList []*clusterv1.MachineSet
+	for i := range machineSets.Items {
+		ms := &machineSets.Items[i]
+		if ref := metav1.GetControllerOf(ms); ref == nil || ref.UID != d.UID {
+			continue
+		}
+		if _, err := mdutil.Revision(ms); err != nil {
+			continue
+		}
+		msList = append(msList, ms)
+	}
+
+	sort.SliceStable(msList, func(i, j int) bool {
+		vi, _ := mdutil.Revision(msList[i])
+		vj, _ := mdutil.Revision(msList[j])
+		return vi < vj
+	})
+	return msList, nil
+}
+
+// templateString returns the machine template of the given MachineSet without the
+// machine template hash label, which differs between every revision.
+func templateString(ms *clusterv1.MachineSet) string {
+	template := ms.Spec.Template.DeepCopy()
+	template.Labels = mdutil.CloneAndRemoveLabel(template.Labels, mdutil.DefaultMachineDeploymentUniqueLabelKey)
+
+	out, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("<unable to encode template: %v>", err)
+	}
+	return string(out)
+}
+
+// templateDiff returns a line diff between the machine templates of the given MachineSets.
+func templateDiff(from, to *clusterv1.MachineSet) string {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(templateString(from), templateString(to))
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	var out strings.Builder
+	for _, diff := range diffs {
+		var prefix string
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		default:
+			continue
+		}
+		for _, line := range strings.SplitAfter(diff.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			out.WriteString("\t" + prefix + " " + strings.TrimSuffix(line, "\n") + "\n")
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newMachineDeployment() *clusterv1.MachineDeployment {
+	return &clusterv1.MachineDeployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineDeployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "md", Namespace: "default", UID: "md-uid"},
+	}
+}
+
+func newMachineSet(name, revision, version string) *clusterv1.MachineSet {
+	return &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			UID:             types.UID(name),
+			Annotations:     map[string]string{mdutil.RevisionAnnotation: revision},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(newMachineDeployment(), clusterv1.GroupVersion.WithKind("MachineDeployment"))},
+		},
+		Spec: clusterv1.MachineSetSpec{
+			Template: clusterv1.MachineTemplateSpec{
+				ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{mdutil.DefaultMachineDeploymentUniqueLabelKey: name}},
+				Spec:       clusterv1.MachineSpec{Version: pointer.StringPtr(version)},
+			},
+		},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	// MachineSets that aren't owned by the MachineDeployment are ignored.
+	other := newMachineSet("other", "1", "v1.14.0")
+	other.OwnerReferences = nil
+
+	c := fake.NewFakeClient(
+		newMachineDeployment(),
+		newMachineSet("ms-2", "2", "v1.16.2"),
+		newMachineSet("ms-1", "1", "v1.15.3"),
+		other,
+	)
+
+	var b bytes.Buffer
+	if err := History(context.Background(), &b, c, "default", "md", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := b.String()
+	if strings.Contains(out, "other") {
+		t.Errorf("expected MachineSets not owned by the MachineDeployment to be ignored, got:\n%s", out)
+	}
+	if strings.Index(out, "REVISION 1") > strings.Index(out, "REVISION 2") {
+		t.Errorf("expected revisions to be sorted, got:\n%s", out)
+	}
+	if !strings.Contains(out, `-     "version": "v1.15.3"`) || !strings.Contains(out, `+     "version": "v1.16.2"`) {
+		t.Errorf("expected a template diff between revisions, got:\n%s", out)
+	}
+	if strings.Contains(out, mdutil.DefaultMachineDeploymentUniqueLabelKey) {
+		t.Errorf("expected the template hash label not to be part of the diff, got:\n%s", out)
+	}
+
+	b.Reset()
+	if err := History(context.Background(), &b, c, "default", "md", 3); err == nil {
+		t.Errorf("expected an error for an unknown revision")
+	}
+}
+
+func TestUndo(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	testCases := []struct {
+		name      string
+		paused    bool
+		revision  int64
+		expectErr bool
+	}{
+		{
+			name:     "rollback to the previous revision",
+			revision: 0,
+		},
+		{
+			name:     "rollback to a given revision",
+			revision: 1,
+		},
+		{
+			name:      "negative revision",
+			revision:  -1,
+			expectErr: true,
+		},
+		{
+			name:      "paused MachineDeployment",
+			paused:    true,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newMachineDeployment()
+			d.Spec.Paused = tc.paused
+			c := fake.NewFakeClient(d)
+
+			var b bytes.Buffer
+			err := Undo(context.Background(), &b, c, "default", "md", tc.revision)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			updated := &clusterv1.MachineDeployment{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "md"}, updated); err != nil {
+				t.Fatal(err)
+			}
+			if updated.Spec.RollbackTo == nil || updated.Spec.RollbackTo.Revision != tc.revision {
+				t.Errorf("expected rollbackTo revision %d, got %+v", tc.revision, updated.Spec.RollbackTo)
+			}
+		})
+	}
+}
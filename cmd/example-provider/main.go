@@ -24,6 +24,7 @@ import (
 	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers"
+	"sigs.k8s.io/cluster-api/util/healthz"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 )
@@ -31,8 +32,11 @@ import (
 func main() {
 	klog.InitFlags(nil)
 	var enableLeaderElection bool
+	var healthAddr string
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&healthAddr, "health-addr", ":9440",
+		"The address the health and readiness probe endpoints bind to.")
 	flag.Parse()
 
 	cfg := ctrl.GetConfigOrDie()
@@ -63,7 +67,19 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	// Providers can add their own checks to the probe endpoints.
+	healthServer := healthz.NewServer(healthAddr)
+	if err := healthz.AddManagerReadinessChecks(healthServer, mgr); err != nil {
+		klog.Fatalf("Failed to set up readiness checks: %v", err)
+	}
+	stop := ctrl.SetupSignalHandler()
+	go func() {
+		if err := healthServer.Start(stop); err != nil {
+			klog.Fatalf("Failed to serve health probes: %v", err)
+		}
+	}()
+
+	if err := mgr.Start(stop); err != nil {
 		klog.Fatalf("Failed to run manager: %v", err)
 	}
 }
@@ -0,0 +1,143 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// bootstrapDataSecretName returns the name of the Secret storing the bootstrap data of the given owner,
+// when the bootstrap data isn't published in a Secret by the bootstrap provider.
+func bootstrapDataSecretName(owner metav1.Object, gvk schema.GroupVersionKind) string {
+	return fmt.Sprintf("%s-%s-bootstrap", owner.GetName(), strings.ToLower(gvk.Kind))
+}
+
+// moveBootstrapDataToSecret moves the deprecated inline bootstrap data to a Secret controlled by the owner.
+func moveBootstrapDataToSecret(ctx context.Context, c client.Client, owner metav1.Object, gvk schema.GroupVersionKind, bootstrap *clusterv1.Bootstrap) error {
+	if bootstrap.Data == nil || bootstrap.DataSecretName != nil {
+		return nil
+	}
+
+	name, err := ensureBootstrapDataSecret(ctx, c, owner, gvk, *bootstrap.Data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to move bootstrap data of %s %q in namespace %q to a secret",
+			gvk.Kind, owner.GetName(), owner.GetNamespace())
+	}
+
+	bootstrap.DataSecretName = pointer.StringPtr(name)
+	bootstrap.Data = nil
+	return nil
+}
+
+// bootstrapDataSecretFromConfig returns the name of the Secret storing the bootstrap data generated by
+// a ready bootstrap provider. Bootstrap providers publish the name of the Secret in status.dataSecretName;
+// for providers that only report the data inline in status.bootstrapData, the data is stored in a Secret
+// controlled by the owner.
+func bootstrapDataSecretFromConfig(ctx context.Context, c client.Client, owner metav1.Object, gvk schema.GroupVersionKind, bootstrapConfig *unstructured.Unstructured) (string, error) {
+	secretName, _, err := unstructured.NestedString(bootstrapConfig.Object, "status", "dataSecretName")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to retrieve data secret name from bootstrap provider for %s %q in namespace %q",
+			gvk.Kind, owner.GetName(), owner.GetNamespace())
+	} else if secretName != "" {
+		return secretName, nil
+	}
+
+	data, _, err := unstructured.NestedString(bootstrapConfig.Object, "status", "bootstrapData")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to retrieve data from bootstrap provider for %s %q in namespace %q",
+			gvk.Kind, owner.GetName(), owner.GetNamespace())
+	} else if data == "" {
+		return "", errors.Errorf("retrieved empty data from bootstrap provider for %s %q in namespace %q",
+			gvk.Kind, owner.GetName(), owner.GetNamespace())
+	}
+
+	return ensureBootstrapDataSecret(ctx, c, owner, gvk, data)
+}
+
+// ensureBootstrapDataSecret stores the given bootstrap data in a Secret controlled by the owner, so that
+// the Secret is garbage collected with it, and returns the name of the Secret.
+func ensureBootstrapDataSecret(ctx context.Context, c client.Client, owner metav1.Object, gvk schema.GroupVersionKind, data string) (string, error) {
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            bootstrapDataSecretName(owner, gvk),
+			Namespace:       owner.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner, gvk)},
+		},
+		Data: map[string][]byte{
+			secret.BootstrapDataName: []byte(data),
+		},
+	}
+	if clusterName, ok := owner.GetLabels()[clusterv1.MachineClusterLabelName]; ok {
+		s.Labels = map[string]string{clusterv1.MachineClusterLabelName: clusterName}
+	}
+
+	err := c.Create(ctx, s)
+	if err == nil {
+		return s.Name, nil
+	} else if !apierrors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	// The Secret could have been created by a previous reconciliation, make sure it's the owner's.
+	existing := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, existing); err != nil {
+		return "", err
+	}
+	if !metav1.IsControlledBy(existing, owner) {
+		return "", errors.Errorf("secret %q in namespace %q already exists and isn't controlled by %s %q",
+			s.Name, s.Namespace, gvk.Kind, owner.GetName())
+	}
+	return existing.Name, nil
+}
+
+// deleteBootstrapDataSecret deletes the Secret storing the bootstrap data of the owner, if it's controlled
+// by the owner. Secrets published by bootstrap providers are left to them.
+func deleteBootstrapDataSecret(ctx context.Context, c client.Client, owner metav1.Object, bootstrap *clusterv1.Bootstrap) error {
+	if bootstrap.DataSecretName == nil {
+		return nil
+	}
+
+	s := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: owner.GetNamespace(), Name: *bootstrap.DataSecretName}, s); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !metav1.IsControlledBy(s, owner) {
+		return nil
+	}
+
+	if err := c.Delete(ctx, s); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete bootstrap data secret %q in namespace %q", s.Name, s.Namespace)
+	}
+	return nil
+}
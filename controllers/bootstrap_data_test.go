@@ -0,0 +1,104 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMoveBootstrapDataToSecret(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-1",
+			Namespace: "default",
+			UID:       "machine-1-uid",
+			Labels:    map[string]string{clusterv1.MachineClusterLabelName: "test-cluster"},
+		},
+		Spec: clusterv1.MachineSpec{
+			Bootstrap: clusterv1.Bootstrap{Data: pointer.StringPtr("#!/bin/bash ... data")},
+		},
+	}
+	c := fake.NewFakeClient(m)
+
+	g.Expect(moveBootstrapDataToSecret(context.Background(), c, m, machineKind, &m.Spec.Bootstrap)).To(gomega.Succeed())
+	g.Expect(m.Spec.Bootstrap.Data).To(gomega.BeNil())
+	g.Expect(m.Spec.Bootstrap.DataSecretName).To(gomega.Equal(pointer.StringPtr("machine-1-machine-bootstrap")))
+
+	s := &corev1.Secret{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "machine-1-machine-bootstrap"}, s)).To(gomega.Succeed())
+	g.Expect(s.Data).To(gomega.HaveKeyWithValue(secret.BootstrapDataName, []byte("#!/bin/bash ... data")))
+	g.Expect(s.Labels).To(gomega.HaveKeyWithValue(clusterv1.MachineClusterLabelName, "test-cluster"))
+	g.Expect(metav1.IsControlledBy(s, m)).To(gomega.BeTrue())
+
+	// Retrying after a failed update of the Machine reuses the Secret.
+	m.Spec.Bootstrap = clusterv1.Bootstrap{Data: pointer.StringPtr("#!/bin/bash ... data")}
+	g.Expect(moveBootstrapDataToSecret(context.Background(), c, m, machineKind, &m.Spec.Bootstrap)).To(gomega.Succeed())
+	g.Expect(m.Spec.Bootstrap.DataSecretName).To(gomega.Equal(pointer.StringPtr("machine-1-machine-bootstrap")))
+
+	// A Secret controlled by someone else is never reused.
+	other := m.DeepCopy()
+	other.UID = "other-uid"
+	other.Spec.Bootstrap = clusterv1.Bootstrap{Data: pointer.StringPtr("#!/bin/bash ... data")}
+	g.Expect(moveBootstrapDataToSecret(context.Background(), c, other, machineKind, &other.Spec.Bootstrap)).ToNot(gomega.Succeed())
+	g.Expect(other.Spec.Bootstrap.DataSecretName).To(gomega.BeNil())
+}
+
+func TestDeleteBootstrapDataSecret(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "default", UID: "machine-1-uid"},
+	}
+	owned := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "owned",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(m, machineKind)},
+		},
+	}
+	published := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "published", Namespace: "default"},
+	}
+	c := fake.NewFakeClient(owned, published)
+
+	// Secrets published by the bootstrap provider are left alone.
+	g.Expect(deleteBootstrapDataSecret(context.Background(), c, m, &clusterv1.Bootstrap{DataSecretName: pointer.StringPtr("published")})).To(gomega.Succeed())
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "published"}, &corev1.Secret{})).To(gomega.Succeed())
+
+	g.Expect(deleteBootstrapDataSecret(context.Background(), c, m, &clusterv1.Bootstrap{DataSecretName: pointer.StringPtr("owned")})).To(gomega.Succeed())
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "owned"}, &corev1.Secret{})
+	g.Expect(apierrors.IsNotFound(err)).To(gomega.BeTrue())
+
+	// Deleting twice is a no-op.
+	g.Expect(deleteBootstrapDataSecret(context.Background(), c, m, &clusterv1.Bootstrap{DataSecretName: pointer.StringPtr("owned")})).To(gomega.Succeed())
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -32,9 +33,11 @@ import (
 	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -58,6 +61,12 @@ type ClusterReconciler struct {
 	client.Client
 	Log logr.Logger
 
+	// APIReader reads from the API server rather than from the cache of the Client, defaults to the Client.
+	APIReader client.Reader
+
+	// WatchFilterValue is the value of the watch label of the objects to reconcile. Every object is reconciled if empty.
+	WatchFilterValue string
+
 	controller       controller.Controller
 	recorder         record.EventRecorder
 	externalWatchers sync.Map
@@ -67,6 +76,7 @@ func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controlle
 	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.Cluster{}).
 		WithOptions(options).
+		WithEventFilter(predicates.ResourceHasFilterLabel(r.WatchFilterValue)).
 		Build(r)
 
 	r.controller = c
@@ -91,6 +101,17 @@ func (r *ClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 		return ctrl.Result{}, err
 	}
 
+	// Ignore the Clusters without the watch label of this manager, they can still be enqueued by other watches.
+	if !util.HasWatchLabel(cluster, r.WatchFilterValue) {
+		return ctrl.Result{}, nil
+	}
+
+	// Return early if the Cluster is paused.
+	if util.IsPaused(cluster, cluster) {
+		klog.Infof("Reconciliation is paused for Cluster %q in namespace %q", cluster.Name, cluster.Namespace)
+		return ctrl.Result{}, nil
+	}
+
 	// Initialize the patch helper.
 	patchHelper, err := patch.NewHelper(cluster, r)
 	if err != nil {
@@ -101,6 +122,9 @@ func (r *ClusterReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 		// Always reconcile the Status.Phase field.
 		r.reconcilePhase(ctx, cluster)
 
+		// Always summarize the Cluster conditions into the Ready condition.
+		r.reconcileReadyCondition(cluster)
+
 		// Always attempt to Patch the Cluster object and status after each reconciliation.
 		if err := patchHelper.Patch(ctx, cluster); err != nil {
 			if reterr == nil {
@@ -128,6 +152,8 @@ func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cl
 	// Call the inner reconciliation methods.
 	reconciliationErrors := []error{
 		r.reconcileInfrastructure(ctx, cluster),
+		r.reconcileControlPlane(ctx, cluster),
+		r.reconcileCertificates(ctx, cluster),
 		r.reconcileKubeconfig(ctx, cluster),
 	}
 
@@ -193,33 +219,49 @@ func (r *ClusterReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 		return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
 	}
 
-	if cluster.Spec.InfrastructureRef != nil {
-		obj, err := external.Get(r.Client, cluster.Spec.InfrastructureRef, cluster.Namespace)
-		switch {
-		case apierrors.IsNotFound(err):
-			// All good - the infra resource has been deleted
-		case err != nil:
-			return ctrl.Result{}, errors.Wrapf(err, "failed to get %s %q for Cluster %s/%s",
-				path.Join(cluster.Spec.InfrastructureRef.APIVersion, cluster.Spec.InfrastructureRef.Kind),
-				cluster.Spec.InfrastructureRef.Name, cluster.Namespace, cluster.Name)
-		default:
-			// Issue a deletion request for the infrastructure object.
-			// Once it's been deleted, the cluster will get processed again.
-			if err := r.Delete(ctx, obj); err != nil {
-				return ctrl.Result{}, errors.Wrapf(err,
-					"failed to delete %v %q for Cluster %q in namespace %q",
-					obj.GroupVersionKind(), obj.GetName(), cluster.Name, cluster.Namespace)
-			}
+	// Delete the control plane before the infrastructure it runs on.
+	for _, ref := range []*corev1.ObjectReference{cluster.Spec.ControlPlaneRef, cluster.Spec.InfrastructureRef} {
+		if ref == nil {
+			continue
+		}
 
+		deleted, err := r.reconcileDeleteExternal(ctx, cluster, ref)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !deleted {
 			// Return here so we don't remove the finalizer yet.
+			// Once the object has been deleted, the cluster will get processed again.
 			return ctrl.Result{}, nil
 		}
 	}
 
+	metrics.DeleteRemoteClusterClientErrors(cluster.Namespace, cluster.Name)
 	cluster.Finalizers = util.Filter(cluster.Finalizers, clusterv1.ClusterFinalizer)
 	return ctrl.Result{}, nil
 }
 
+// reconcileDeleteExternal issues a deletion request for the object referenced by a Cluster,
+// returning true if the object doesn't exist anymore.
+func (r *ClusterReconciler) reconcileDeleteExternal(ctx context.Context, cluster *clusterv1.Cluster, ref *corev1.ObjectReference) (bool, error) {
+	obj, err := external.Get(r.Client, ref, cluster.Namespace)
+	switch {
+	case apierrors.IsNotFound(err):
+		// All good - the external object has been deleted
+		return true, nil
+	case err != nil:
+		return false, errors.Wrapf(err, "failed to get %s %q for Cluster %s/%s",
+			path.Join(ref.APIVersion, ref.Kind), ref.Name, cluster.Namespace, cluster.Name)
+	}
+
+	if err := r.Delete(ctx, obj); err != nil {
+		return false, errors.Wrapf(err,
+			"failed to delete %v %q for Cluster %q in namespace %q",
+			obj.GroupVersionKind(), obj.GetName(), cluster.Name, cluster.Namespace)
+	}
+	return false, nil
+}
+
 // listChildren returns a list of MachineDeployments, MachineSets, and Machines than have an owner reference to cluster
 func (r *ClusterReconciler) listChildren(ctx context.Context, cluster *clusterv1.Cluster) ([]runtime.Object, error) {
 	listOptions := []client.ListOption{
@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -29,9 +30,12 @@ import (
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	"sigs.k8s.io/cluster-api/util/secret"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -66,14 +70,24 @@ func (r *ClusterReconciler) reconcilePhase(ctx context.Context, cluster *cluster
 }
 
 // reconcileExternal handles generic unstructured objects referenced by a Cluster.
-func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clusterv1.Cluster, ref *corev1.ObjectReference) (*unstructured.Unstructured, error) {
+// Failures to retrieve or adopt the object are reported on the condition of the given type.
+func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clusterv1.Cluster, ref *corev1.ObjectReference, t clusterv1.ConditionType) (_ *unstructured.Unstructured, reterr error) {
+	defer func() {
+		if reterr != nil {
+			metrics.RecordExternalObjectReconcileError(ref.GroupVersionKind())
+		}
+	}()
+
 	obj, err := external.Get(r.Client, ref, cluster.Namespace)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(cluster, t, clusterv1.ExternalObjectNotFoundReason, clusterv1.ConditionSeverityWarning,
+				"%s %q not found", ref.Kind, ref.Name)
 			return nil, errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: 30 * time.Second},
 				"could not find %v %q for Cluster %q in namespace %q, requeuing",
 				ref.GroupVersionKind(), ref.Name, cluster.Name, cluster.Namespace)
 		}
+		conditions.MarkFalse(cluster, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 		return nil, err
 	}
 
@@ -87,9 +101,12 @@ func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clus
 		UID:        cluster.UID,
 	}
 
-	if !util.HasOwnerRef(obj.GetOwnerReferences(), ownerRef) {
+	// Also set the watch label of the external object, its events are filtered out otherwise.
+	if !util.HasOwnerRef(obj.GetOwnerReferences(), ownerRef) || !util.HasWatchLabel(obj, r.WatchFilterValue) {
 		obj.SetOwnerReferences(util.EnsureOwnerRef(obj.GetOwnerReferences(), ownerRef))
+		util.SetWatchLabel(obj, r.WatchFilterValue)
 		if err := r.Patch(ctx, obj, objPatch); err != nil {
+			conditions.MarkFalse(cluster, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 			return nil, errors.Wrapf(err,
 				"failed to set OwnerReference on %v %q for Cluster %q in namespace %q",
 				obj.GroupVersionKind(), ref.Name, cluster.Name, cluster.Namespace)
@@ -103,6 +120,7 @@ func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clus
 		err := r.controller.Watch(
 			&source.Kind{Type: obj},
 			&handler.EnqueueRequestForOwner{OwnerType: &clusterv1.Cluster{}},
+			predicates.ResourceHasFilterLabel(r.WatchFilterValue),
 		)
 		if err != nil {
 			r.externalWatchers.Delete(obj.GroupVersionKind().String())
@@ -113,6 +131,7 @@ func (r *ClusterReconciler) reconcileExternal(ctx context.Context, cluster *clus
 	// Set error reason and message, if any.
 	errorReason, errorMessage, err := external.ErrorsFrom(obj)
 	if err != nil {
+		conditions.MarkFalse(cluster, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 		return nil, err
 	}
 	if errorReason != "" {
@@ -133,24 +152,45 @@ func (r *ClusterReconciler) reconcileInfrastructure(ctx context.Context, cluster
 	}
 
 	// Call generic external reconciler.
-	infraConfig, err := r.reconcileExternal(ctx, cluster, cluster.Spec.InfrastructureRef)
+	infraConfig, err := r.reconcileExternal(ctx, cluster, cluster.Spec.InfrastructureRef, clusterv1.InfrastructureReadyCondition)
 	if err != nil {
 		return err
 	}
 
-	if cluster.Status.InfrastructureReady || !infraConfig.GetDeletionTimestamp().IsZero() {
+	// Get and parse Status.FailureDomains field from the infrastructure provider, if any.
+	// Failure domains can be added or removed during the lifetime of a Cluster, so they're always copied,
+	// and cleared if the provider doesn't report any.
+	var failureDomains clusterv1.FailureDomains
+	if err := util.UnstructuredUnmarshalField(infraConfig, &failureDomains, "status", "failureDomains"); err != nil && err != util.ErrUnstructuredFieldNotFound {
+		return errors.Wrapf(err, "failed to retrieve Status.FailureDomains from infrastructure provider for Cluster %q in namespace %q",
+			cluster.Name, cluster.Namespace)
+	}
+	cluster.Status.FailureDomains = failureDomains
+
+	if cluster.Status.InfrastructureReady {
+		conditions.MarkTrue(cluster, clusterv1.InfrastructureReadyCondition)
+		return nil
+	}
+
+	if !infraConfig.GetDeletionTimestamp().IsZero() {
 		return nil
 	}
 
 	// Determine if the infrastructure provider is ready.
 	ready, err := external.IsReady(infraConfig)
 	if err != nil {
+		conditions.MarkFalse(cluster, clusterv1.InfrastructureReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 		return err
 	} else if !ready {
 		klog.V(3).Infof("Infrastructure provider for Cluster %q in namespace %q is not ready yet", cluster.Name, cluster.Namespace)
+		if !conditions.SetMirror(cluster, clusterv1.InfrastructureReadyCondition, conditions.UnstructuredGetter(infraConfig)) {
+			conditions.MarkFalse(cluster, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureReason, clusterv1.ConditionSeverityInfo,
+				"Waiting for %s %q to be ready", infraConfig.GetKind(), infraConfig.GetName())
+		}
 		return nil
 	}
 	cluster.Status.InfrastructureReady = true
+	conditions.MarkTrue(cluster, clusterv1.InfrastructureReadyCondition)
 
 	// Get and parse Status.APIEndpoint field from the infrastructure provider.
 	if err := util.UnstructuredUnmarshalField(infraConfig, &cluster.Status.APIEndpoints, "status", "apiEndpoints"); err != nil {
@@ -164,17 +204,142 @@ func (r *ClusterReconciler) reconcileInfrastructure(ctx context.Context, cluster
 	return nil
 }
 
+// reconcileControlPlane reconciles the Spec.ControlPlaneRef object on a Cluster.
+// The control plane provider is expected to report status.ready once the control plane can serve requests,
+// and status.initialized once the first control plane instance is up; status.replicas and status.version
+// are informational.
+func (r *ClusterReconciler) reconcileControlPlane(ctx context.Context, cluster *clusterv1.Cluster) error {
+	if cluster.Spec.ControlPlaneRef == nil {
+		return nil
+	}
+
+	// Call generic external reconciler.
+	controlPlaneConfig, err := r.reconcileExternal(ctx, cluster, cluster.Spec.ControlPlaneRef, clusterv1.ControlPlaneReadyCondition)
+	if err != nil {
+		return err
+	}
+
+	if !controlPlaneConfig.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	// Determine if the control plane has been initialized.
+	initialized, _, err := unstructured.NestedBool(controlPlaneConfig.Object, "status", "initialized")
+	if err != nil {
+		conditions.MarkFalse(cluster, clusterv1.ControlPlaneReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+		return errors.Wrapf(err, "failed to retrieve Status.Initialized from control plane provider for Cluster %q in namespace %q",
+			cluster.Name, cluster.Namespace)
+	}
+	if initialized {
+		cluster.Status.ControlPlaneInitialized = true
+	}
+
+	// Determine if the control plane provider is ready.
+	ready, err := external.IsReady(controlPlaneConfig)
+	if err != nil {
+		conditions.MarkFalse(cluster, clusterv1.ControlPlaneReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+		return err
+	}
+	cluster.Status.ControlPlaneReady = ready
+	if !ready {
+		klog.V(3).Infof("Control plane provider for Cluster %q in namespace %q is not ready yet", cluster.Name, cluster.Namespace)
+		if !conditions.SetMirror(cluster, clusterv1.ControlPlaneReadyCondition, conditions.UnstructuredGetter(controlPlaneConfig)) {
+			conditions.MarkFalse(cluster, clusterv1.ControlPlaneReadyCondition, clusterv1.WaitingForControlPlaneReason, clusterv1.ConditionSeverityInfo,
+				"Waiting for %s %q to be ready", controlPlaneConfig.GetKind(), controlPlaneConfig.GetName())
+		}
+		return nil
+	}
+	conditions.MarkTrue(cluster, clusterv1.ControlPlaneReadyCondition)
+
+	return nil
+}
+
+// reconcileCertificates looks up the CAs and the service account keys of the Cluster, and generates and stores
+// the missing ones, so that every control plane of the Cluster shares them. Users can supply their own CAs
+// by creating the Secrets before the Cluster. Once the control plane is initialized, the missing certificates
+// aren't generated anymore, since they wouldn't match the ones the control plane has been initialized with.
+func (r *ClusterReconciler) reconcileCertificates(ctx context.Context, cluster *clusterv1.Cluster) error {
+	certificates := secret.NewCertificates()
+	if !cluster.Status.ControlPlaneInitialized {
+		if err := certificates.LookupOrGenerate(ctx, r.Client, r.apiReader(), cluster); err != nil {
+			conditions.MarkFalse(cluster, clusterv1.CertificatesAvailableCondition, clusterv1.CertificatesGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+			return errors.Wrapf(err, "failed to reconcile certificates for Cluster %q in namespace %q", cluster.Name, cluster.Namespace)
+		}
+		conditions.MarkTrue(cluster, clusterv1.CertificatesAvailableCondition)
+		return nil
+	}
+
+	if err := certificates.Lookup(ctx, r.apiReader(), cluster); err != nil {
+		conditions.MarkFalse(cluster, clusterv1.CertificatesAvailableCondition, clusterv1.CertificatesGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+		return errors.Wrapf(err, "failed to look up certificates for Cluster %q in namespace %q", cluster.Name, cluster.Namespace)
+	}
+	if missing := certificates.Missing(); len(missing) > 0 {
+		purposes := make([]string, 0, len(missing))
+		for _, cert := range missing {
+			purposes = append(purposes, string(cert.Purpose))
+		}
+		conditions.MarkFalse(cluster, clusterv1.CertificatesAvailableCondition, clusterv1.CertificatesMissingReason, clusterv1.ConditionSeverityError,
+			"Missing %s certificates of an initialized control plane", strings.Join(purposes, ", "))
+		return nil
+	}
+
+	conditions.MarkTrue(cluster, clusterv1.CertificatesAvailableCondition)
+	return nil
+}
+
+// apiReader returns the reader used to read objects which must not be read from the cache.
+func (r *ClusterReconciler) apiReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
 func (r *ClusterReconciler) reconcileKubeconfig(ctx context.Context, cluster *clusterv1.Cluster) error {
 	if len(cluster.Status.APIEndpoints) == 0 {
+		conditions.MarkFalse(cluster, clusterv1.KubeconfigReadyCondition, clusterv1.WaitingForAPIEndpointsReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for the infrastructure provider to report the API endpoints")
 		return nil
 	}
 
 	_, err := secret.Get(r.Client, cluster, secret.Kubeconfig)
 	switch {
 	case apierrors.IsNotFound(err):
-		return kubeconfig.CreateSecret(ctx, r.Client, cluster)
+		if err := kubeconfig.CreateSecret(ctx, r.Client, cluster); err != nil {
+			conditions.MarkFalse(cluster, clusterv1.KubeconfigReadyCondition, clusterv1.KubeconfigSecretFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
+			return err
+		}
 	case err != nil:
+		conditions.MarkFalse(cluster, clusterv1.KubeconfigReadyCondition, clusterv1.KubeconfigSecretFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
 		return errors.Wrapf(err, "failed to retrieve Kubeconfig Secret for Cluster %q in namespace %q", cluster.Name, cluster.Namespace)
 	}
+
+	conditions.MarkTrue(cluster, clusterv1.KubeconfigReadyCondition)
 	return nil
 }
+
+// reconcileReadyCondition sets the ControlPlaneInitialized condition and summarizes the
+// conditions owned by the Cluster controller into the Ready condition.
+func (r *ClusterReconciler) reconcileReadyCondition(cluster *clusterv1.Cluster) {
+	switch {
+	case cluster.Status.ControlPlaneInitialized:
+		conditions.MarkTrue(cluster, clusterv1.ControlPlaneInitializedCondition)
+	case cluster.Spec.ControlPlaneRef != nil:
+		conditions.MarkFalse(cluster, clusterv1.ControlPlaneInitializedCondition, clusterv1.WaitingForControlPlaneReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for %s %q to be initialized", cluster.Spec.ControlPlaneRef.Kind, cluster.Spec.ControlPlaneRef.Name)
+	default:
+		conditions.MarkFalse(cluster, clusterv1.ControlPlaneInitializedCondition, clusterv1.WaitingForControlPlaneReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for the first control plane Machine to have a Node")
+	}
+
+	conditionTypes := []clusterv1.ConditionType{
+		clusterv1.InfrastructureReadyCondition,
+		clusterv1.CertificatesAvailableCondition,
+		clusterv1.KubeconfigReadyCondition,
+		clusterv1.ControlPlaneInitializedCondition,
+	}
+	if cluster.Spec.ControlPlaneRef != nil {
+		conditionTypes = append(conditionTypes, clusterv1.ControlPlaneReadyCondition)
+	}
+	conditions.SetSummary(cluster, conditionTypes...)
+}
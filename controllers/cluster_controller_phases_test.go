@@ -0,0 +1,582 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestClusterReconcileInfrastructure(t *testing.T) {
+	defaultCluster := clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+		},
+		Spec: clusterv1.ClusterSpec{
+			InfrastructureRef: &corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha2",
+				Kind:       "InfrastructureConfig",
+				Name:       "infra-config1",
+			},
+		},
+		Status: clusterv1.ClusterStatus{
+			FailureDomains: clusterv1.FailureDomains{
+				"us-east-1c": clusterv1.FailureDomainSpec{},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		infraConfig map[string]interface{}
+		expectError bool
+		expected    func(g *gomega.WithT, c *clusterv1.Cluster)
+	}{
+		{
+			name: "infrastructure config ready",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"ready": true,
+					"apiEndpoints": []interface{}{
+						map[string]interface{}{
+							"host": "1.2.3.4",
+							"port": 6443,
+						},
+					},
+				},
+			},
+			expectError: false,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(c.Status.InfrastructureReady).To(gomega.BeTrue())
+				g.Expect(conditions.IsTrue(c, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+			},
+		},
+		{
+			name: "infrastructure config reports failure domains",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"ready": true,
+					"apiEndpoints": []interface{}{
+						map[string]interface{}{
+							"host": "1.2.3.4",
+							"port": 6443,
+						},
+					},
+					"failureDomains": map[string]interface{}{
+						"us-east-1a": map[string]interface{}{
+							"controlPlane": true,
+						},
+						"us-east-1b": map[string]interface{}{
+							"attributes": map[string]interface{}{
+								"subnet": "subnet-1",
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(c.Status.FailureDomains).To(gomega.Equal(clusterv1.FailureDomains{
+					"us-east-1a": clusterv1.FailureDomainSpec{ControlPlane: true},
+					"us-east-1b": clusterv1.FailureDomainSpec{Attributes: map[string]string{"subnet": "subnet-1"}},
+				}))
+			},
+		},
+		{
+			name: "infrastructure config stops reporting failure domains",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec":   map[string]interface{}{},
+				"status": map[string]interface{}{},
+			},
+			expectError: false,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(c.Status.FailureDomains).To(gomega.BeEmpty())
+			},
+		},
+		{
+			name: "infrastructure config not ready",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec":   map[string]interface{}{},
+				"status": map[string]interface{}{},
+			},
+			expectError: false,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(c.Status.InfrastructureReady).To(gomega.BeFalse())
+				g.Expect(conditions.IsFalse(c, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(c, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal(clusterv1.WaitingForInfrastructureReason))
+			},
+		},
+		{
+			name: "infrastructure config not ready, mirrors its Ready condition",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{
+							"type":     "Ready",
+							"status":   "False",
+							"severity": "Warning",
+							"reason":   "LoadBalancerFailed",
+							"message":  "failed to create the load balancer",
+						},
+					},
+				},
+			},
+			expectError: false,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.IsFalse(c, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(c, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal("LoadBalancerFailed"))
+				g.Expect(conditions.Get(c, clusterv1.InfrastructureReadyCondition).Severity).To(gomega.Equal(clusterv1.ConditionSeverityWarning))
+			},
+		},
+		{
+			name: "infrastructure config is not found",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "wrong-namespace",
+				},
+				"spec":   map[string]interface{}{},
+				"status": map[string]interface{}{},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.IsFalse(c, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(c, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal(clusterv1.ExternalObjectNotFoundReason))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			cluster := defaultCluster.DeepCopy()
+			infraConfig := &unstructured.Unstructured{Object: tc.infraConfig}
+			r := &ClusterReconciler{
+				Client: fake.NewFakeClient(cluster, infraConfig),
+				Log:    log.Log,
+			}
+
+			err := r.reconcileInfrastructure(context.Background(), cluster)
+			if tc.expectError {
+				g.Expect(err).ToNot(gomega.BeNil())
+			} else {
+				g.Expect(err).To(gomega.BeNil())
+			}
+
+			if tc.expected != nil {
+				tc.expected(g, cluster)
+			}
+		})
+	}
+}
+
+func TestClusterReconcileControlPlane(t *testing.T) {
+	defaultCluster := clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+		},
+		Spec: clusterv1.ClusterSpec{
+			ControlPlaneRef: &corev1.ObjectReference{
+				APIVersion: "controlplane.cluster.x-k8s.io/v1alpha2",
+				Kind:       "ControlPlaneConfig",
+				Name:       "control-plane1",
+			},
+		},
+	}
+
+	controlPlaneConfig := func(status map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"kind":       "ControlPlaneConfig",
+			"apiVersion": "controlplane.cluster.x-k8s.io/v1alpha2",
+			"metadata": map[string]interface{}{
+				"name":      "control-plane1",
+				"namespace": "default",
+			},
+			"spec":   map[string]interface{}{},
+			"status": status,
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		controlPlaneConfig map[string]interface{}
+		expectError        bool
+		expected           func(g *gomega.WithT, c *clusterv1.Cluster)
+	}{
+		{
+			name: "control plane ready and initialized",
+			controlPlaneConfig: controlPlaneConfig(map[string]interface{}{
+				"ready":       true,
+				"initialized": true,
+				"replicas":    int64(3),
+				"version":     "v1.16.2",
+			}),
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(c.Status.ControlPlaneReady).To(gomega.BeTrue())
+				g.Expect(c.Status.ControlPlaneInitialized).To(gomega.BeTrue())
+				g.Expect(conditions.IsTrue(c, clusterv1.ControlPlaneReadyCondition)).To(gomega.BeTrue())
+			},
+		},
+		{
+			name: "control plane initialized, not ready",
+			controlPlaneConfig: controlPlaneConfig(map[string]interface{}{
+				"initialized": true,
+			}),
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(c.Status.ControlPlaneReady).To(gomega.BeFalse())
+				g.Expect(c.Status.ControlPlaneInitialized).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(c, clusterv1.ControlPlaneReadyCondition)).To(gomega.Equal(clusterv1.WaitingForControlPlaneReason))
+			},
+		},
+		{
+			name:               "control plane not initialized",
+			controlPlaneConfig: controlPlaneConfig(map[string]interface{}{}),
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(c.Status.ControlPlaneReady).To(gomega.BeFalse())
+				g.Expect(c.Status.ControlPlaneInitialized).To(gomega.BeFalse())
+				g.Expect(conditions.IsFalse(c, clusterv1.ControlPlaneReadyCondition)).To(gomega.BeTrue())
+			},
+		},
+		{
+			name: "control plane config is not found",
+			controlPlaneConfig: map[string]interface{}{
+				"kind":       "ControlPlaneConfig",
+				"apiVersion": "controlplane.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "control-plane1",
+					"namespace": "wrong-namespace",
+				},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.GetReason(c, clusterv1.ControlPlaneReadyCondition)).To(gomega.Equal(clusterv1.ExternalObjectNotFoundReason))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			cluster := defaultCluster.DeepCopy()
+			controlPlane := &unstructured.Unstructured{Object: tc.controlPlaneConfig}
+			r := &ClusterReconciler{
+				Client: fake.NewFakeClient(cluster, controlPlane),
+				Log:    log.Log,
+			}
+
+			err := r.reconcileControlPlane(context.Background(), cluster)
+			if tc.expectError {
+				g.Expect(err).ToNot(gomega.BeNil())
+			} else {
+				g.Expect(err).To(gomega.BeNil())
+			}
+
+			tc.expected(g, cluster)
+		})
+	}
+}
+
+func TestClusterReconcileKubeconfig(t *testing.T) {
+	testCases := []struct {
+		name         string
+		apiEndpoints []clusterv1.APIEndpoint
+		objects      []runtime.Object
+		expectError  bool
+		expected     func(g *gomega.WithT, c *clusterv1.Cluster)
+	}{
+		{
+			name: "no api endpoints",
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.IsFalse(c, clusterv1.KubeconfigReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(c, clusterv1.KubeconfigReadyCondition)).To(gomega.Equal(clusterv1.WaitingForAPIEndpointsReason))
+			},
+		},
+		{
+			name:         "kubeconfig secret exists",
+			apiEndpoints: []clusterv1.APIEndpoint{{Host: "1.2.3.4", Port: 6443}},
+			objects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      secret.Name("test-cluster", secret.Kubeconfig),
+						Namespace: "default",
+					},
+				},
+			},
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.IsTrue(c, clusterv1.KubeconfigReadyCondition)).To(gomega.BeTrue())
+			},
+		},
+		{
+			name:         "kubeconfig secret can't be generated without the cluster CA",
+			apiEndpoints: []clusterv1.APIEndpoint{{Host: "1.2.3.4", Port: 6443}},
+			expectError:  true,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.IsFalse(c, clusterv1.KubeconfigReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(c, clusterv1.KubeconfigReadyCondition)).To(gomega.Equal(clusterv1.KubeconfigSecretFailedReason))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-cluster",
+					Namespace: "default",
+				},
+				Status: clusterv1.ClusterStatus{
+					APIEndpoints: tc.apiEndpoints,
+				},
+			}
+
+			r := &ClusterReconciler{
+				Client: fake.NewFakeClientWithScheme(scheme.Scheme, tc.objects...),
+				Log:    log.Log,
+			}
+
+			err := r.reconcileKubeconfig(context.Background(), cluster)
+			if tc.expectError {
+				g.Expect(err).ToNot(gomega.BeNil())
+			} else {
+				g.Expect(err).To(gomega.BeNil())
+			}
+
+			tc.expected(g, cluster)
+		})
+	}
+}
+
+func TestClusterReconcileCertificates(t *testing.T) {
+	newCluster := func(initialized bool) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-cluster",
+				Namespace: "default",
+			},
+			Status: clusterv1.ClusterStatus{
+				ControlPlaneInitialized: initialized,
+			},
+		}
+	}
+
+	existing := secret.NewCertificates()
+	if err := existing.Generate(); err != nil {
+		t.Fatalf("failed to generate the certificates: %v", err)
+	}
+	var existingSecrets []runtime.Object
+	for _, cert := range existing {
+		existingSecrets = append(existingSecrets, cert.AsSecret(newCluster(false)))
+	}
+
+	testCases := []struct {
+		name          string
+		initialized   bool
+		apiObjects    []runtime.Object
+		expectSecrets bool
+		expected      func(g *gomega.WithT, c *clusterv1.Cluster)
+	}{
+		{
+			name:          "control plane not initialized, certificates generated",
+			expectSecrets: true,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.IsTrue(c, clusterv1.CertificatesAvailableCondition)).To(gomega.BeTrue())
+			},
+		},
+		{
+			name:          "control plane initialized, certificates looked up from the API server",
+			initialized:   true,
+			apiObjects:    existingSecrets,
+			expectSecrets: true,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.IsTrue(c, clusterv1.CertificatesAvailableCondition)).To(gomega.BeTrue())
+			},
+		},
+		{
+			name:        "control plane initialized, missing certificates not generated",
+			initialized: true,
+			expected: func(g *gomega.WithT, c *clusterv1.Cluster) {
+				g.Expect(conditions.IsFalse(c, clusterv1.CertificatesAvailableCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(c, clusterv1.CertificatesAvailableCondition)).To(gomega.Equal(clusterv1.CertificatesMissingReason))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			cluster := newCluster(tc.initialized)
+			// The Secrets created after the cache has been synced are only visible to the APIReader.
+			apiReader := fake.NewFakeClientWithScheme(scheme.Scheme, tc.apiObjects...)
+			r := &ClusterReconciler{
+				Client:    apiReader,
+				APIReader: apiReader,
+				Log:       log.Log,
+			}
+			if tc.apiObjects != nil {
+				r.Client = fake.NewFakeClientWithScheme(scheme.Scheme)
+			}
+
+			g.Expect(r.reconcileCertificates(context.Background(), cluster)).To(gomega.Succeed())
+			tc.expected(g, cluster)
+
+			for _, purpose := range []secret.Purpose{secret.ClusterCA, secret.EtcdCA, secret.FrontProxyCA, secret.ServiceAccount} {
+				s := &corev1.Secret{}
+				err := apiReader.Get(context.Background(), client.ObjectKey{Namespace: cluster.Namespace, Name: secret.Name(cluster.Name, purpose)}, s)
+				if tc.expectSecrets {
+					g.Expect(err).NotTo(gomega.HaveOccurred())
+				} else {
+					g.Expect(apierrors.IsNotFound(err)).To(gomega.BeTrue())
+				}
+			}
+		})
+	}
+}
+
+func TestClusterReconcileReadyCondition(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		controlPlaneRef         *corev1.ObjectReference
+		controlPlaneInitialized bool
+		conditions              clusterv1.Conditions
+		expectedStatus          corev1.ConditionStatus
+		expectedReason          string
+	}{
+		{
+			name:                    "control plane not initialized",
+			controlPlaneInitialized: false,
+			conditions: clusterv1.Conditions{
+				*conditions.TrueCondition(clusterv1.InfrastructureReadyCondition),
+				*conditions.TrueCondition(clusterv1.KubeconfigReadyCondition),
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: clusterv1.WaitingForControlPlaneReason,
+		},
+		{
+			name:                    "infrastructure failed",
+			controlPlaneInitialized: true,
+			conditions: clusterv1.Conditions{
+				*conditions.FalseCondition(clusterv1.InfrastructureReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, ""),
+				*conditions.FalseCondition(clusterv1.KubeconfigReadyCondition, clusterv1.WaitingForAPIEndpointsReason, clusterv1.ConditionSeverityInfo, ""),
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: clusterv1.ExternalObjectErrorReason,
+		},
+		{
+			name:                    "all ready",
+			controlPlaneInitialized: true,
+			conditions: clusterv1.Conditions{
+				*conditions.TrueCondition(clusterv1.InfrastructureReadyCondition),
+				*conditions.TrueCondition(clusterv1.KubeconfigReadyCondition),
+			},
+			expectedStatus: corev1.ConditionTrue,
+		},
+		{
+			name:                    "control plane provider not ready",
+			controlPlaneRef:         &corev1.ObjectReference{Kind: "ControlPlaneConfig", Name: "control-plane1"},
+			controlPlaneInitialized: true,
+			conditions: clusterv1.Conditions{
+				*conditions.TrueCondition(clusterv1.InfrastructureReadyCondition),
+				*conditions.TrueCondition(clusterv1.KubeconfigReadyCondition),
+				*conditions.FalseCondition(clusterv1.ControlPlaneReadyCondition, clusterv1.WaitingForControlPlaneReason, clusterv1.ConditionSeverityInfo, ""),
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: clusterv1.WaitingForControlPlaneReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			cluster := &clusterv1.Cluster{
+				Spec: clusterv1.ClusterSpec{
+					ControlPlaneRef: tc.controlPlaneRef,
+				},
+				Status: clusterv1.ClusterStatus{
+					ControlPlaneInitialized: tc.controlPlaneInitialized,
+					Conditions:              tc.conditions,
+				},
+			}
+
+			r := &ClusterReconciler{}
+			r.reconcileReadyCondition(cluster)
+
+			g.Expect(conditions.IsTrue(cluster, clusterv1.ControlPlaneInitializedCondition)).To(gomega.Equal(tc.controlPlaneInitialized))
+
+			ready := conditions.Get(cluster, clusterv1.ReadyCondition)
+			g.Expect(ready).ToNot(gomega.BeNil())
+			g.Expect(ready.Status).To(gomega.Equal(tc.expectedStatus))
+			g.Expect(ready.Reason).To(gomega.Equal(tc.expectedReason))
+		})
+	}
+}
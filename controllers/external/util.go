@@ -28,7 +28,12 @@ import (
 )
 
 // Get uses the client and reference to get an external, unstructured object.
+// The object is read in the given namespace, the namespace of the object referencing it, so that
+// managers limited to some namespaces and their namespaced Roles can read it.
 func Get(c client.Client, ref *corev1.ObjectReference, namespace string) (*unstructured.Unstructured, error) {
+	if namespace == "" {
+		return nil, errors.Errorf("cannot get %s %q without a namespace", ref.Kind, ref.Name)
+	}
 	obj := new(unstructured.Unstructured)
 	obj.SetAPIVersion(ref.APIVersion)
 	obj.SetKind(ref.Kind)
@@ -31,13 +31,17 @@ import (
 	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var (
@@ -47,7 +51,7 @@ var (
 )
 
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
-// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
@@ -57,22 +61,55 @@ type MachineReconciler struct {
 	client.Client
 	Log logr.Logger
 
+	// Tracker caches the clients of workload clusters. If unset, a new client is created on every request.
+	Tracker *remote.ClusterCacheTracker
+
+	// WatchFilterValue is the value of the watch label of the objects to reconcile. Every object is reconciled if empty.
+	WatchFilterValue string
+
 	controller       controller.Controller
 	recorder         record.EventRecorder
 	externalWatchers sync.Map
+
+	// remoteClientGetter returns a client for the workload cluster, defaults to the Tracker if set
+	// or remote.NewClusterClient otherwise.
+	remoteClientGetter remote.ClusterClientGetter
 }
 
 func (r *MachineReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.Machine{}).
 		WithOptions(options).
+		WithEventFilter(predicates.ResourceHasFilterLabel(r.WatchFilterValue)).
 		Build(r)
+	if err != nil {
+		return err
+	}
+
+	// Reconcile the Machines of a Cluster as soon as it's unpaused.
+	err = c.Watch(
+		&source.Kind{Type: &clusterv1.Cluster{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: util.ClusterToObjectsMapFunc(r.Client, &clusterv1.MachineList{})},
+		predicates.ClusterUnpaused(),
+		predicates.ResourceHasFilterLabel(r.WatchFilterValue),
+	)
 
 	r.controller = c
 	r.recorder = mgr.GetEventRecorderFor("machine-controller")
 	return err
 }
 
+// clusterClientGetter returns the function used to create clients for workload clusters.
+func (r *MachineReconciler) clusterClientGetter() remote.ClusterClientGetter {
+	if r.remoteClientGetter != nil {
+		return r.remoteClientGetter
+	}
+	if r.Tracker != nil {
+		return r.Tracker.ClusterClient
+	}
+	return remote.NewClusterClient
+}
+
 func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
 	ctx := context.Background()
 	_ = r.Log.WithValues("machine", req.NamespacedName)
@@ -90,35 +127,54 @@ func (r *MachineReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr e
 		return ctrl.Result{}, err
 	}
 
+	// Ignore the Machines without the watch label of this manager, they can still be enqueued by other watches.
+	if !util.HasWatchLabel(m, r.WatchFilterValue) {
+		return ctrl.Result{}, nil
+	}
+
+	// Cluster might be nil as some providers might not require a cluster object
+	// for machine management.
+	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, m.ObjectMeta)
+	if errors.Cause(err) == util.ErrNoCluster {
+		klog.V(2).Infof("Machine %q in namespace %q doesn't specify %q label, assuming nil cluster",
+			m.Name, m.Namespace, clusterv1.MachineClusterLabelName)
+	} else if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get cluster %q for machine %q in namespace %q",
+			m.Labels[clusterv1.MachineClusterLabelName], m.Name, m.Namespace)
+	}
+
+	// Return early if the Machine or its Cluster is paused.
+	if util.IsPaused(cluster, m) {
+		klog.Infof("Reconciliation is paused for Machine %q in namespace %q", m.Name, m.Namespace)
+		return ctrl.Result{}, nil
+	}
+
 	// Initialize the patch helper
 	patchHelper, err := patch.NewHelper(m, r)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Keep the original status, to observe the provisioning milestones reached by this reconciliation.
+	previousStatus := m.Status.DeepCopy()
+
 	defer func() {
 		// Always reconcile the Status.Phase field.
 		r.reconcilePhase(ctx, m)
 
+		// Always summarize the Machine conditions into the Ready condition.
+		r.reconcileReadyCondition(m)
+
 		// Always attempt to Patch the Machine object and status after each reconciliation.
 		if err := patchHelper.Patch(ctx, m); err != nil {
 			if reterr == nil {
 				reterr = err
 			}
+			return
 		}
+		metrics.ObserveMachineProvisioning(previousStatus, m)
 	}()
 
-	// Cluster might be nil as some providers might not require a cluster object
-	// for machine management.
-	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, m.ObjectMeta)
-	if errors.Cause(err) == util.ErrNoCluster {
-		klog.V(2).Infof("Machine %q in namespace %q doesn't specify %q label, assuming nil cluster",
-			m.Name, m.Namespace, clusterv1.MachineClusterLabelName)
-	} else if err != nil {
-		return ctrl.Result{}, errors.Wrapf(err, "failed to get cluster %q for machine %q in namespace %q",
-			m.Labels[clusterv1.MachineClusterLabelName], m.Name, m.Namespace)
-	}
-
 	// Handle deletion reconciliation loop.
 	if !m.ObjectMeta.DeletionTimestamp.IsZero() {
 		return r.reconcileDelete(ctx, cluster, m)
@@ -148,7 +204,9 @@ func (r *MachineReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cl
 	reconciliationErrors := []error{
 		r.reconcileBootstrap(ctx, m),
 		r.reconcileInfrastructure(ctx, m),
+		r.watchClusterNodes(cluster),
 		r.reconcileNodeRef(ctx, cluster, m),
+		r.reconcileNodeMetadata(ctx, cluster, m),
 		r.reconcileClusterStatus(ctx, cluster, m),
 	}
 
@@ -183,6 +241,17 @@ func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 			return ctrl.Result{}, err
 		}
 	} else {
+		// Drain the node before deleting it, unless the user explicitly opted out.
+		if _, exists := m.ObjectMeta.Annotations[clusterv1.ExcludeNodeDrainingAnnotation]; !exists {
+			if err := r.drainNode(ctx, cluster, m); err != nil {
+				if requeueErr, ok := errors.Cause(err).(capierrors.HasRequeueAfterError); ok {
+					return ctrl.Result{RequeueAfter: requeueErr.GetRequeueAfter()}, nil
+				}
+				klog.Errorf("Error draining node %q for machine %q: %v", m.Status.NodeRef.Name, m.Name, err)
+				return ctrl.Result{}, err
+			}
+		}
+
 		klog.Infof("Deleting node %q for machine %q", m.Status.NodeRef.Name, m.Name)
 		if err := r.deleteNode(ctx, cluster, m.Status.NodeRef.Name); err != nil && !apierrors.IsNotFound(err) {
 			klog.Errorf("Error deleting node %q for machine %q: %v", m.Status.NodeRef.Name, m.Name, err)
@@ -196,6 +265,11 @@ func (r *MachineReconciler) reconcileDelete(ctx context.Context, cluster *cluste
 		return ctrl.Result{}, err
 	}
 
+	// Delete the bootstrap data secret, if it has been created by the controller.
+	if err := deleteBootstrapDataSecret(ctx, r.Client, m, &m.Spec.Bootstrap); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	m.ObjectMeta.Finalizers = util.Filter(m.ObjectMeta.Finalizers, clusterv1.MachineFinalizer)
 	return ctrl.Result{}, nil
 }
@@ -243,21 +317,22 @@ func (r *MachineReconciler) deleteNode(ctx context.Context, cluster *clusterv1.C
 	}
 
 	// Otherwise, proceed to get the remote cluster client and get the Node.
-	remoteClient, err := remote.NewClusterClient(r.Client, cluster)
+	remoteClient, err := r.clusterClientGetter()(r.Client, cluster)
 	if err != nil {
 		klog.Errorf("Error creating a remote client for cluster %q while deleting Machine %q, won't retry: %v",
 			cluster.Name, name, err)
 		return nil
 	}
 
-	corev1Remote, err := remoteClient.CoreV1()
+	workloadClient, err := remoteClient.Client()
 	if err != nil {
 		klog.Errorf("Error creating a remote client for cluster %q while deleting Machine %q, won't retry: %v",
 			cluster.Name, name, err)
 		return nil
 	}
 
-	return corev1Remote.Nodes().Delete(name, &metav1.DeleteOptions{})
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	return workloadClient.Delete(ctx, node)
 }
 
 // getMachinesInCluster returns all of the Machine objects that belong to the
@@ -321,6 +396,8 @@ func (r *MachineReconciler) reconcileDeleteExternal(ctx context.Context, m *clus
 	return len(objects) == 0, nil
 }
 
+// shouldAdopt returns true if the Machine should be owned by its Cluster.
+// Machines that already have a controller, e.g. a MachineSet or a control plane provider, are left alone.
 func (r *MachineReconciler) shouldAdopt(m *clusterv1.Machine) bool {
-	return !util.HasOwner(m.OwnerReferences, clusterv1.GroupVersion.String(), []string{"MachineSet", "Cluster"})
+	return metav1.GetControllerOf(m) == nil && !util.HasOwner(m.OwnerReferences, clusterv1.GroupVersion.String(), []string{"MachineSet", "Cluster"})
 }
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/drain"
+)
+
+var (
+	// nodeDrainRetryInterval is how long to wait before checking again on pods
+	// that are still running on a Node being drained.
+	nodeDrainRetryInterval = 20 * time.Second
+
+	// unreachableNodeSkipWaitForDeleteTimeoutSeconds is how long to wait for pods that are
+	// terminating on an unreachable Node, where they would otherwise never go away.
+	unreachableNodeSkipWaitForDeleteTimeoutSeconds = 1
+)
+
+// drainNode cordons and drains the Node referenced by the Machine, returning a RequeueAfterError
+// until all the pods that can be evicted are gone.
+//
+// Machines that don't belong to a Cluster aren't drained: their Node is deleted through the
+// management cluster client by deleteNode, which doesn't give access to the eviction API.
+func (r *MachineReconciler) drainNode(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	nodeName := m.Status.NodeRef.Name
+
+	if cluster == nil {
+		klog.Warningf("Skipping drain of node %q for machine %q in namespace %q: machine doesn't belong to a cluster",
+			nodeName, m.Name, m.Namespace)
+		return nil
+	}
+
+	if isNodeDrainTimeoutExceeded(m) {
+		klog.Warningf("Timed out draining node %q for machine %q in namespace %q, proceeding with deletion",
+			nodeName, m.Name, m.Namespace)
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "NodeDrainTimeout",
+			"Timed out after %v draining node %q", m.Spec.NodeDrainTimeout.Duration, nodeName)
+		return nil
+	}
+
+	// Errors creating the remote client are returned so that the drain is retried,
+	// until it succeeds or NodeDrainTimeout expires.
+	remoteClient, err := r.clusterClientGetter()(r.Client, cluster)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a remote client for cluster %q while draining Machine %q in namespace %q",
+			cluster.Name, m.Name, m.Namespace)
+	}
+
+	corev1Remote, err := remoteClient.CoreV1()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a remote client for cluster %q while draining Machine %q in namespace %q",
+			cluster.Name, m.Name, m.Namespace)
+	}
+
+	return r.cordonAndDrain(corev1Remote, m)
+}
+
+func (r *MachineReconciler) cordonAndDrain(client corev1client.CoreV1Interface, m *clusterv1.Machine) error {
+	nodeName := m.Status.NodeRef.Name
+
+	node, err := client.Nodes().Get(nodeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// Nothing left to drain.
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to get node %q for Machine %q in namespace %q", nodeName, m.Name, m.Namespace)
+	}
+
+	drainer := &drain.Helper{
+		Client: client,
+		// Respect the grace period of each pod.
+		GracePeriodSeconds: -1,
+	}
+
+	// Pods can't finish terminating on a Node that stopped reporting its status,
+	// don't wait for them once their deletion has started.
+	if isNodeUnreachable(node) {
+		klog.Infof("Node %q for machine %q is unreachable, not waiting for terminating pods", nodeName, m.Name)
+		drainer.SkipWaitForDeleteTimeoutSeconds = unreachableNodeSkipWaitForDeleteTimeoutSeconds
+	}
+
+	remaining, err := drainer.Drain(node)
+	if err != nil {
+		r.recorder.Eventf(m, corev1.EventTypeWarning, "FailedDrainNode", "Error draining node %q: %v", nodeName, err)
+		return errors.Wrapf(err, "failed to drain node %q for Machine %q in namespace %q", nodeName, m.Name, m.Namespace)
+	}
+
+	if remaining > 0 {
+		klog.Infof("Waiting for %d pods to be evicted from node %q for machine %q", remaining, nodeName, m.Name)
+		return &capierrors.RequeueAfterError{RequeueAfter: nodeDrainRetryInterval}
+	}
+
+	r.recorder.Eventf(m, corev1.EventTypeNormal, "SuccessfulDrainNode", "Drained node %q", nodeName)
+	return nil
+}
+
+// isNodeUnreachable returns true if the Node's Ready condition is Unknown, i.e. the kubelet stopped posting its status.
+func isNodeUnreachable(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionUnknown
+		}
+	}
+	return false
+}
+
+// isNodeDrainTimeoutExceeded returns true if the Machine has been deleting for longer than its NodeDrainTimeout.
+func isNodeDrainTimeoutExceeded(m *clusterv1.Machine) bool {
+	if m.Spec.NodeDrainTimeout == nil || m.Spec.NodeDrainTimeout.Duration <= 0 || m.DeletionTimestamp.IsZero() {
+		return false
+	}
+
+	return time.Since(m.DeletionTimestamp.Time) > m.Spec.NodeDrainTimeout.Duration
+}
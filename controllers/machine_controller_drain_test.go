@@ -0,0 +1,283 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	restclient "k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// fakeClusterClient is a remote.ClusterClient backed by fake clients.
+type fakeClusterClient struct {
+	coreV1 corev1client.CoreV1Interface
+	client client.Client
+}
+
+func (f *fakeClusterClient) RESTConfig() *restclient.Config {
+	return &restclient.Config{}
+}
+
+func (f *fakeClusterClient) CoreV1() (corev1client.CoreV1Interface, error) {
+	return f.coreV1, nil
+}
+
+func (f *fakeClusterClient) Client() (client.Client, error) {
+	return f.client, nil
+}
+
+func fakeClusterClientGetter(coreV1 corev1client.CoreV1Interface) remote.ClusterClientGetter {
+	return func(_ client.Client, _ *clusterv1.Cluster) (remote.ClusterClient, error) {
+		return &fakeClusterClient{coreV1: coreV1, client: fake.NewFakeClient()}, nil
+	}
+}
+
+func TestIsNodeDrainTimeoutExceeded(t *testing.T) {
+	deletedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+
+	testCases := []struct {
+		name     string
+		timeout  *metav1.Duration
+		deleted  *metav1.Time
+		expected bool
+	}{
+		{name: "no timeout", deleted: &deletedAt, expected: false},
+		{name: "zero timeout", timeout: &metav1.Duration{}, deleted: &deletedAt, expected: false},
+		{name: "not deleted", timeout: &metav1.Duration{Duration: time.Second}, expected: false},
+		{name: "within timeout", timeout: &metav1.Duration{Duration: time.Hour}, deleted: &deletedAt, expected: false},
+		{name: "timeout exceeded", timeout: &metav1.Duration{Duration: time.Second}, deleted: &deletedAt, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: tc.deleted},
+				Spec:       clusterv1.MachineSpec{NodeDrainTimeout: tc.timeout},
+			}
+			if actual := isNodeDrainTimeoutExceeded(m); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCordonAndDrain(t *testing.T) {
+	r := &MachineReconciler{
+		Client:   fake.NewFakeClient(),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "default"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node-1"},
+		},
+	}
+
+	// A missing node has nothing left to drain.
+	if err := r.cordonAndDrain(fakeclient.NewSimpleClientset().CoreV1(), m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Pods still running on the node require a requeue.
+	client := fakeclient.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default", DeletionTimestamp: &metav1.Time{Time: time.Now()}},
+			Spec:       corev1.PodSpec{NodeName: "node-1"},
+		},
+	)
+	err := r.cordonAndDrain(client.CoreV1(), m)
+	if !capierrors.IsRequeueAfter(err) {
+		t.Fatalf("expected a RequeueAfterError, got %v", err)
+	}
+}
+
+func TestCordonAndDrainUnreachableNode(t *testing.T) {
+	r := &MachineReconciler{
+		Client:   fake.NewFakeClient(),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "default"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node-1"},
+		},
+	}
+
+	// The pod has been terminating for a while on a Node whose kubelet stopped reporting.
+	client := fakeclient.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default", DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-time.Minute)}},
+			Spec:       corev1.PodSpec{NodeName: "node-1"},
+		},
+	)
+	if err := r.cordonAndDrain(client.CoreV1(), m); err != nil {
+		t.Fatalf("expected the drain to complete, got %v", err)
+	}
+}
+
+func TestReconcileDeleteDrain(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+
+	controlPlane := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "control-plane",
+			Namespace: "default",
+			Labels: map[string]string{
+				clusterv1.MachineClusterLabelName:      "test-cluster",
+				clusterv1.MachineControlPlaneLabelName: "true",
+			},
+		},
+	}
+
+	newMachine := func(annotations map[string]string, timeout *metav1.Duration, deletedAt time.Time) *clusterv1.Machine {
+		deletionTimestamp := metav1.NewTime(deletedAt)
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "worker",
+				Namespace:         "default",
+				Labels:            map[string]string{clusterv1.MachineClusterLabelName: "test-cluster"},
+				Annotations:       annotations,
+				Finalizers:        []string{clusterv1.MachineFinalizer},
+				DeletionTimestamp: &deletionTimestamp,
+			},
+			Spec: clusterv1.MachineSpec{
+				InfrastructureRef: corev1.ObjectReference{
+					APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha2",
+					Kind:       "InfrastructureConfig",
+					Name:       "worker-infra",
+				},
+				NodeDrainTimeout: timeout,
+			},
+			Status: clusterv1.MachineStatus{
+				NodeRef: &corev1.ObjectReference{Name: "node-1"},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		machine           *clusterv1.Machine
+		expectRequeue     bool
+		expectEvictions   int
+		expectNodeDeleted bool
+	}{
+		{
+			name:              "pods still running, requeue",
+			machine:           newMachine(nil, nil, time.Now()),
+			expectRequeue:     true,
+			expectEvictions:   1,
+			expectNodeDeleted: false,
+		},
+		{
+			name:              "drain excluded by annotation",
+			machine:           newMachine(map[string]string{clusterv1.ExcludeNodeDrainingAnnotation: ""}, nil, time.Now()),
+			expectRequeue:     false,
+			expectEvictions:   0,
+			expectNodeDeleted: true,
+		},
+		{
+			name:              "drain timeout exceeded",
+			machine:           newMachine(nil, &metav1.Duration{Duration: time.Minute}, time.Now().Add(-time.Hour)),
+			expectRequeue:     false,
+			expectEvictions:   0,
+			expectNodeDeleted: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			workloadClient := fakeclient.NewSimpleClientset(
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"},
+					Spec:       corev1.PodSpec{NodeName: "node-1"},
+				},
+			)
+
+			evictions := 0
+			workloadClient.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "eviction" {
+					return false, nil, nil
+				}
+				evictions++
+				return true, nil, nil
+			})
+
+			r := &MachineReconciler{
+				Client:             fake.NewFakeClient(controlPlane.DeepCopy(), tc.machine),
+				Log:                log.Log,
+				recorder:           record.NewFakeRecorder(32),
+				remoteClientGetter: fakeClusterClientGetter(workloadClient.CoreV1()),
+			}
+
+			res, err := r.reconcileDelete(context.Background(), cluster, tc.machine)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectRequeue != (res.RequeueAfter == nodeDrainRetryInterval) {
+				t.Errorf("expected requeue %v, got result %+v", tc.expectRequeue, res)
+			}
+			if evictions != tc.expectEvictions {
+				t.Errorf("expected %d evictions, got %d", tc.expectEvictions, evictions)
+			}
+
+			_, err = workloadClient.CoreV1().Nodes().Get("node-1", metav1.GetOptions{})
+			if nodeDeleted := apierrors.IsNotFound(err); nodeDeleted != tc.expectNodeDeleted {
+				t.Errorf("expected node deleted %v, got %v", tc.expectNodeDeleted, nodeDeleted)
+			}
+
+			// The finalizer is removed only once the Node is gone.
+			if hasFinalizer := util.Contains(tc.machine.Finalizers, clusterv1.MachineFinalizer); hasFinalizer == tc.expectNodeDeleted {
+				t.Errorf("unexpected finalizers %v", tc.machine.Finalizers)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileNodeMetadata syncs the labels and annotations in Machine.Spec.ObjectMeta, and the taints in
+// Machine.Spec.Taints, onto the Node of the Machine. The keys managed by the controller are tracked in
+// annotations on the Node, so that labels, annotations and taints set by kubelet or users are never
+// overwritten or removed.
+func (r *MachineReconciler) reconcileNodeMetadata(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	// Check that the Machine hasn't been deleted or in the process, and that it has a Node.
+	if !machine.DeletionTimestamp.IsZero() || machine.Status.NodeRef == nil || cluster == nil {
+		return nil
+	}
+
+	clusterClient, err := r.clusterClientGetter()(r.Client, cluster)
+	if err != nil {
+		return err
+	}
+
+	workloadClient, err := clusterClient.Client()
+	if err != nil {
+		return err
+	}
+
+	node := &corev1.Node{}
+	if err := workloadClient.Get(ctx, client.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(2).Infof("Node %q for Machine %q in namespace %q not found, skipping metadata sync",
+				machine.Status.NodeRef.Name, machine.Name, machine.Namespace)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get Node %q for Machine %q in namespace %q",
+			machine.Status.NodeRef.Name, machine.Name, machine.Namespace)
+	}
+
+	// Drop the resource version from the base, so that it ends up in the patch and the patch fails
+	// if the Node has been changed in the meantime: taints are a list and are patched as a whole.
+	base := node.DeepCopy()
+	base.ResourceVersion = ""
+	patch := client.MergeFrom(base)
+
+	original := node.DeepCopy()
+	syncNodeMetadata(node, machine)
+	if equality.Semantic.DeepEqual(original, node) {
+		return nil
+	}
+
+	klog.V(2).Infof("Updating labels, annotations and taints of Node %q for Machine %q in namespace %q",
+		node.Name, machine.Name, machine.Namespace)
+	if err := workloadClient.Patch(ctx, node, patch); err != nil {
+		return errors.Wrapf(err, "failed to patch Node %q for Machine %q in namespace %q",
+			node.Name, machine.Name, machine.Namespace)
+	}
+	return nil
+}
+
+// syncNodeMetadata applies the labels, annotations and taints of the Machine to the Node.
+func syncNodeMetadata(node *corev1.Node, machine *clusterv1.Machine) {
+	var ownedLabels, ownedAnnotations []string
+	node.Labels, ownedLabels = syncOwnedKeys(node.Labels, machine.Spec.Labels,
+		splitOwnedKeys(node.Annotations[clusterv1.LabelsFromMachineAnnotation]))
+
+	// The annotations used to track the owned keys can't be set from the Machine.
+	desiredAnnotations := map[string]string{}
+	for k, v := range machine.Spec.Annotations {
+		switch k {
+		case clusterv1.LabelsFromMachineAnnotation, clusterv1.AnnotationsFromMachineAnnotation, clusterv1.TaintsFromMachineAnnotation:
+			continue
+		}
+		desiredAnnotations[k] = v
+	}
+	node.Annotations, ownedAnnotations = syncOwnedKeys(node.Annotations, desiredAnnotations,
+		splitOwnedKeys(node.Annotations[clusterv1.AnnotationsFromMachineAnnotation]))
+
+	var ownedTaints []string
+	node.Spec.Taints, ownedTaints = syncOwnedTaints(node.Spec.Taints, machine.Spec.Taints,
+		splitOwnedKeys(node.Annotations[clusterv1.TaintsFromMachineAnnotation]))
+
+	node.Annotations = setOwnedKeys(node.Annotations, clusterv1.LabelsFromMachineAnnotation, ownedLabels)
+	node.Annotations = setOwnedKeys(node.Annotations, clusterv1.AnnotationsFromMachineAnnotation, ownedAnnotations)
+	node.Annotations = setOwnedKeys(node.Annotations, clusterv1.TaintsFromMachineAnnotation, ownedTaints)
+}
+
+// syncOwnedKeys sets the desired entries on current and removes the previously owned entries that aren't
+// desired anymore. Entries that are already set and aren't owned are left untouched.
+// It returns the updated map and the sorted list of owned keys.
+func syncOwnedKeys(current, desired map[string]string, previouslyOwned []string) (map[string]string, []string) {
+	owned := map[string]bool{}
+	for _, k := range previouslyOwned {
+		owned[k] = true
+	}
+
+	for k := range owned {
+		if _, ok := desired[k]; !ok {
+			delete(current, k)
+			delete(owned, k)
+		}
+	}
+
+	for k, v := range desired {
+		if _, ok := current[k]; ok && !owned[k] {
+			continue
+		}
+		if current == nil {
+			current = map[string]string{}
+		}
+		current[k] = v
+		owned[k] = true
+	}
+
+	return current, sortedKeys(owned)
+}
+
+// syncOwnedTaints does the same as syncOwnedKeys for taints, which are identified by their key and effect.
+func syncOwnedTaints(current, desired []corev1.Taint, previouslyOwned []string) ([]corev1.Taint, []string) {
+	owned := map[string]bool{}
+	for _, k := range previouslyOwned {
+		owned[k] = true
+	}
+
+	desiredByKey := map[string]corev1.Taint{}
+	for _, t := range desired {
+		desiredByKey[taintKey(t)] = t
+	}
+
+	result := []corev1.Taint{}
+	present := map[string]bool{}
+	for _, t := range current {
+		k := taintKey(t)
+		if owned[k] {
+			d, ok := desiredByKey[k]
+			if !ok {
+				// The taint has been removed from the Machine.
+				delete(owned, k)
+				continue
+			}
+			t.Value = d.Value
+		}
+		result = append(result, t)
+		present[k] = true
+	}
+
+	for _, t := range desired {
+		k := taintKey(t)
+		if present[k] {
+			continue
+		}
+		result = append(result, t)
+		present[k] = true
+		owned[k] = true
+	}
+
+	// Forget about owned taints that have been removed from the Node by someone else.
+	for k := range owned {
+		if !present[k] {
+			delete(owned, k)
+		}
+	}
+
+	if len(result) == 0 {
+		result = nil
+	}
+	return result, sortedKeys(owned)
+}
+
+func taintKey(t corev1.Taint) string {
+	return t.Key + ":" + string(t.Effect)
+}
+
+func splitOwnedKeys(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// setOwnedKeys records the owned keys in the given annotation, removing it if there are none.
+func setOwnedKeys(annotations map[string]string, annotation string, keys []string) map[string]string {
+	if len(keys) == 0 {
+		delete(annotations, annotation)
+		return annotations
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[annotation] = strings.Join(keys, ",")
+	return annotations
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestSyncNodeMetadata(t *testing.T) {
+	noSchedule := corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}
+	kubeletTaint := corev1.Taint{Key: "node.kubernetes.io/not-ready", Effect: corev1.TaintEffectNoSchedule}
+
+	testCases := []struct {
+		name              string
+		node              *corev1.Node
+		machineSpec       clusterv1.MachineSpec
+		expectLabels      map[string]string
+		expectAnnotations map[string]string
+		expectTaints      []corev1.Taint
+	}{
+		{
+			name: "adds the metadata of the machine",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"kubernetes.io/hostname": "node-1"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{kubeletTaint}},
+			},
+			machineSpec: clusterv1.MachineSpec{
+				ObjectMeta: clusterv1.ObjectMeta{
+					Labels:      map[string]string{"pool": "gpu"},
+					Annotations: map[string]string{"owner": "team-a"},
+				},
+				Taints: []corev1.Taint{noSchedule},
+			},
+			expectLabels: map[string]string{"kubernetes.io/hostname": "node-1", "pool": "gpu"},
+			expectAnnotations: map[string]string{
+				"owner":                               "team-a",
+				clusterv1.LabelsFromMachineAnnotation: "pool",
+				clusterv1.AnnotationsFromMachineAnnotation: "owner",
+				clusterv1.TaintsFromMachineAnnotation:      "dedicated:NoSchedule",
+			},
+			expectTaints: []corev1.Taint{kubeletTaint, noSchedule},
+		},
+		{
+			name: "removes the metadata previously set from the machine",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"kubernetes.io/hostname": "node-1", "pool": "gpu"},
+					Annotations: map[string]string{
+						"owner":                               "team-a",
+						clusterv1.LabelsFromMachineAnnotation: "pool",
+						clusterv1.AnnotationsFromMachineAnnotation: "owner",
+						clusterv1.TaintsFromMachineAnnotation:      "dedicated:NoSchedule",
+					},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{kubeletTaint, noSchedule}},
+			},
+			machineSpec:       clusterv1.MachineSpec{},
+			expectLabels:      map[string]string{"kubernetes.io/hostname": "node-1"},
+			expectAnnotations: map[string]string{},
+			expectTaints:      []corev1.Taint{kubeletTaint},
+		},
+		{
+			name: "doesn't overwrite metadata set by others",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"pool": "cpu"},
+					Annotations: map[string]string{"owner": "team-b"},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Value: "cpu", Effect: corev1.TaintEffectNoSchedule}}},
+			},
+			machineSpec: clusterv1.MachineSpec{
+				ObjectMeta: clusterv1.ObjectMeta{
+					Labels:      map[string]string{"pool": "gpu"},
+					Annotations: map[string]string{"owner": "team-a"},
+				},
+				Taints: []corev1.Taint{noSchedule},
+			},
+			expectLabels:      map[string]string{"pool": "cpu"},
+			expectAnnotations: map[string]string{"owner": "team-b"},
+			expectTaints:      []corev1.Taint{{Key: "dedicated", Value: "cpu", Effect: corev1.TaintEffectNoSchedule}},
+		},
+		{
+			name: "updates the metadata owned by the machine",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"pool": "cpu"},
+					Annotations: map[string]string{
+						clusterv1.LabelsFromMachineAnnotation: "pool",
+						clusterv1.TaintsFromMachineAnnotation: "dedicated:NoSchedule",
+					},
+				},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Value: "cpu", Effect: corev1.TaintEffectNoSchedule}}},
+			},
+			machineSpec: clusterv1.MachineSpec{
+				ObjectMeta: clusterv1.ObjectMeta{
+					Labels: map[string]string{"pool": "gpu"},
+				},
+				Taints: []corev1.Taint{noSchedule},
+			},
+			expectLabels: map[string]string{"pool": "gpu"},
+			expectAnnotations: map[string]string{
+				clusterv1.LabelsFromMachineAnnotation: "pool",
+				clusterv1.TaintsFromMachineAnnotation: "dedicated:NoSchedule",
+			},
+			expectTaints: []corev1.Taint{noSchedule},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			syncNodeMetadata(tc.node, &clusterv1.Machine{Spec: tc.machineSpec})
+
+			g.Expect(tc.node.Labels).To(gomega.Equal(tc.expectLabels))
+			if len(tc.expectAnnotations) == 0 {
+				g.Expect(tc.node.Annotations).To(gomega.BeEmpty())
+			} else {
+				g.Expect(tc.node.Annotations).To(gomega.Equal(tc.expectAnnotations))
+			}
+			g.Expect(tc.node.Spec.Taints).To(gomega.Equal(tc.expectTaints))
+		})
+	}
+}
+
+func TestReconcileNodeMetadata(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{"kubernetes.io/hostname": "node-1"},
+		},
+	}
+	workloadClient := fake.NewFakeClient(node)
+
+	r := &MachineReconciler{
+		Client: fake.NewFakeClient(),
+		Log:    log.Log,
+		remoteClientGetter: func(_ client.Client, _ *clusterv1.Cluster) (remote.ClusterClient, error) {
+			return &fakeClusterClient{client: workloadClient}, nil
+		},
+	}
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "default"},
+		Spec: clusterv1.MachineSpec{
+			ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"pool": "gpu"}},
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Kind: "Node", Name: "node-1"},
+		},
+	}
+
+	g.Expect(r.reconcileNodeMetadata(context.Background(), cluster, machine)).To(gomega.Succeed())
+
+	updated := &corev1.Node{}
+	g.Expect(workloadClient.Get(context.Background(), client.ObjectKey{Name: "node-1"}, updated)).To(gomega.Succeed())
+	g.Expect(updated.Labels).To(gomega.Equal(map[string]string{"kubernetes.io/hostname": "node-1", "pool": "gpu"}))
+	g.Expect(updated.Annotations).To(gomega.HaveKeyWithValue(clusterv1.LabelsFromMachineAnnotation, "pool"))
+
+	// A Machine without a Node is ignored.
+	machine.Status.NodeRef = nil
+	g.Expect(r.reconcileNodeMetadata(context.Background(), cluster, machine)).To(gomega.Succeed())
+}
@@ -22,19 +22,30 @@ import (
 
 	"github.com/pkg/errors"
 	apicorev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 )
 
 var (
 	ErrNodeNotFound = errors.New("cannot find node with matching ProviderID")
 )
 
+const (
+	// nodeRefRequeueAfter is how long to wait before looking for the Node of a Machine again.
+	nodeRefRequeueAfter = 10 * time.Second
+
+	// nodeRefWatchedRequeueAfter is how long to wait before looking for the Node of a Machine again when
+	// the Nodes of its Cluster are watched.
+	nodeRefWatchedRequeueAfter = time.Minute
+)
+
 func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
 	// Check that the Machine hasn't been deleted or in the process.
 	if !machine.DeletionTimestamp.IsZero() {
@@ -43,6 +54,7 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 
 	// Check that the Machine doesn't already have a NodeRef.
 	if machine.Status.NodeRef != nil {
+		conditions.MarkTrue(machine, clusterv1.NodeRefAssignedCondition)
 		return nil
 	}
 
@@ -55,74 +67,159 @@ func (r *MachineReconciler) reconcileNodeRef(ctx context.Context, cluster *clust
 	// Check that the Machine has a valid ProviderID.
 	if machine.Spec.ProviderID == nil || *machine.Spec.ProviderID == "" {
 		klog.Warningf("Machine %q in namespace %q doesn't have a valid ProviderID yet", machine.Name, machine.Namespace)
+		conditions.MarkFalse(machine, clusterv1.NodeRefAssignedCondition, clusterv1.WaitingForProviderIDReason, clusterv1.ConditionSeverityInfo,
+			"Waiting for the infrastructure provider to set the ProviderID")
 		return nil
 	}
 
 	providerID, err := noderefutil.NewProviderID(*machine.Spec.ProviderID)
 	if err != nil {
+		conditions.MarkFalse(machine, clusterv1.NodeRefAssignedCondition, clusterv1.NodeRefFailedReason, clusterv1.ConditionSeverityError, "%v", err)
 		return err
 	}
 
-	clusterClient, err := remote.NewClusterClient(r.Client, cluster)
+	clusterClient, err := r.clusterClientGetter()(r.Client, cluster)
 	if err != nil {
+		conditions.MarkFalse(machine, clusterv1.NodeRefAssignedCondition, clusterv1.NodeRefFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
 		return err
 	}
 
-	corev1Client, err := clusterClient.CoreV1()
+	workloadClient, err := clusterClient.Client()
 	if err != nil {
+		conditions.MarkFalse(machine, clusterv1.NodeRefAssignedCondition, clusterv1.NodeRefFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
 		return err
 	}
 
 	// Get the Node reference.
-	nodeRef, err := r.getNodeReference(corev1Client, providerID)
+	nodeRef, err := r.getNodeReference(ctx, workloadClient, providerID)
 	if err != nil {
 		if err == ErrNodeNotFound {
-			return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: 10 * time.Second},
+			conditions.MarkFalse(machine, clusterv1.NodeRefAssignedCondition, clusterv1.NodeNotFoundReason, clusterv1.ConditionSeverityInfo,
+				"Waiting for a Node with ProviderID %q", *machine.Spec.ProviderID)
+			// The Machine is reconciled again as soon as the Node shows up if the Nodes are watched,
+			// the requeue is a safety net in case the watch failed or the Node misses its ProviderID.
+			requeueAfter := nodeRefRequeueAfter
+			if r.shouldWatchNodes(cluster) {
+				requeueAfter = nodeRefWatchedRequeueAfter
+			}
+			return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: requeueAfter},
 				"cannot assign NodeRef to Machine %q in namespace %q, no matching Node", machine.Name, machine.Namespace)
 		}
 		klog.Errorf("Failed to assign NodeRef to Machine %q in namespace %q: %v", machine.Name, machine.Namespace, err)
 		r.recorder.Event(machine, apicorev1.EventTypeWarning, "FailedSetNodeRef", err.Error())
+		conditions.MarkFalse(machine, clusterv1.NodeRefAssignedCondition, clusterv1.NodeRefFailedReason, clusterv1.ConditionSeverityWarning, "%v", err)
 		return err
 	}
 
 	// Set the Machine NodeRef.
 	machine.Status.NodeRef = nodeRef
+	conditions.MarkTrue(machine, clusterv1.NodeRefAssignedCondition)
 	klog.Infof("Set Machine's (%q in namespace %q) NodeRef to %q", machine.Name, machine.Namespace, machine.Status.NodeRef.Name)
 	r.recorder.Event(machine, apicorev1.EventTypeNormal, "SuccessfulSetNodeRef", machine.Status.NodeRef.Name)
 	return nil
 }
 
-func (r *MachineReconciler) getNodeReference(client corev1.NodesGetter, providerID *noderefutil.ProviderID) (*apicorev1.ObjectReference, error) {
-	listOpt := metav1.ListOptions{}
+func (r *MachineReconciler) getNodeReference(ctx context.Context, c client.Reader, providerID *noderefutil.ProviderID) (*apicorev1.ObjectReference, error) {
+	nodeList := &apicorev1.NodeList{}
+	if err := c.List(ctx, nodeList); err != nil {
+		return nil, err
+	}
 
-	for {
-		nodeList, err := client.Nodes().List(listOpt)
+	for _, node := range nodeList.Items {
+		nodeProviderID, err := noderefutil.NewProviderID(node.Spec.ProviderID)
 		if err != nil {
-			return nil, err
+			klog.V(3).Infof("Failed to parse ProviderID for Node %q: %v", node.Name, err)
+			continue
 		}
 
-		for _, node := range nodeList.Items {
-			nodeProviderID, err := noderefutil.NewProviderID(node.Spec.ProviderID)
-			if err != nil {
-				klog.V(3).Infof("Failed to parse ProviderID for Node %q: %v", node.Name, err)
-				continue
-			}
+		if providerID.Equals(nodeProviderID) {
+			return &apicorev1.ObjectReference{
+				Kind:       node.Kind,
+				APIVersion: node.APIVersion,
+				Name:       node.Name,
+				UID:        node.UID,
+			}, nil
+		}
+	}
 
-			if providerID.Equals(nodeProviderID) {
-				return &apicorev1.ObjectReference{
-					Kind:       node.Kind,
-					APIVersion: node.APIVersion,
-					Name:       node.Name,
-					UID:        node.UID,
-				}, nil
-			}
+	return nil, ErrNodeNotFound
+}
+
+// shouldWatchNodes returns true if the Nodes of the given Cluster can be watched, i.e. if the workload
+// cluster clients are cached and the control plane of the cluster is up.
+func (r *MachineReconciler) shouldWatchNodes(cluster *clusterv1.Cluster) bool {
+	return r.Tracker != nil && r.controller != nil && cluster != nil && cluster.Status.ControlPlaneInitialized
+}
+
+// watchClusterNodes starts watching the Nodes of the workload cluster, so that Machines are reconciled
+// as soon as their Node is created, changes or is deleted.
+func (r *MachineReconciler) watchClusterNodes(cluster *clusterv1.Cluster) error {
+	if !r.shouldWatchNodes(cluster) {
+		return nil
+	}
+
+	return r.Tracker.Watch(r.Client, remote.WatchInput{
+		Name:         "machine-watchNodes",
+		Cluster:      cluster,
+		Watcher:      r.controller,
+		Kind:         &apicorev1.Node{},
+		EventHandler: &handler.EnqueueRequestsFromMapFunc{ToRequests: r.nodeToMachines(cluster)},
+	})
+}
+
+// nodeToMachines returns a handler.ToRequestsFunc that maps the Nodes of the given Cluster to the Machines
+// with a matching ProviderID.
+func (r *MachineReconciler) nodeToMachines(cluster *clusterv1.Cluster) handler.ToRequestsFunc {
+	clusterKey := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}
+	return func(o handler.MapObject) []ctrl.Request {
+		node, ok := o.Object.(*apicorev1.Node)
+		if !ok {
+			klog.Errorf("expected a Node but got a %T", o.Object)
+			return nil
 		}
 
-		listOpt.Continue = nodeList.Continue
-		if listOpt.Continue == "" {
-			break
+		machines, err := getMachinesForNode(context.Background(), r.Client, clusterKey, node)
+		if err != nil {
+			klog.V(4).Infof("Failed to find Machines for Node %q in Cluster %q: %v", node.Name, clusterKey, err)
+			return nil
 		}
+
+		result := []ctrl.Request{}
+		for _, m := range machines {
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: m.Namespace, Name: m.Name}})
+		}
+		return result
 	}
+}
 
-	return nil, ErrNodeNotFound
+// getMachinesForNode returns the Machines of the given Cluster whose ProviderID matches the ProviderID of the Node.
+func getMachinesForNode(ctx context.Context, c client.Client, clusterKey client.ObjectKey, node *apicorev1.Node) ([]*clusterv1.Machine, error) {
+	nodeProviderID, err := noderefutil.NewProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	machineList := &clusterv1.MachineList{}
+	labels := map[string]string{clusterv1.MachineClusterLabelName: clusterKey.Name}
+	if err := c.List(ctx, machineList, client.InNamespace(clusterKey.Namespace), client.MatchingLabels(labels)); err != nil {
+		return nil, errors.Wrap(err, "failed to list machines")
+	}
+
+	machines := []*clusterv1.Machine{}
+	for i := range machineList.Items {
+		m := &machineList.Items[i]
+		if m.Spec.ProviderID == nil {
+			continue
+		}
+
+		providerID, err := noderefutil.NewProviderID(*m.Spec.ProviderID)
+		if err != nil {
+			continue
+		}
+
+		if providerID.Equals(nodeProviderID) {
+			machines = append(machines, m)
+		}
+	}
+	return machines, nil
 }
@@ -17,18 +17,22 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
+	"reflect"
 	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	fakeclient "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -67,7 +71,7 @@ func TestGetNodeReference(t *testing.T) {
 		},
 	}
 
-	coreV1Client := fakeclient.NewSimpleClientset(nodeList...).CoreV1()
+	workloadClient := fake.NewFakeClient(nodeList...)
 
 	testCases := []struct {
 		name       string
@@ -105,7 +109,7 @@ func TestGetNodeReference(t *testing.T) {
 				t.Fatalf("Expected no error parsing provider id %q, got %v", test.providerID, err)
 			}
 
-			reference, err := r.getNodeReference(coreV1Client, providerID)
+			reference, err := r.getNodeReference(context.Background(), workloadClient, providerID)
 			if err != nil {
 				if (test.err != nil && !strings.Contains(err.Error(), test.err.Error())) || test.err == nil {
 					t.Fatalf("Expected error %v, got %v", test.err, err)
@@ -127,3 +131,64 @@ func TestGetNodeReference(t *testing.T) {
 
 	}
 }
+
+func TestNodeToMachines(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	newMachine := func(name, clusterName, providerID string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{clusterv1.MachineClusterLabelName: clusterName},
+			},
+			Spec: clusterv1.MachineSpec{ProviderID: &providerID},
+		}
+	}
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+	r := &MachineReconciler{
+		Client: fake.NewFakeClient(
+			newMachine("machine-1", "test-cluster", "aws:///id-node-1"),
+			newMachine("machine-2", "test-cluster", "aws:///id-node-2"),
+			newMachine("other-machine-1", "other-cluster", "aws:///id-node-1"),
+		),
+		Log: log.Log,
+	}
+
+	testCases := []struct {
+		name       string
+		providerID string
+		expected   []ctrl.Request
+	}{
+		{
+			name:       "node with a matching machine in the cluster",
+			providerID: "aws://us-east-1/id-node-1",
+			expected:   []ctrl.Request{{NamespacedName: client.ObjectKey{Namespace: "default", Name: "machine-1"}}},
+		},
+		{
+			name:       "node without a matching machine",
+			providerID: "aws://us-east-1/id-node-3",
+			expected:   []ctrl.Request{},
+		},
+		{
+			name:       "node without a provider id",
+			providerID: "",
+			expected:   nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node"},
+				Spec:       corev1.NodeSpec{ProviderID: tc.providerID},
+			}
+
+			got := r.nodeToMachines(cluster)(handler.MapObject{Meta: node, Object: node})
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -29,9 +29,12 @@ import (
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	capierrors "sigs.k8s.io/cluster-api/errors"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -39,6 +42,9 @@ import (
 
 var (
 	externalReadyWait = 30 * time.Second
+
+	// machineKind contains the schema.GroupVersionKind for the Machine type.
+	machineKind = clusterv1.GroupVersion.WithKind("Machine")
 )
 
 func (r *MachineReconciler) reconcilePhase(ctx context.Context, m *clusterv1.Machine) {
@@ -73,15 +79,34 @@ func (r *MachineReconciler) reconcilePhase(ctx context.Context, m *clusterv1.Mac
 	}
 }
 
+// reconcileReadyCondition summarizes the conditions owned by the Machine controller into the Ready condition.
+func (r *MachineReconciler) reconcileReadyCondition(m *clusterv1.Machine) {
+	conditions.SetSummary(m,
+		clusterv1.BootstrapReadyCondition,
+		clusterv1.InfrastructureReadyCondition,
+		clusterv1.NodeRefAssignedCondition,
+	)
+}
+
 // reconcileExternal handles generic unstructured objects referenced by a Machine.
-func (r *MachineReconciler) reconcileExternal(ctx context.Context, m *clusterv1.Machine, ref *corev1.ObjectReference) (*unstructured.Unstructured, error) {
+// Failures to retrieve or adopt the object are reported on the condition of the given type.
+func (r *MachineReconciler) reconcileExternal(ctx context.Context, m *clusterv1.Machine, ref *corev1.ObjectReference, t clusterv1.ConditionType) (_ *unstructured.Unstructured, reterr error) {
+	defer func() {
+		if reterr != nil {
+			metrics.RecordExternalObjectReconcileError(ref.GroupVersionKind())
+		}
+	}()
+
 	obj, err := external.Get(r.Client, ref, m.Namespace)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(m, t, clusterv1.ExternalObjectNotFoundReason, clusterv1.ConditionSeverityWarning,
+				"%s %q not found", ref.Kind, ref.Name)
 			return nil, errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
 				"could not find %v %q for Machine %q in namespace %q, requeuing",
 				ref.GroupVersionKind(), ref.Name, m.Name, m.Namespace)
 		}
+		conditions.MarkFalse(m, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 		return nil, err
 	}
 
@@ -95,9 +120,12 @@ func (r *MachineReconciler) reconcileExternal(ctx context.Context, m *clusterv1.
 		UID:        m.UID,
 	}
 
-	if !util.HasOwnerRef(obj.GetOwnerReferences(), machineOwnerRef) {
+	// Also set the watch label of the external object, its events are filtered out otherwise.
+	if !util.HasOwnerRef(obj.GetOwnerReferences(), machineOwnerRef) || !util.HasWatchLabel(obj, r.WatchFilterValue) {
 		obj.SetOwnerReferences(util.EnsureOwnerRef(obj.GetOwnerReferences(), machineOwnerRef))
+		util.SetWatchLabel(obj, r.WatchFilterValue)
 		if err := r.Patch(ctx, obj, objPatch); err != nil {
+			conditions.MarkFalse(m, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 			return nil, errors.Wrapf(err,
 				"failed to set OwnerReference on %v %q for Machine %q in namespace %q",
 				obj.GroupVersionKind(), ref.Name, m.Name, m.Namespace)
@@ -111,6 +139,7 @@ func (r *MachineReconciler) reconcileExternal(ctx context.Context, m *clusterv1.
 		err := r.controller.Watch(
 			&source.Kind{Type: obj},
 			&handler.EnqueueRequestForOwner{OwnerType: &clusterv1.Machine{}},
+			predicates.ResourceHasFilterLabel(r.WatchFilterValue),
 		)
 		if err != nil {
 			r.externalWatchers.Delete(obj.GroupVersionKind().String())
@@ -121,6 +150,7 @@ func (r *MachineReconciler) reconcileExternal(ctx context.Context, m *clusterv1.
 	// Set error reason and message, if any.
 	errorReason, errorMessage, err := external.ErrorsFrom(obj)
 	if err != nil {
+		conditions.MarkFalse(m, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 		return nil, err
 	}
 	if errorReason != "" {
@@ -136,27 +166,35 @@ func (r *MachineReconciler) reconcileExternal(ctx context.Context, m *clusterv1.
 
 // reconcileBootstrap reconciles the Spec.Bootstrap.ConfigRef object on a Machine.
 func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, m *clusterv1.Machine) error {
-	// TODO(vincepri): Move this validation in kubebuilder / webhook.
-	if m.Spec.Bootstrap.ConfigRef == nil && m.Spec.Bootstrap.Data == nil {
+	// This is enforced by the validation webhook, but Machines could have been created without it.
+	if m.Spec.Bootstrap.ConfigRef == nil && m.Spec.Bootstrap.Data == nil && m.Spec.Bootstrap.DataSecretName == nil {
+		conditions.MarkFalse(m, clusterv1.BootstrapReadyCondition, clusterv1.InvalidBootstrapConfigurationReason, clusterv1.ConditionSeverityError,
+			"Expected at least one of Bootstrap.ConfigRef, Bootstrap.Data or Bootstrap.DataSecretName to be populated")
 		return errors.Errorf(
-			"Expected at least one of `Bootstrap.ConfigRef` or `Bootstrap.Data` to be populated for Machine %q in namespace %q",
+			"Expected at least one of `Bootstrap.ConfigRef`, `Bootstrap.Data` or `Bootstrap.DataSecretName` to be populated for Machine %q in namespace %q",
 			m.Name, m.Namespace,
 		)
 	}
 
+	// Move the deprecated inline bootstrap data to a Secret.
+	if err := moveBootstrapDataToSecret(ctx, r.Client, m, machineKind, &m.Spec.Bootstrap); err != nil {
+		return err
+	}
+
 	// Call generic external reconciler if we have an external reference.
 	var bootstrapConfig *unstructured.Unstructured
 	if m.Spec.Bootstrap.ConfigRef != nil {
 		var err error
-		bootstrapConfig, err = r.reconcileExternal(ctx, m, m.Spec.Bootstrap.ConfigRef)
+		bootstrapConfig, err = r.reconcileExternal(ctx, m, m.Spec.Bootstrap.ConfigRef, clusterv1.BootstrapReadyCondition)
 		if err != nil {
 			return err
 		}
 	}
 
-	// If the bootstrap data is populated, set ready and return.
-	if m.Spec.Bootstrap.Data != nil {
+	// If the bootstrap data secret is populated, set ready and return.
+	if m.Spec.Bootstrap.DataSecretName != nil {
 		m.Status.BootstrapReady = true
+		conditions.MarkTrue(m, clusterv1.BootstrapReadyCondition)
 		return nil
 	}
 
@@ -168,44 +206,59 @@ func (r *MachineReconciler) reconcileBootstrap(ctx context.Context, m *clusterv1
 	// Determine if the bootstrap provider is ready.
 	ready, err := external.IsReady(bootstrapConfig)
 	if err != nil {
+		conditions.MarkFalse(m, clusterv1.BootstrapReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 		return err
 	} else if !ready {
+		if !conditions.SetMirror(m, clusterv1.BootstrapReadyCondition, conditions.UnstructuredGetter(bootstrapConfig)) {
+			conditions.MarkFalse(m, clusterv1.BootstrapReadyCondition, clusterv1.WaitingForBootstrapDataReason, clusterv1.ConditionSeverityInfo,
+				"Waiting for %s %q to generate the bootstrap data", bootstrapConfig.GetKind(), bootstrapConfig.GetName())
+		}
 		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
 			"Bootstrap provider for Machine %q in namespace %q is not ready, requeuing", m.Name, m.Namespace)
 	}
 
-	// Get and set data from the bootstrap provider.
-	data, _, err := unstructured.NestedString(bootstrapConfig.Object, "status", "bootstrapData")
+	// Get and set the name of the secret containing the bootstrap data.
+	secretName, err := bootstrapDataSecretFromConfig(ctx, r.Client, m, machineKind, bootstrapConfig)
 	if err != nil {
-		return errors.Wrapf(err, "failed to retrieve data from bootstrap provider for Machine %q in namespace %q", m.Name, m.Namespace)
-	} else if data == "" {
-		return errors.Errorf("retrieved empty data from bootstrap provider for Machine %q in namespace %q", m.Name, m.Namespace)
+		conditions.MarkFalse(m, clusterv1.BootstrapReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+		return err
 	}
 
-	m.Spec.Bootstrap.Data = pointer.StringPtr(data)
+	m.Spec.Bootstrap.DataSecretName = pointer.StringPtr(secretName)
 	m.Status.BootstrapReady = true
+	conditions.MarkTrue(m, clusterv1.BootstrapReadyCondition)
 	return nil
 }
 
 // reconcileInfrastructure reconciles the Spec.InfrastructureRef object on a Machine.
 func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, m *clusterv1.Machine) error {
 	// Call generic external reconciler.
-	infraConfig, err := r.reconcileExternal(ctx, m, &m.Spec.InfrastructureRef)
+	infraConfig, err := r.reconcileExternal(ctx, m, &m.Spec.InfrastructureRef, clusterv1.InfrastructureReadyCondition)
 	if infraConfig == nil && err == nil {
 		return nil
 	} else if err != nil {
 		return err
 	}
 
-	if m.Status.InfrastructureReady || !infraConfig.GetDeletionTimestamp().IsZero() {
+	if m.Status.InfrastructureReady {
+		conditions.MarkTrue(m, clusterv1.InfrastructureReadyCondition)
+		return nil
+	}
+
+	if !infraConfig.GetDeletionTimestamp().IsZero() {
 		return nil
 	}
 
 	// Determine if the infrastructure provider is ready.
 	ready, err := external.IsReady(infraConfig)
 	if err != nil {
+		conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 		return err
 	} else if !ready {
+		if !conditions.SetMirror(m, clusterv1.InfrastructureReadyCondition, conditions.UnstructuredGetter(infraConfig)) {
+			conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureReason, clusterv1.ConditionSeverityInfo,
+				"Waiting for %s %q to be ready", infraConfig.GetKind(), infraConfig.GetName())
+		}
 		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
 			"Infrastructure provider for Machine %q in namespace %q is not ready, requeuing", m.Name, m.Namespace,
 		)
@@ -214,8 +267,11 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, m *clus
 	// Get Spec.ProviderID from the infrastructure provider.
 	var providerID string
 	if err := util.UnstructuredUnmarshalField(infraConfig, &providerID, "spec", "providerID"); err != nil {
+		conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 		return errors.Wrapf(err, "failed to retrieve data from infrastructure provider for Machine %q in namespace %q", m.Name, m.Namespace)
 	} else if providerID == "" {
+		conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForProviderIDReason, clusterv1.ConditionSeverityWarning,
+			"%s %q doesn't have a ProviderID", infraConfig.GetKind(), infraConfig.GetName())
 		return errors.Errorf("retrieved empty Spec.ProviderID from infrastructure provider for Machine %q in namespace %q", m.Name, m.Namespace)
 	}
 
@@ -224,18 +280,22 @@ func (r *MachineReconciler) reconcileInfrastructure(ctx context.Context, m *clus
 
 	if err != nil {
 		if err != util.ErrUnstructuredFieldNotFound {
+			conditions.MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
 			return errors.Wrapf(err, "failed to retrieve addresses from infrastructure provider for Machine %q in namespace %q", m.Name, m.Namespace)
 		}
 	}
 
 	m.Spec.ProviderID = pointer.StringPtr(providerID)
 	m.Status.InfrastructureReady = true
+	conditions.MarkTrue(m, clusterv1.InfrastructureReadyCondition)
 	return nil
 }
 
 // reconcileClusterStatus reconciles the status on the Cluster associated with Machines.
 func (r *MachineReconciler) reconcileClusterStatus(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
-	if cluster == nil {
+	// The control plane provider referenced by the Cluster is responsible for reporting
+	// Status.ControlPlaneInitialized, if any.
+	if cluster == nil || cluster.Spec.ControlPlaneRef != nil {
 		return nil
 	}
 
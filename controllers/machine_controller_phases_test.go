@@ -27,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -432,8 +433,31 @@ func TestReconcileBootstrap(t *testing.T) {
 			expectError: false,
 			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
 				g.Expect(m.Status.BootstrapReady).To(gomega.BeTrue())
-				g.Expect(m.Spec.Bootstrap.Data).ToNot(gomega.BeNil())
-				g.Expect(*m.Spec.Bootstrap.Data).To(gomega.ContainSubstring("#!/bin/bash"))
+				g.Expect(m.Spec.Bootstrap.Data).To(gomega.BeNil())
+				g.Expect(m.Spec.Bootstrap.DataSecretName).To(gomega.Equal(pointer.StringPtr("machine-test-machine-bootstrap")))
+				g.Expect(conditions.IsTrue(m, clusterv1.BootstrapReadyCondition)).To(gomega.BeTrue())
+			},
+		},
+		{
+			name: "new machine, bootstrap config ready with data secret",
+			bootstrapConfig: map[string]interface{}{
+				"kind":       "BootstrapConfig",
+				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "bootstrap-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"ready":          true,
+					"dataSecretName": "secret-data",
+				},
+			},
+			expectError: false,
+			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
+				g.Expect(m.Status.BootstrapReady).To(gomega.BeTrue())
+				g.Expect(m.Spec.Bootstrap.DataSecretName).To(gomega.Equal(pointer.StringPtr("secret-data")))
+				g.Expect(conditions.IsTrue(m, clusterv1.BootstrapReadyCondition)).To(gomega.BeTrue())
 			},
 		},
 		{
@@ -454,6 +478,7 @@ func TestReconcileBootstrap(t *testing.T) {
 			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
 				g.Expect(m.Status.BootstrapReady).To(gomega.BeFalse())
 				g.Expect(m.Spec.Bootstrap.Data).To(gomega.BeNil())
+				g.Expect(m.Spec.Bootstrap.DataSecretName).To(gomega.BeNil())
 			},
 		},
 		{
@@ -471,6 +496,8 @@ func TestReconcileBootstrap(t *testing.T) {
 			expectError: true,
 			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
 				g.Expect(m.Status.BootstrapReady).To(gomega.BeFalse())
+				g.Expect(conditions.IsFalse(m, clusterv1.BootstrapReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.BootstrapReadyCondition)).To(gomega.Equal(clusterv1.WaitingForBootstrapDataReason))
 			},
 		},
 		{
@@ -488,6 +515,7 @@ func TestReconcileBootstrap(t *testing.T) {
 			expectError: true,
 			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
 				g.Expect(m.Status.BootstrapReady).To(gomega.BeFalse())
+				g.Expect(conditions.GetReason(m, clusterv1.BootstrapReadyCondition)).To(gomega.Equal(clusterv1.ExternalObjectNotFoundReason))
 			},
 		},
 		{
@@ -505,7 +533,64 @@ func TestReconcileBootstrap(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name: "existing machine, bootstrap data should not change",
+			name: "new machine, bootstrap config not ready, mirrors its Ready condition",
+			bootstrapConfig: map[string]interface{}{
+				"kind":       "BootstrapConfig",
+				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "bootstrap-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{
+							"type":     "Ready",
+							"status":   "False",
+							"severity": "Warning",
+							"reason":   "CertificatesNotGenerated",
+							"message":  "waiting for certificates",
+						},
+					},
+				},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
+				g.Expect(m.Status.BootstrapReady).To(gomega.BeFalse())
+				g.Expect(conditions.IsFalse(m, clusterv1.BootstrapReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.BootstrapReadyCondition)).To(gomega.Equal("CertificatesNotGenerated"))
+				g.Expect(conditions.GetMessage(m, clusterv1.BootstrapReadyCondition)).To(gomega.Equal("waiting for certificates"))
+				g.Expect(conditions.Get(m, clusterv1.BootstrapReadyCondition).Severity).To(gomega.Equal(clusterv1.ConditionSeverityWarning))
+			},
+		},
+		{
+			name: "new machine, neither bootstrap config reference nor data",
+			bootstrapConfig: map[string]interface{}{
+				"kind":       "BootstrapConfig",
+				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "bootstrap-config1",
+					"namespace": "default",
+				},
+				"spec":   map[string]interface{}{},
+				"status": map[string]interface{}{},
+			},
+			machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "bootstrap-test-empty",
+					Namespace: "default",
+				},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
+				g.Expect(m.Status.BootstrapReady).To(gomega.BeFalse())
+				g.Expect(conditions.IsFalse(m, clusterv1.BootstrapReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.BootstrapReadyCondition)).To(gomega.Equal(clusterv1.InvalidBootstrapConfigurationReason))
+				g.Expect(conditions.Get(m, clusterv1.BootstrapReadyCondition).Severity).To(gomega.Equal(clusterv1.ConditionSeverityError))
+			},
+		},
+		{
+			name: "existing machine, inline bootstrap data is moved to a secret",
 			bootstrapConfig: map[string]interface{}{
 				"kind":       "BootstrapConfig",
 				"apiVersion": "bootstrap.cluster.x-k8s.io/v1alpha2",
@@ -541,7 +626,8 @@ func TestReconcileBootstrap(t *testing.T) {
 			expectError: false,
 			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
 				g.Expect(m.Status.BootstrapReady).To(gomega.BeTrue())
-				g.Expect(*m.Spec.Bootstrap.Data).To(gomega.Equal("#!/bin/bash ... data"))
+				g.Expect(m.Spec.Bootstrap.Data).To(gomega.BeNil())
+				g.Expect(m.Spec.Bootstrap.DataSecretName).To(gomega.Equal(pointer.StringPtr("bootstrap-test-existing-machine-bootstrap")))
 			},
 		},
 		{
@@ -671,6 +757,124 @@ func TestReconcileInfrastructure(t *testing.T) {
 			expectChanged: true,
 			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
 				g.Expect(m.Status.InfrastructureReady).To(gomega.BeTrue())
+				g.Expect(conditions.IsTrue(m, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+			},
+		},
+		{
+			name: "new machine, infrastructure config not ready",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec":   map[string]interface{}{},
+				"status": map[string]interface{}{},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
+				g.Expect(m.Status.InfrastructureReady).To(gomega.BeFalse())
+				g.Expect(conditions.IsFalse(m, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal(clusterv1.WaitingForInfrastructureReason))
+			},
+		},
+		{
+			name: "new machine, infrastructure config not ready, mirrors its Ready condition",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{
+							"type":     "Ready",
+							"status":   "False",
+							"severity": "Info",
+							"reason":   "InstanceProvisioning",
+							"message":  "instance is starting",
+						},
+					},
+				},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
+				g.Expect(conditions.IsFalse(m, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal("InstanceProvisioning"))
+				g.Expect(conditions.GetMessage(m, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal("instance is starting"))
+
+				// The mirrored condition bubbles up to the Machine's Ready condition.
+				r := &MachineReconciler{}
+				r.reconcileReadyCondition(m)
+				g.Expect(conditions.IsFalse(m, clusterv1.ReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.ReadyCondition)).To(gomega.Equal("InstanceProvisioning"))
+			},
+		},
+		{
+			name: "new machine, infrastructure config is not found",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "wrong-namespace",
+				},
+				"spec":   map[string]interface{}{},
+				"status": map[string]interface{}{},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
+				g.Expect(conditions.IsFalse(m, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal(clusterv1.ExternalObjectNotFoundReason))
+				g.Expect(conditions.Get(m, clusterv1.InfrastructureReadyCondition).Severity).To(gomega.Equal(clusterv1.ConditionSeverityWarning))
+			},
+		},
+		{
+			name: "new machine, infrastructure config has an invalid ready field",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"ready": "true",
+				},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
+				g.Expect(conditions.IsFalse(m, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal(clusterv1.ExternalObjectErrorReason))
+				g.Expect(conditions.Get(m, clusterv1.InfrastructureReadyCondition).Severity).To(gomega.Equal(clusterv1.ConditionSeverityError))
+			},
+		},
+		{
+			name: "new machine, infrastructure config ready without a provider ID",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{
+					"providerID": "",
+				},
+				"status": map[string]interface{}{
+					"ready": true,
+				},
+			},
+			expectError: true,
+			expected: func(g *gomega.WithT, m *clusterv1.Machine) {
+				g.Expect(m.Status.InfrastructureReady).To(gomega.BeFalse())
+				g.Expect(conditions.IsFalse(m, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(conditions.GetReason(m, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal(clusterv1.WaitingForProviderIDReason))
 			},
 		},
 	}
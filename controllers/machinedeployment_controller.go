@@ -30,7 +30,9 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -49,11 +51,14 @@ type MachineDeploymentReconciler struct {
 	client.Client
 	Log logr.Logger
 
+	// WatchFilterValue is the value of the watch label of the objects to reconcile. Every object is reconciled if empty.
+	WatchFilterValue string
+
 	recorder record.EventRecorder
 }
 
 func (r *MachineDeploymentReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
-	err := ctrl.NewControllerManagedBy(mgr).
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.MachineDeployment{}).
 		Owns(&clusterv1.MachineSet{}).
 		Watches(
@@ -61,7 +66,19 @@ func (r *MachineDeploymentReconciler) SetupWithManager(mgr ctrl.Manager, options
 			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.MachineSetToDeployments)},
 		).
 		WithOptions(options).
-		Complete(r)
+		WithEventFilter(predicates.ResourceHasFilterLabel(r.WatchFilterValue)).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	// Reconcile the MachineDeployments of a Cluster as soon as it's unpaused.
+	err = c.Watch(
+		&source.Kind{Type: &clusterv1.Cluster{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: util.ClusterToObjectsMapFunc(r.Client, &clusterv1.MachineDeploymentList{})},
+		predicates.ClusterUnpaused(),
+		predicates.ResourceHasFilterLabel(r.WatchFilterValue),
+	)
 
 	r.recorder = mgr.GetEventRecorderFor("machinedeployment-controller")
 	return err
@@ -77,19 +94,27 @@ func (r *MachineDeploymentReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		if apierrors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			metrics.DeleteMachineDeploymentReplicas(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return ctrl.Result{}, err
 	}
 
+	// Ignore the MachineDeployments without the watch label of this manager, they can still be enqueued by other watches.
+	if !util.HasWatchLabel(d, r.WatchFilterValue) {
+		return ctrl.Result{}, nil
+	}
+
 	// Ignore deleted MachineDeployments, this can happen when foregroundDeletion
 	// is enabled
 	if d.DeletionTimestamp != nil {
+		metrics.DeleteMachineDeploymentReplicas(d.Namespace, d.Name)
 		return ctrl.Result{}, nil
 	}
 
 	result, err := r.reconcile(ctx, d)
+	metrics.SetMachineDeploymentReplicas(d)
 	if err != nil {
 		klog.Errorf("Failed to reconcile MachineDeployment %q: %v", req.NamespacedName, err)
 		r.recorder.Eventf(d, corev1.EventTypeWarning, "ReconcileError", "%v", err)
@@ -115,7 +140,7 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, d *clusterv
 	}
 
 	// Make sure that label selector can match the template's labels.
-	// TODO(vincepri): Move to a validation (admission) webhook when supported.
+	// This is enforced by the validation webhook, but MachineDeployments could have been created without it.
 	selector, err := metav1.LabelSelectorAsSelector(&d.Spec.Selector)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrapf(err, "failed to parse MachineDeployment %q label selector", d.Name)
@@ -138,6 +163,12 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, d *clusterv
 		return ctrl.Result{}, err
 	}
 
+	// Return early if the MachineDeployment or its Cluster is paused.
+	if util.IsPaused(cluster, d) {
+		klog.Infof("Reconciliation is paused for MachineDeployment %q in namespace %q", d.Name, d.Namespace)
+		return ctrl.Result{}, nil
+	}
+
 	if cluster != nil && r.shouldAdopt(d) {
 		d.OwnerReferences = util.EnsureOwnerRef(d.OwnerReferences, metav1.OwnerReference{
 			APIVersion: cluster.APIVersion,
@@ -157,16 +188,32 @@ func (r *MachineDeploymentReconciler) reconcile(ctx context.Context, d *clusterv
 		return ctrl.Result{}, err
 	}
 
+	if err := r.checkPausedConditions(d); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if d.Spec.Paused {
 		return ctrl.Result{}, r.sync(d, msList, machineMap)
 	}
 
+	if d.Spec.RollbackTo != nil {
+		return ctrl.Result{}, r.rollback(d, msList, machineMap)
+	}
+
 	switch d.Spec.Strategy.Type {
 	case clusterv1.RollingUpdateMachineDeploymentStrategyType:
-		return ctrl.Result{}, r.rolloutRolling(d, msList, machineMap)
+		err = r.rolloutRolling(d, msList, machineMap)
+	case clusterv1.RecreateMachineDeploymentStrategyType:
+		err = r.rolloutRecreate(d, msList, machineMap)
+	default:
+		return ctrl.Result{}, errors.Errorf("unexpected deployment strategy type: %s", d.Spec.Strategy.Type)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, errors.Errorf("unexpected deployment strategy type: %s", d.Spec.Strategy.Type)
+	// Check the progress deadline again once it's due, in case the rollout is stuck.
+	return ctrl.Result{RequeueAfter: requeueStuckDeployment(d)}, nil
 }
 
 // getMachineSetsForDeployment returns a list of MachineSets associated with a MachineDeployment.
@@ -29,6 +29,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -513,3 +514,60 @@ func TestGetMachineSetsForDeployment(t *testing.T) {
 		}
 	}
 }
+
+func TestCalculateStatusConditions(t *testing.T) {
+	newMachineSet := func(replicas, readyReplicas int32) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			Spec: clusterv1.MachineSetSpec{Replicas: pointer.Int32Ptr(replicas)},
+			Status: clusterv1.MachineSetStatus{
+				Replicas:      replicas,
+				ReadyReplicas: readyReplicas,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		replicas       int32
+		machineSets    []*clusterv1.MachineSet
+		expectedStatus corev1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "all replicas ready",
+			replicas:       3,
+			machineSets:    []*clusterv1.MachineSet{newMachineSet(1, 1), newMachineSet(2, 2)},
+			expectedStatus: corev1.ConditionTrue,
+		},
+		{
+			name:           "some replicas not ready",
+			replicas:       3,
+			machineSets:    []*clusterv1.MachineSet{newMachineSet(1, 1), newMachineSet(2, 0)},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: clusterv1.WaitingForReadyReplicasReason,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			deployment := &clusterv1.MachineDeployment{
+				Spec: clusterv1.MachineDeploymentSpec{Replicas: pointer.Int32Ptr(tc.replicas)},
+			}
+
+			status := calculateStatus(tc.machineSets, tc.machineSets[len(tc.machineSets)-1], deployment)
+			holder := &clusterv1.MachineDeployment{Status: status}
+
+			for _, conditionType := range []clusterv1.ConditionType{clusterv1.MachinesReadyCondition, clusterv1.ReadyCondition} {
+				condition := conditions.Get(holder, conditionType)
+				g.Expect(condition).ToNot(BeNil())
+				g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+				g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+			}
+
+			// The MachineDeployment passed in is left untouched.
+			g.Expect(deployment.Status.Conditions).To(BeEmpty())
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncRolloutStatus updates the status of a deployment during a rollout. There are
+// cases this helper will run that cannot be prevented from the scaling detection,
+// for example a resync of the deployment after it was scaled up. In those cases,
+// we shouldn't try to estimate any progress.
+func (r *MachineDeploymentReconciler) syncRolloutStatus(allMSs []*clusterv1.MachineSet, newMS *clusterv1.MachineSet, d *clusterv1.MachineDeployment) error {
+	newStatus := calculateStatus(allMSs, newMS, d)
+	statusHolder := &clusterv1.MachineDeployment{Status: newStatus}
+
+	// If there is no progressDeadlineSeconds set, remove any Progressing condition.
+	if !mdutil.HasProgressDeadline(d) {
+		conditions.Delete(statusHolder, clusterv1.ProgressingCondition)
+	}
+
+	// If all the replicas have been updated and the new machine set has already been reported as
+	// available, we are not running a new rollout and this is a resync where we don't need to
+	// estimate any progress.
+	currentCond := conditions.Get(d, clusterv1.ProgressingCondition)
+	isCompleteDeployment := newStatus.Replicas == newStatus.UpdatedReplicas && currentCond != nil && currentCond.Reason == mdutil.NewMSAvailableReason
+
+	// Check for progress only if there is a progress deadline set and the latest rollout
+	// hasn't completed yet.
+	if mdutil.HasProgressDeadline(d) && !isCompleteDeployment {
+		switch {
+		case mdutil.DeploymentComplete(d, &newStatus):
+			// Update the deployment conditions with a message for the new machine set that
+			// was successfully deployed. If the condition already exists, we ignore this update.
+			msg := fmt.Sprintf("MachineDeployment %q has successfully progressed.", d.Name)
+			if newMS != nil {
+				msg = fmt.Sprintf("MachineSet %q has successfully progressed.", newMS.Name)
+			}
+			setProgressingCondition(statusHolder, corev1.ConditionTrue, mdutil.NewMSAvailableReason, msg, false)
+		case mdutil.DeploymentProgressing(d, &newStatus):
+			// If there is any progress made, continue by not checking if the deployment failed. This
+			// behavior emulates the rolling updater progressDeadline check.
+			msg := fmt.Sprintf("MachineDeployment %q is progressing.", d.Name)
+			if newMS != nil {
+				msg = fmt.Sprintf("MachineSet %q is progressing.", newMS.Name)
+			}
+			setProgressingCondition(statusHolder, corev1.ConditionTrue, mdutil.MachineSetUpdatedReason, msg, true)
+		case mdutil.DeploymentTimedOut(d, &newStatus):
+			// Update the deployment with a timeout condition. If the condition already exists,
+			// we ignore this update.
+			msg := fmt.Sprintf("MachineDeployment %q has timed out progressing.", d.Name)
+			if newMS != nil {
+				msg = fmt.Sprintf("MachineSet %q has timed out progressing.", newMS.Name)
+			}
+			setProgressingCondition(statusHolder, corev1.ConditionFalse, mdutil.TimedOutReason, msg, false)
+		}
+	}
+
+	newStatus = statusHolder.Status
+	r.recordProgressingEvents(d, currentCond, conditions.Get(statusHolder, clusterv1.ProgressingCondition))
+
+	// Do not update if there is nothing new to add.
+	if reflect.DeepEqual(d.Status, newStatus) {
+		return nil
+	}
+
+	patch := client.MergeFrom(d.DeepCopy())
+	d.Status = newStatus
+	return r.Status().Patch(context.Background(), d, patch)
+}
+
+// recordProgressingEvents emits an event when the rollout of the deployment completes or fails to make progress.
+func (r *MachineDeploymentReconciler) recordProgressingEvents(d *clusterv1.MachineDeployment, oldCond, newCond *clusterv1.Condition) {
+	if newCond == nil || (oldCond != nil && oldCond.Reason == newCond.Reason) {
+		return
+	}
+
+	switch newCond.Reason {
+	case mdutil.NewMSAvailableReason:
+		r.recorder.Event(d, corev1.EventTypeNormal, mdutil.NewMSAvailableReason, newCond.Message)
+	case mdutil.TimedOutReason:
+		r.recorder.Event(d, corev1.EventTypeWarning, mdutil.TimedOutReason, newCond.Message)
+	}
+}
+
+// checkPausedConditions checks if the given deployment is paused or not and adds an appropriate condition.
+// These conditions are needed so that we won't accidentally report lack of progress for resumed deployments
+// that were paused for longer than progressDeadlineSeconds.
+func (r *MachineDeploymentReconciler) checkPausedConditions(d *clusterv1.MachineDeployment) error {
+	if !mdutil.HasProgressDeadline(d) {
+		return nil
+	}
+
+	cond := conditions.Get(d, clusterv1.ProgressingCondition)
+	if cond != nil && cond.Reason == mdutil.TimedOutReason {
+		// If we have reported lack of progress, do not overwrite it with a paused condition.
+		return nil
+	}
+	pausedCondExists := cond != nil && cond.Reason == mdutil.PausedDeployReason
+
+	patch := client.MergeFrom(d.DeepCopy())
+	switch {
+	case d.Spec.Paused && !pausedCondExists:
+		setProgressingCondition(d, corev1.ConditionUnknown, mdutil.PausedDeployReason, "MachineDeployment is paused", false)
+	case !d.Spec.Paused && pausedCondExists:
+		// Resuming restarts the progress deadline.
+		setProgressingCondition(d, corev1.ConditionUnknown, mdutil.ResumedDeployReason, "MachineDeployment is resumed", true)
+	default:
+		return nil
+	}
+
+	return r.Status().Patch(context.Background(), d, patch)
+}
+
+// setProgressingCondition sets the Progressing condition on the given deployment. If restartDeadline is true,
+// the existing condition is dropped first so that its LastTransitionTime, which the progress deadline is
+// measured from, is reset even if the status of the condition doesn't change.
+func setProgressingCondition(d *clusterv1.MachineDeployment, status corev1.ConditionStatus, reason, message string, restartDeadline bool) {
+	if restartDeadline {
+		conditions.Delete(d, clusterv1.ProgressingCondition)
+	}
+
+	condition := &clusterv1.Condition{
+		Type:    clusterv1.ProgressingCondition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	if status == corev1.ConditionFalse {
+		condition.Severity = clusterv1.ConditionSeverityError
+	}
+	conditions.Set(d, condition)
+}
+
+// requeueStuckDeployment returns the time after which the deployment should be reconciled again to check
+// whether its rollout has exceeded the progress deadline, or zero if there is no need to requeue.
+func requeueStuckDeployment(d *clusterv1.MachineDeployment) time.Duration {
+	currentCond := conditions.Get(d, clusterv1.ProgressingCondition)
+	// Can't estimate progress if there is no deadline in the spec or progressing condition in the current status.
+	if !mdutil.HasProgressDeadline(d) || currentCond == nil {
+		return 0
+	}
+	// No need to estimate progress if the rollout is complete, paused or already timed out.
+	if mdutil.DeploymentComplete(d, &d.Status) || d.Spec.Paused || currentCond.Reason == mdutil.TimedOutReason {
+		return 0
+	}
+
+	// If there is no sign of progress at this point then there is a high chance that the
+	// deployment is stuck. We should reconcile this deployment at some point in the future
+	// and check whether it has timed out, instead of depending on the resync interval.
+	after := time.Until(currentCond.LastTransitionTime.Add(time.Duration(*d.Spec.ProgressDeadlineSeconds) * time.Second))
+
+	// If the remaining time is less than a second, then requeue the deployment right after.
+	if after < time.Second {
+		after = time.Second
+	}
+
+	klog.V(4).Infof("Requeuing MachineDeployment %q in %v to check its progress deadline", d.Name, after)
+	return after
+}
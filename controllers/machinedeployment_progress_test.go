@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMachineDeploymentSyncRolloutStatus(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	newDeployment := func(progressingReason string, lastProgress time.Duration) *clusterv1.MachineDeployment {
+		d := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "md-progress", Namespace: "default"},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Replicas: pointer.Int32Ptr(1),
+			},
+			Status: clusterv1.MachineDeploymentStatus{
+				Replicas:        1,
+				UpdatedReplicas: 1,
+				Conditions: clusterv1.Conditions{
+					{
+						Type:               clusterv1.ProgressingCondition,
+						Status:             corev1.ConditionTrue,
+						Reason:             progressingReason,
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-lastProgress)),
+					},
+				},
+			},
+		}
+		clusterv1.PopulateDefaultsMachineDeployment(d)
+		return d
+	}
+
+	newMachineSet := func(available int32) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "md-progress-ms", Namespace: "default"},
+			Spec:       clusterv1.MachineSetSpec{Replicas: pointer.Int32Ptr(1)},
+			Status: clusterv1.MachineSetStatus{
+				Replicas:          1,
+				ReadyReplicas:     available,
+				AvailableReplicas: available,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		d                 *clusterv1.MachineDeployment
+		ms                *clusterv1.MachineSet
+		expectStatus      corev1.ConditionStatus
+		expectReason      string
+		expectAvailable   bool
+		expectEvent       string
+		expectRequeueZero bool
+	}{
+		{
+			name:              "rollout completes",
+			d:                 newDeployment(mdutil.MachineSetUpdatedReason, time.Minute),
+			ms:                newMachineSet(1),
+			expectStatus:      corev1.ConditionTrue,
+			expectReason:      mdutil.NewMSAvailableReason,
+			expectAvailable:   true,
+			expectEvent:       mdutil.NewMSAvailableReason,
+			expectRequeueZero: true,
+		},
+		{
+			name:         "rollout is still within the deadline",
+			d:            newDeployment(mdutil.NewMachineSetReason, time.Minute),
+			ms:           newMachineSet(0),
+			expectStatus: corev1.ConditionTrue,
+			expectReason: mdutil.NewMachineSetReason,
+		},
+		{
+			name:              "rollout exceeds the deadline",
+			d:                 newDeployment(mdutil.MachineSetUpdatedReason, time.Hour),
+			ms:                newMachineSet(0),
+			expectStatus:      corev1.ConditionFalse,
+			expectReason:      mdutil.TimedOutReason,
+			expectEvent:       mdutil.TimedOutReason,
+			expectRequeueZero: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			recorder := record.NewFakeRecorder(32)
+			r := &MachineDeploymentReconciler{
+				Client:   fake.NewFakeClient(tc.d),
+				Log:      log.Log,
+				recorder: recorder,
+			}
+
+			err := r.syncRolloutStatus([]*clusterv1.MachineSet{tc.ms}, tc.ms, tc.d)
+			g.Expect(err).ToNot(gomega.HaveOccurred())
+
+			progressing := conditions.Get(tc.d, clusterv1.ProgressingCondition)
+			g.Expect(progressing).ToNot(gomega.BeNil())
+			g.Expect(progressing.Status).To(gomega.Equal(tc.expectStatus))
+			g.Expect(progressing.Reason).To(gomega.Equal(tc.expectReason))
+			g.Expect(conditions.IsTrue(tc.d, clusterv1.AvailableCondition)).To(gomega.Equal(tc.expectAvailable))
+
+			if tc.expectEvent != "" {
+				g.Expect(recorder.Events).To(gomega.Receive(gomega.ContainSubstring(tc.expectEvent)))
+			} else {
+				g.Expect(recorder.Events).ToNot(gomega.Receive())
+			}
+
+			if tc.expectRequeueZero {
+				g.Expect(requeueStuckDeployment(tc.d)).To(gomega.BeZero())
+			} else {
+				g.Expect(requeueStuckDeployment(tc.d)).To(gomega.BeNumerically(">", 0))
+			}
+		})
+	}
+}
+
+func TestMachineDeploymentCheckPausedConditions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	d := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "md-paused", Namespace: "default"},
+		Spec:       clusterv1.MachineDeploymentSpec{Paused: true},
+	}
+	clusterv1.PopulateDefaultsMachineDeployment(d)
+
+	r := &MachineDeploymentReconciler{
+		Client:   fake.NewFakeClient(d),
+		Log:      log.Log,
+		recorder: record.NewFakeRecorder(32),
+	}
+
+	g.Expect(r.checkPausedConditions(d)).To(gomega.Succeed())
+	g.Expect(conditions.GetReason(d, clusterv1.ProgressingCondition)).To(gomega.Equal(mdutil.PausedDeployReason))
+
+	d.Spec.Paused = false
+	g.Expect(r.checkPausedConditions(d)).To(gomega.Succeed())
+	g.Expect(conditions.GetReason(d, clusterv1.ProgressingCondition)).To(gomega.Equal(mdutil.ResumedDeployReason))
+}
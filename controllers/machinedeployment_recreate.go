@@ -0,0 +1,135 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+)
+
+// rolloutRecreate implements the logic for recreating a machine set: the old machine sets are scaled
+// down to zero, and the new machine set is only created and scaled up once all the old machines are gone.
+func (r *MachineDeploymentReconciler) rolloutRecreate(d *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet, machineMap map[types.UID]*clusterv1.MachineList) error {
+	// Don't create a new MachineSet if it doesn't exist yet, so that we avoid scaling up before scaling down.
+	newMS, oldMSs, err := r.getAllMachineSetsAndSyncRevision(d, msList, machineMap, false)
+	if err != nil {
+		return err
+	}
+
+	allMSs := append(oldMSs, newMS)
+	activeOldMSs := mdutil.FilterActiveMachineSets(oldMSs)
+
+	// Scale down old machine sets.
+	scaledDown, err := r.scaleDownOldMachineSetsForRecreate(activeOldMSs, d)
+	if err != nil {
+		return err
+	}
+
+	if scaledDown {
+		// Update the status and wait for the old machines to be deleted.
+		return r.syncRolloutStatus(allMSs, newMS, d)
+	}
+
+	// Do not scale up the new machine set while old machines still exist.
+	if oldMachinesRunning(oldMSs, machineMap) {
+		klog.V(4).Infof("Waiting for the Machines of old MachineSets of MachineDeployment %q to be deleted", d.Name)
+		return r.syncRolloutStatus(allMSs, newMS, d)
+	}
+
+	// If we need to create a new machine set, create it now.
+	if newMS == nil {
+		newMS, oldMSs, err = r.getAllMachineSetsAndSyncRevision(d, msList, machineMap, true)
+		if err != nil {
+			return err
+		}
+
+		// newMS can be nil in case there are only changes in annotations or MinReadySeconds,
+		// see rolloutRolling.
+		if newMS == nil {
+			return nil
+		}
+		allMSs = append(oldMSs, newMS)
+	}
+
+	// Scale up the new machine set.
+	if err := r.scaleUpNewMachineSetForRecreate(newMS, d); err != nil {
+		return err
+	}
+
+	if err := r.syncRolloutStatus(allMSs, newMS, d); err != nil {
+		return err
+	}
+
+	if mdutil.DeploymentComplete(d, &d.Status) {
+		if err := r.cleanupDeployment(oldMSs, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scaleDownOldMachineSetsForRecreate scales down old machine sets when deployment strategy is "Recreate".
+// It returns true if any of the machine sets has been scaled down.
+func (r *MachineDeploymentReconciler) scaleDownOldMachineSetsForRecreate(oldMSs []*clusterv1.MachineSet, deployment *clusterv1.MachineDeployment) (bool, error) {
+	scaled := false
+	for _, ms := range oldMSs {
+		if ms.Spec.Replicas == nil {
+			return false, errors.Errorf("spec replicas for machine set %v is nil, this is unexpected", ms.Name)
+		}
+
+		// Scaling not required.
+		if *(ms.Spec.Replicas) == 0 {
+			continue
+		}
+
+		scaledMS, err := r.scaleMachineSet(ms, 0, deployment)
+		if err != nil {
+			return false, err
+		}
+		if scaledMS {
+			scaled = true
+		}
+	}
+	return scaled, nil
+}
+
+// scaleUpNewMachineSetForRecreate scales up the new machine set when deployment strategy is "Recreate".
+func (r *MachineDeploymentReconciler) scaleUpNewMachineSetForRecreate(newMS *clusterv1.MachineSet, deployment *clusterv1.MachineDeployment) error {
+	if deployment.Spec.Replicas == nil {
+		return errors.Errorf("spec replicas for deployment %v is nil, this is unexpected", deployment.Name)
+	}
+
+	_, err := r.scaleMachineSet(newMS, *(deployment.Spec.Replicas), deployment)
+	return err
+}
+
+// oldMachinesRunning returns whether there are old machines still around, including the ones being deleted.
+func oldMachinesRunning(oldMSs []*clusterv1.MachineSet, machineMap map[types.UID]*clusterv1.MachineList) bool {
+	for _, ms := range oldMSs {
+		if ms.Status.Replicas > 0 {
+			return true
+		}
+		if machineList, ok := machineMap[ms.UID]; ok && len(machineList.Items) > 0 {
+			return true
+		}
+	}
+	return false
+}
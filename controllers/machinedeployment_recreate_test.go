@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMachineDeploymentRolloutRecreate(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	newDeployment := func() *clusterv1.MachineDeployment {
+		d := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "md-recreate", Namespace: "default", UID: "md-uid"},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Replicas: pointer.Int32Ptr(3),
+				Selector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "licensed"}},
+				Strategy: &clusterv1.MachineDeploymentStrategy{Type: clusterv1.RecreateMachineDeploymentStrategyType},
+				Template: clusterv1.MachineTemplateSpec{
+					ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"pool": "licensed"}},
+					Spec:       clusterv1.MachineSpec{Version: pointer.StringPtr("v1.16.2")},
+				},
+			},
+		}
+		clusterv1.PopulateDefaultsMachineDeployment(d)
+		return d
+	}
+
+	newOldMachineSet := func(replicas, statusReplicas int32) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "md-recreate-old",
+				Namespace:       "default",
+				UID:             "old-ms-uid",
+				Labels:          map[string]string{"pool": "licensed"},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(newDeployment(), controllerKind)},
+			},
+			Spec: clusterv1.MachineSetSpec{
+				Replicas: pointer.Int32Ptr(replicas),
+				Template: clusterv1.MachineTemplateSpec{
+					ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"pool": "licensed"}},
+					Spec:       clusterv1.MachineSpec{Version: pointer.StringPtr("v1.15.3")},
+				},
+			},
+			Status: clusterv1.MachineSetStatus{Replicas: statusReplicas},
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		oldMS            *clusterv1.MachineSet
+		oldMachines      int
+		expectOldScale   int32
+		expectNewMSScale *int32
+	}{
+		{
+			name:           "old machine set is scaled down first",
+			oldMS:          newOldMachineSet(3, 3),
+			oldMachines:    3,
+			expectOldScale: 0,
+		},
+		{
+			name:           "new machine set isn't created while old machines are being deleted",
+			oldMS:          newOldMachineSet(0, 1),
+			oldMachines:    1,
+			expectOldScale: 0,
+		},
+		{
+			name:             "new machine set is scaled up once old machines are gone",
+			oldMS:            newOldMachineSet(0, 0),
+			expectOldScale:   0,
+			expectNewMSScale: pointer.Int32Ptr(3),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			d := newDeployment()
+			machineMap := map[types.UID]*clusterv1.MachineList{tc.oldMS.UID: {}}
+			for i := 0; i < tc.oldMachines; i++ {
+				machineMap[tc.oldMS.UID].Items = append(machineMap[tc.oldMS.UID].Items, clusterv1.Machine{})
+			}
+
+			r := &MachineDeploymentReconciler{
+				Client:   fake.NewFakeClient(d, tc.oldMS),
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			err := r.rolloutRecreate(d, []*clusterv1.MachineSet{tc.oldMS}, machineMap)
+			g.Expect(err).ToNot(gomega.HaveOccurred())
+
+			msList := &clusterv1.MachineSetList{}
+			g.Expect(r.Client.List(context.Background(), msList, client.InNamespace("default"))).To(gomega.Succeed())
+
+			var newMS *clusterv1.MachineSet
+			for i := range msList.Items {
+				ms := &msList.Items[i]
+				if ms.UID == tc.oldMS.UID {
+					g.Expect(*ms.Spec.Replicas).To(gomega.Equal(tc.expectOldScale))
+					continue
+				}
+				newMS = ms
+			}
+
+			if tc.expectNewMSScale == nil {
+				g.Expect(newMS).To(gomega.BeNil())
+				return
+			}
+			g.Expect(newMS).ToNot(gomega.BeNil())
+			g.Expect(newMS.Spec.Replicas).To(gomega.Equal(tc.expectNewMSScale))
+		})
+	}
+}
+
+func TestOldMachinesRunning(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ms := &clusterv1.MachineSet{ObjectMeta: metav1.ObjectMeta{UID: "ms-uid"}}
+	g.Expect(oldMachinesRunning([]*clusterv1.MachineSet{ms}, map[types.UID]*clusterv1.MachineList{})).To(gomega.BeFalse())
+
+	machineMap := map[types.UID]*clusterv1.MachineList{
+		"ms-uid": {Items: []clusterv1.Machine{{ObjectMeta: metav1.ObjectMeta{Name: "deleting"}}}},
+	}
+	g.Expect(oldMachinesRunning([]*clusterv1.MachineSet{ms}, machineMap)).To(gomega.BeTrue())
+
+	ms.Status.Replicas = 1
+	g.Expect(oldMachinesRunning([]*clusterv1.MachineSet{ms}, map[types.UID]*clusterv1.MachineList{})).To(gomega.BeTrue())
+}
+
+func TestCalculateStatusRecreate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	deployment := &clusterv1.MachineDeployment{
+		Spec: clusterv1.MachineDeploymentSpec{
+			Replicas: pointer.Int32Ptr(3),
+			Strategy: &clusterv1.MachineDeploymentStrategy{Type: clusterv1.RecreateMachineDeploymentStrategyType},
+		},
+	}
+	oldMS := &clusterv1.MachineSet{
+		Spec:   clusterv1.MachineSetSpec{Replicas: pointer.Int32Ptr(0)},
+		Status: clusterv1.MachineSetStatus{Replicas: 1},
+	}
+
+	// All the old machines are being deleted and the new machine set doesn't exist yet.
+	status := calculateStatus([]*clusterv1.MachineSet{oldMS, nil}, nil, deployment)
+	g.Expect(status.UnavailableReplicas).To(gomega.BeEquivalentTo(3))
+	g.Expect(status.UpdatedReplicas).To(gomega.BeEquivalentTo(0))
+	g.Expect(status.Replicas).To(gomega.BeEquivalentTo(1))
+	g.Expect(conditions.IsFalse(&clusterv1.MachineDeployment{Status: status}, clusterv1.MachinesReadyCondition)).To(gomega.BeTrue())
+}
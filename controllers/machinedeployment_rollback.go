@@ -0,0 +1,106 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+)
+
+// rollback the deployment to the specified revision. In any case cleanup the rollback spec.
+func (r *MachineDeploymentReconciler) rollback(d *clusterv1.MachineDeployment, msList []*clusterv1.MachineSet, machineMap map[types.UID]*clusterv1.MachineList) error {
+	newMS, allOldMSs, err := r.getAllMachineSetsAndSyncRevision(d, msList, machineMap, true)
+	if err != nil {
+		return err
+	}
+
+	// newMS can be nil if its annotations have just been updated, fallback to the one in the list.
+	if newMS == nil {
+		newMS = mdutil.FindNewMachineSet(d, msList)
+	}
+
+	allMSs := allOldMSs
+	if newMS != nil {
+		allMSs = append(allMSs, newMS)
+	}
+	revision := d.Spec.RollbackTo.Revision
+
+	// If rollback revision is 0, rollback to the last revision
+	if revision == 0 {
+		if revision = mdutil.LastRevision(allMSs); revision == 0 {
+			// If we still can't find the last revision, gives up rollback
+			r.recorder.Eventf(d, corev1.EventTypeWarning, mdutil.RollbackRevisionNotFound, "Unable to find last revision.")
+			// Gives up rollback
+			return r.updateDeploymentAndClearRollbackTo(d, nil)
+		}
+	}
+
+	for _, ms := range allMSs {
+		v, err := mdutil.Revision(ms)
+		if err != nil {
+			klog.V(4).Infof("Unable to extract revision from MachineDeployment's MachineSet %q: %v", ms.Name, err)
+			continue
+		}
+
+		if v == revision {
+			// Rollback by copying the machine template from the machine set.
+			// The revision number will be incremented during the next getAllMachineSetsAndSyncRevision call,
+			// this is a no-op if the template matches the current deployment's template.
+			return r.rollbackToTemplate(d, ms, revision)
+		}
+	}
+
+	r.recorder.Eventf(d, corev1.EventTypeWarning, mdutil.RollbackRevisionNotFound, "Unable to find the revision %d to rollback to.", revision)
+	// Gives up rollback
+	return r.updateDeploymentAndClearRollbackTo(d, nil)
+}
+
+// rollbackToTemplate compares the templates of the given deployment and machine set and copies the machine set
+// template into the deployment if they are different. The rollback spec of the deployment is always cleaned up.
+func (r *MachineDeploymentReconciler) rollbackToTemplate(d *clusterv1.MachineDeployment, ms *clusterv1.MachineSet, revision int64) error {
+	if mdutil.EqualIgnoreHash(&d.Spec.Template, &ms.Spec.Template) {
+		klog.V(4).Infof("Rolling back to a revision that contains the same template as current MachineDeployment %q, skipping rollback...", d.Name)
+		r.recorder.Eventf(d, corev1.EventTypeWarning, mdutil.RollbackTemplateUnchanged,
+			"The rollback revision contains the same template as current MachineDeployment %q", d.Name)
+		return r.updateDeploymentAndClearRollbackTo(d, nil)
+	}
+
+	klog.V(4).Infof("Rolling back MachineDeployment %q to template spec %+v", d.Name, ms.Spec.Template.Spec)
+	if err := r.updateDeploymentAndClearRollbackTo(d, ms); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(d, corev1.EventTypeNormal, mdutil.RollbackDone, "Rolled back MachineDeployment %q to revision %d", d.Name, revision)
+	return nil
+}
+
+// updateDeploymentAndClearRollbackTo sets .spec.rollbackTo to nil and updates the input deployment.
+// If a machine set is given, its template and annotations are copied into the deployment as well.
+func (r *MachineDeploymentReconciler) updateDeploymentAndClearRollbackTo(d *clusterv1.MachineDeployment, rollbackToMS *clusterv1.MachineSet) error {
+	klog.V(4).Infof("Cleans up rollbackTo of MachineDeployment %q", d.Name)
+	return r.updateMachineDeployment(d, func(innerDeployment *clusterv1.MachineDeployment) {
+		if rollbackToMS != nil {
+			mdutil.SetFromMachineSetTemplate(innerDeployment, rollbackToMS.Spec.Template)
+			// Set the annotations of the machine set we're rolling back to back to the deployment.
+			mdutil.SetDeploymentAnnotationsTo(innerDeployment, rollbackToMS)
+		}
+		innerDeployment.Spec.RollbackTo = nil
+	})
+}
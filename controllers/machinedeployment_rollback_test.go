@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMachineDeploymentRollback(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	template := func(version string) clusterv1.MachineTemplateSpec {
+		return clusterv1.MachineTemplateSpec{
+			ObjectMeta: clusterv1.ObjectMeta{Labels: map[string]string{"pool": "workers"}},
+			Spec:       clusterv1.MachineSpec{Version: pointer.StringPtr(version)},
+		}
+	}
+
+	newDeployment := func(rollbackTo int64) *clusterv1.MachineDeployment {
+		d := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "md-rollback",
+				Namespace:   "default",
+				UID:         "md-uid",
+				Annotations: map[string]string{mdutil.RevisionAnnotation: "2"},
+			},
+			Spec: clusterv1.MachineDeploymentSpec{
+				Replicas:   pointer.Int32Ptr(1),
+				Selector:   metav1.LabelSelector{MatchLabels: map[string]string{"pool": "workers"}},
+				Template:   template("v1.16.2"),
+				RollbackTo: &clusterv1.RollbackConfig{Revision: rollbackTo},
+			},
+		}
+		clusterv1.PopulateDefaultsMachineDeployment(d)
+		return d
+	}
+
+	newMachineSet := func(name, revision string, tpl clusterv1.MachineTemplateSpec) *clusterv1.MachineSet {
+		tpl.Labels = mdutil.CloneAndAddLabel(tpl.Labels, mdutil.DefaultMachineDeploymentUniqueLabelKey, name)
+		return &clusterv1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID(name),
+				Labels:    tpl.Labels,
+				Annotations: map[string]string{
+					mdutil.RevisionAnnotation:        revision,
+					mdutil.DesiredReplicasAnnotation: "1",
+					mdutil.MaxReplicasAnnotation:     "2",
+				},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(newDeployment(0), controllerKind)},
+			},
+			Spec: clusterv1.MachineSetSpec{
+				Replicas: pointer.Int32Ptr(1),
+				Template: tpl,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		rollbackTo      int64
+		expectedVersion string
+	}{
+		{
+			name:            "rollback to the given revision",
+			rollbackTo:      1,
+			expectedVersion: "v1.15.3",
+		},
+		{
+			name:            "rollback to the last revision",
+			rollbackTo:      0,
+			expectedVersion: "v1.15.3",
+		},
+		{
+			name:            "revision not found, gives up rollback",
+			rollbackTo:      5,
+			expectedVersion: "v1.16.2",
+		},
+		{
+			name:            "same template as current one, gives up rollback",
+			rollbackTo:      2,
+			expectedVersion: "v1.16.2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			d := newDeployment(tc.rollbackTo)
+			oldMS := newMachineSet("md-rollback-old", "1", template("v1.15.3"))
+			newMS := newMachineSet("md-rollback-new", "2", template("v1.16.2"))
+
+			r := &MachineDeploymentReconciler{
+				Client:   fake.NewFakeClient(d, oldMS, newMS),
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			err := r.rollback(d, []*clusterv1.MachineSet{oldMS, newMS}, map[types.UID]*clusterv1.MachineList{})
+			g.Expect(err).ToNot(gomega.HaveOccurred())
+
+			updated := &clusterv1.MachineDeployment{}
+			g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: d.Namespace, Name: d.Name}, updated)).To(gomega.Succeed())
+			g.Expect(updated.Spec.RollbackTo).To(gomega.BeNil())
+			g.Expect(*updated.Spec.Template.Spec.Version).To(gomega.Equal(tc.expectedVersion))
+			g.Expect(updated.Spec.Template.Labels).ToNot(gomega.HaveKey(mdutil.DefaultMachineDeploymentUniqueLabelKey))
+		})
+	}
+}
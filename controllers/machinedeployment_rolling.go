@@ -48,7 +48,7 @@ func (r *MachineDeploymentReconciler) rolloutRolling(d *clusterv1.MachineDeploym
 		return err
 	}
 
-	if err := r.syncDeploymentStatus(allMSs, newMS, d); err != nil {
+	if err := r.syncRolloutStatus(allMSs, newMS, d); err != nil {
 		return err
 	}
 
@@ -57,7 +57,7 @@ func (r *MachineDeploymentReconciler) rolloutRolling(d *clusterv1.MachineDeploym
 		return err
 	}
 
-	if err := r.syncDeploymentStatus(allMSs, newMS, d); err != nil {
+	if err := r.syncRolloutStatus(allMSs, newMS, d); err != nil {
 		return err
 	}
 
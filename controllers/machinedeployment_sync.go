@@ -35,6 +35,7 @@ import (
 	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -117,7 +118,17 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 		err := r.updateMachineDeployment(d, func(innerDeployment *clusterv1.MachineDeployment) {
 			mdutil.SetDeploymentRevision(d, msCopy.Annotations[mdutil.RevisionAnnotation])
 		})
-		return msCopy, err
+		if err != nil {
+			return msCopy, err
+		}
+
+		// If no Progressing condition has been recorded yet, the progress of this deployment is
+		// estimated from the first time we noticed its new machine set.
+		if mdutil.HasProgressDeadline(d) && !conditions.Has(d, clusterv1.ProgressingCondition) {
+			msg := fmt.Sprintf("Found new MachineSet %q", msCopy.Name)
+			return msCopy, r.patchProgressingCondition(d, corev1.ConditionTrue, mdutil.FoundNewMSReason, msg)
+		}
+		return msCopy, nil
 	}
 
 	if !createIfNotExisted {
@@ -156,6 +167,12 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 		},
 	}
 
+	// Set the watch label of the MachineSet, its events are filtered out otherwise.
+	// The labels are cloned, the template labels are compared to find the new MachineSet.
+	if r.WatchFilterValue != "" {
+		newMS.Labels = mdutil.CloneAndAddLabel(newMS.Labels, clusterv1.WatchLabel, r.WatchFilterValue)
+	}
+
 	// Add foregroundDeletion finalizer to MachineSet if the MachineDeployment has it
 	if sets.NewString(d.Finalizers...).Has(metav1.FinalizerDeleteDependents) {
 		newMS.Finalizers = []string{metav1.FinalizerDeleteDependents}
@@ -202,6 +219,12 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 	case err != nil:
 		klog.V(4).Infof("Failed to create new machine set %q: %v", newMS.Name, err)
 		r.recorder.Eventf(d, corev1.EventTypeWarning, "FailedCreate", "Failed to create MachineSet %q: %v", newMS.Name, err)
+		if mdutil.HasProgressDeadline(d) {
+			msg := fmt.Sprintf("Failed to create new MachineSet %q: %v", newMS.Name, err)
+			if patchErr := r.patchProgressingCondition(d, corev1.ConditionFalse, mdutil.FailedMSCreateReason, msg); patchErr != nil {
+				klog.Warningf("Failed to patch status for MachineDeployment %q: %v", d.Name, patchErr)
+			}
+		}
 		return nil, err
 	}
 
@@ -213,8 +236,16 @@ func (r *MachineDeploymentReconciler) getNewMachineSet(d *clusterv1.MachineDeplo
 	err = r.updateMachineDeployment(d, func(innerDeployment *clusterv1.MachineDeployment) {
 		mdutil.SetDeploymentRevision(d, newRevision)
 	})
+	if err != nil {
+		return createdMS, err
+	}
 
-	return createdMS, err
+	// A new rollout has started, the progress deadline is measured from now on.
+	if !alreadyExists && mdutil.HasProgressDeadline(d) {
+		msg := fmt.Sprintf("Created new MachineSet %q", createdMS.Name)
+		return createdMS, r.patchProgressingCondition(d, corev1.ConditionTrue, mdutil.NewMachineSetReason, msg)
+	}
+	return createdMS, nil
 }
 
 // scale scales proportionally in order to mitigate risk. Otherwise, scaling up can increase the size
@@ -346,10 +377,21 @@ func (r *MachineDeploymentReconciler) syncDeploymentStatus(allMSs []*clusterv1.M
 
 // calculateStatus calculates the latest status for the provided deployment by looking into the provided machine sets.
 func calculateStatus(allMSs []*clusterv1.MachineSet, newMS *clusterv1.MachineSet, deployment *clusterv1.MachineDeployment) clusterv1.MachineDeploymentStatus {
+	var desiredReplicas int32
+	if deployment.Spec.Replicas != nil {
+		desiredReplicas = *deployment.Spec.Replicas
+	}
+
 	availableReplicas := mdutil.GetAvailableReplicaCountForMachineSets(allMSs)
 	totalReplicas := mdutil.GetReplicaCountForMachineSets(allMSs)
 	unavailableReplicas := totalReplicas - availableReplicas
 
+	// During a Recreate rollout the old machine sets are scaled down to zero before the new one is scaled up,
+	// so the machines that are gone are accounted as unavailable against the desired replicas instead.
+	if deployment.Spec.Strategy != nil && deployment.Spec.Strategy.Type == clusterv1.RecreateMachineDeploymentStrategyType {
+		unavailableReplicas = desiredReplicas - availableReplicas
+	}
+
 	// If unavailableReplicas is negative, then that means the Deployment has more available replicas running than
 	// desired, e.g. whenever it scales down. In such a case we should simply default unavailableReplicas to zero.
 	if unavailableReplicas < 0 {
@@ -364,9 +406,33 @@ func calculateStatus(allMSs []*clusterv1.MachineSet, newMS *clusterv1.MachineSet
 		ReadyReplicas:       mdutil.GetReadyReplicaCountForMachineSets(allMSs),
 		AvailableReplicas:   availableReplicas,
 		UnavailableReplicas: unavailableReplicas,
+		Conditions:          deployment.Status.Conditions.DeepCopy(),
 	}
 
-	return status
+	// Set the conditions through a throwaway MachineDeployment, given that status is a copy.
+	statusHolder := &clusterv1.MachineDeployment{Status: status}
+	setMachinesReadyCondition(statusHolder, desiredReplicas, status.ReadyReplicas)
+
+	if availableReplicas >= desiredReplicas-mdutil.MaxUnavailable(*deployment) {
+		conditions.Set(statusHolder, &clusterv1.Condition{
+			Type:    clusterv1.AvailableCondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  mdutil.MinimumReplicasAvailable,
+			Message: "MachineDeployment has minimum availability.",
+		})
+	} else {
+		conditions.MarkFalse(statusHolder, clusterv1.AvailableCondition, mdutil.MinimumReplicasUnavailable, clusterv1.ConditionSeverityWarning,
+			"MachineDeployment does not have minimum availability.")
+	}
+	return statusHolder.Status
+}
+
+// patchProgressingCondition sets the Progressing condition of the deployment and patches its status.
+// The progress deadline is restarted, given that a new machine set has been created or found.
+func (r *MachineDeploymentReconciler) patchProgressingCondition(d *clusterv1.MachineDeployment, status corev1.ConditionStatus, reason, message string) error {
+	patch := client.MergeFrom(d.DeepCopy())
+	setProgressingCondition(d, status, reason, message, true)
+	return r.Status().Patch(context.Background(), d, patch)
 }
 
 func (r *MachineDeploymentReconciler) scaleMachineSet(ms *clusterv1.MachineSet, newScale int32, deployment *clusterv1.MachineDeployment) (bool, error) {
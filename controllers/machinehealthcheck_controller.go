@@ -0,0 +1,311 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var (
+	// defaultNodeStartupTimeout is used when a MachineHealthCheck doesn't set NodeStartupTimeout.
+	defaultNodeStartupTimeout = 10 * time.Minute
+
+	// defaultMaxUnhealthy is used when a MachineHealthCheck doesn't set MaxUnhealthy.
+	defaultMaxUnhealthy = intstr.FromString("100%")
+)
+
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinehealthchecks;machinehealthchecks/status,verbs=get;list;watch;update;patch
+
+// MachineHealthCheckReconciler reconciles a MachineHealthCheck object
+type MachineHealthCheckReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	// Tracker caches the clients of workload clusters. If unset, a new client is created on every request.
+	Tracker *remote.ClusterCacheTracker
+
+	// WatchFilterValue is the value of the watch label of the objects to reconcile. Every object is reconciled if empty.
+	WatchFilterValue string
+
+	recorder           record.EventRecorder
+	remoteClientGetter remote.ClusterClientGetter
+}
+
+func (r *MachineHealthCheckReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.MachineHealthCheck{}).
+		Watches(
+			&source.Kind{Type: &clusterv1.Machine{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.MachineToMachineHealthChecks)},
+		).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceHasFilterLabel(r.WatchFilterValue)).
+		Complete(r)
+
+	r.recorder = mgr.GetEventRecorderFor("machinehealthcheck-controller")
+	return err
+}
+
+// clusterClientGetter returns the function used to build clients for workload clusters.
+func (r *MachineHealthCheckReconciler) clusterClientGetter() remote.ClusterClientGetter {
+	if r.remoteClientGetter != nil {
+		return r.remoteClientGetter
+	}
+	if r.Tracker != nil {
+		return r.Tracker.ClusterClient
+	}
+	return remote.NewClusterClient
+}
+
+func (r *MachineHealthCheckReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	_ = r.Log.WithValues("machinehealthcheck", req.NamespacedName)
+
+	// Fetch the MachineHealthCheck instance.
+	mhc := &clusterv1.MachineHealthCheck{}
+	if err := r.Get(ctx, req.NamespacedName, mhc); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Object not found, return.  Created objects are automatically garbage collected.
+			// For additional cleanup logic use finalizers.
+			return ctrl.Result{}, nil
+		}
+
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	// Ignore the MachineHealthChecks without the watch label of this manager, they can still be enqueued by other watches.
+	if !util.HasWatchLabel(mhc, r.WatchFilterValue) {
+		return ctrl.Result{}, nil
+	}
+
+	// Ignore deleted MachineHealthChecks.
+	if !mhc.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	cluster, err := util.GetClusterByName(ctx, r.Client, mhc.Namespace, mhc.Spec.ClusterName)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get Cluster %q for MachineHealthCheck %q in namespace %q",
+			mhc.Spec.ClusterName, mhc.Name, mhc.Namespace)
+	}
+
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(mhc, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always attempt to Patch the MachineHealthCheck object and status after each reconciliation.
+		if err := patchHelper.Patch(ctx, mhc); err != nil {
+			if reterr == nil {
+				reterr = err
+			}
+		}
+	}()
+
+	// Make sure the MachineHealthCheck is garbage collected together with its Cluster.
+	mhc.OwnerReferences = util.EnsureOwnerRef(mhc.OwnerReferences, metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "Cluster",
+		Name:       cluster.Name,
+		UID:        cluster.UID,
+	})
+
+	result, err := r.reconcile(ctx, cluster, mhc)
+	if err != nil {
+		klog.Errorf("Failed to reconcile MachineHealthCheck %q: %v", req.NamespacedName, err)
+		r.recorder.Eventf(mhc, corev1.EventTypeWarning, "ReconcileError", "%v", err)
+	}
+	return result, err
+}
+
+func (r *MachineHealthCheckReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, mhc *clusterv1.MachineHealthCheck) (ctrl.Result, error) {
+	mhc.Status.ObservedGeneration = mhc.Generation
+
+	remoteClient, err := r.clusterClientGetter()(r.Client, cluster)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to create a remote client for cluster %q", cluster.Name)
+	}
+
+	corev1Remote, err := remoteClient.CoreV1()
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to create a remote client for cluster %q", cluster.Name)
+	}
+
+	targets, err := r.getTargetsFromMHC(ctx, corev1Remote, mhc)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get targets for MachineHealthCheck %q in namespace %q", mhc.Name, mhc.Namespace)
+	}
+
+	healthy, unhealthy, nextCheckTimes := healthCheckTargets(targets, nodeStartupTimeout(mhc))
+	mhc.Status.ExpectedMachines = int32(len(targets))
+	mhc.Status.CurrentHealthy = int32(len(healthy))
+
+	result := ctrl.Result{}
+	if minNextCheck := minDuration(nextCheckTimes); minNextCheck > 0 {
+		result.RequeueAfter = minNextCheck
+	}
+
+	// Don't remediate anything if too many Machines are unhealthy, e.g. because of a network partition.
+	if !isRemediationAllowed(mhc, len(unhealthy)) {
+		klog.Warningf("Not remediating %d unhealthy machines of MachineHealthCheck %q in namespace %q: more than %s of %d are unhealthy",
+			len(unhealthy), mhc.Name, mhc.Namespace, maxUnhealthy(mhc).String(), len(targets))
+		conditions.MarkFalse(mhc, clusterv1.RemediationAllowedCondition, clusterv1.TooManyUnhealthyReason, clusterv1.ConditionSeverityWarning,
+			"Remediation is not allowed, %d of %d machines are unhealthy (max %s)", len(unhealthy), len(targets), maxUnhealthy(mhc).String())
+		r.recorder.Eventf(mhc, corev1.EventTypeWarning, "RemediationRestricted",
+			"Remediation restricted: %d of %d machines are unhealthy (max %s)", len(unhealthy), len(targets), maxUnhealthy(mhc).String())
+		return result, nil
+	}
+	conditions.MarkTrue(mhc, clusterv1.RemediationAllowedCondition)
+
+	errs := []error{}
+	for _, t := range unhealthy {
+		if err := r.remediate(ctx, mhc, t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return result, kerrors.NewAggregate(errs)
+}
+
+// remediate deletes the unhealthy Machine, so that the MachineSet owning it creates a replacement.
+// Machines that aren't owned by a MachineSet are left untouched, given that nothing would replace them.
+func (r *MachineHealthCheckReconciler) remediate(ctx context.Context, mhc *clusterv1.MachineHealthCheck, t healthCheckTarget) error {
+	if !t.hasMachineSetOwner() {
+		klog.V(3).Infof("Not remediating unhealthy machine %q in namespace %q: it isn't owned by a MachineSet",
+			t.Machine.Name, t.Machine.Namespace)
+		return nil
+	}
+
+	klog.Infof("Deleting unhealthy machine %q in namespace %q: %s", t.Machine.Name, t.Machine.Namespace, t.reason)
+	if err := r.Client.Delete(ctx, t.Machine); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete unhealthy Machine %q in namespace %q", t.Machine.Name, t.Machine.Namespace)
+	}
+
+	r.recorder.Eventf(mhc, corev1.EventTypeNormal, "MachineRemediated", "Deleted unhealthy machine %q: %s", t.Machine.Name, t.reason)
+	return nil
+}
+
+// MachineToMachineHealthChecks maps a Machine to the MachineHealthChecks selecting it.
+func (r *MachineHealthCheckReconciler) MachineToMachineHealthChecks(o handler.MapObject) []ctrl.Request {
+	m, ok := o.Object.(*clusterv1.Machine)
+	if !ok {
+		klog.Errorf("expected a Machine but got a %T", o.Object)
+		return nil
+	}
+
+	mhcList := &clusterv1.MachineHealthCheckList{}
+	if err := r.Client.List(context.Background(), mhcList, client.InNamespace(m.Namespace)); err != nil {
+		klog.Errorf("Failed to list MachineHealthChecks, %v", err)
+		return nil
+	}
+
+	result := []ctrl.Request{}
+	for i := range mhcList.Items {
+		mhc := &mhcList.Items[i]
+		if machineMatchesHealthCheck(mhc, m) {
+			name := client.ObjectKey{Namespace: mhc.Namespace, Name: mhc.Name}
+			result = append(result, ctrl.Request{NamespacedName: name})
+		}
+	}
+
+	return result
+}
+
+// machineMatchesHealthCheck returns true if the Machine belongs to the MachineHealthCheck's Cluster
+// and matches its selector. An empty selector doesn't match any Machine.
+func machineMatchesHealthCheck(mhc *clusterv1.MachineHealthCheck, m *clusterv1.Machine) bool {
+	if m.Labels[clusterv1.MachineClusterLabelName] != mhc.Spec.ClusterName {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&mhc.Spec.Selector)
+	if err != nil {
+		klog.Warningf("Unable to convert selector of MachineHealthCheck %q: %v", mhc.Name, err)
+		return false
+	}
+
+	if selector.Empty() {
+		return false
+	}
+
+	return selector.Matches(labels.Set(m.Labels))
+}
+
+// isRemediationAllowed returns true if the number of unhealthy Machines doesn't exceed MaxUnhealthy.
+func isRemediationAllowed(mhc *clusterv1.MachineHealthCheck, unhealthy int) bool {
+	max, err := intstr.GetValueFromIntOrPercent(maxUnhealthy(mhc), int(mhc.Status.ExpectedMachines), false)
+	if err != nil {
+		klog.Errorf("Failed to parse MaxUnhealthy of MachineHealthCheck %q in namespace %q: %v", mhc.Name, mhc.Namespace, err)
+		return false
+	}
+	return unhealthy <= max
+}
+
+func maxUnhealthy(mhc *clusterv1.MachineHealthCheck) *intstr.IntOrString {
+	if mhc.Spec.MaxUnhealthy == nil {
+		return &defaultMaxUnhealthy
+	}
+	return mhc.Spec.MaxUnhealthy
+}
+
+func nodeStartupTimeout(mhc *clusterv1.MachineHealthCheck) time.Duration {
+	if mhc.Spec.NodeStartupTimeout == nil {
+		return defaultNodeStartupTimeout
+	}
+	return mhc.Spec.NodeStartupTimeout.Duration
+}
+
+// minDuration returns the smallest of the given durations, or zero if there are none.
+func minDuration(durations []time.Duration) time.Duration {
+	var min time.Duration
+	for _, d := range durations {
+		if min == 0 || d < min {
+			min = d
+		}
+	}
+	return min
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ reconcile.Reconciler = &MachineHealthCheckReconciler{}
+
+func TestMachineHealthCheckReconcile(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+	isController := true
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+
+	newMachine := func(name string, healthy bool) (*clusterv1.Machine, *corev1.Node) {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					clusterv1.MachineClusterLabelName: "test-cluster",
+					"pool":                            "workers",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineSet", Name: "ms", Controller: &isController},
+				},
+			},
+			Status: clusterv1.MachineStatus{
+				NodeRef: &corev1.ObjectReference{Name: name},
+			},
+		}
+
+		status := corev1.ConditionTrue
+		if !healthy {
+			status = corev1.ConditionFalse
+		}
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour))},
+				},
+			},
+		}
+		return m, node
+	}
+
+	testCases := []struct {
+		name             string
+		unhealthy        int
+		maxUnhealthy     *intstr.IntOrString
+		expectRemediated bool
+	}{
+		{
+			name:             "unhealthy machine is remediated",
+			unhealthy:        1,
+			expectRemediated: true,
+		},
+		{
+			name:             "remediation is short-circuited by maxUnhealthy",
+			unhealthy:        2,
+			maxUnhealthy:     intstrPtr(intstr.FromString("40%")),
+			expectRemediated: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			mhc := &clusterv1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{Name: "mhc", Namespace: "default"},
+				Spec: clusterv1.MachineHealthCheckSpec{
+					ClusterName: "test-cluster",
+					Selector:    metav1.LabelSelector{MatchLabels: map[string]string{"pool": "workers"}},
+					UnhealthyConditions: []clusterv1.UnhealthyCondition{
+						{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Timeout: metav1.Duration{Duration: time.Minute}},
+					},
+					MaxUnhealthy: tc.maxUnhealthy,
+				},
+			}
+
+			objs := []runtime.Object{cluster.DeepCopy()}
+			nodes := []runtime.Object{}
+			for i := 0; i < 4; i++ {
+				m, node := newMachine(fmt.Sprintf("machine-%d", i), i >= tc.unhealthy)
+				objs = append(objs, m)
+				nodes = append(nodes, node)
+			}
+
+			r := &MachineHealthCheckReconciler{
+				Client:             fake.NewFakeClient(objs...),
+				Log:                log.Log,
+				recorder:           record.NewFakeRecorder(32),
+				remoteClientGetter: fakeClusterClientGetter(fakeclient.NewSimpleClientset(nodes...).CoreV1()),
+			}
+
+			_, err := r.reconcile(context.Background(), cluster, mhc)
+			g.Expect(err).ToNot(gomega.HaveOccurred())
+
+			g.Expect(mhc.Status.ExpectedMachines).To(gomega.BeEquivalentTo(4))
+			g.Expect(mhc.Status.CurrentHealthy).To(gomega.BeEquivalentTo(4 - tc.unhealthy))
+			g.Expect(conditions.IsTrue(mhc, clusterv1.RemediationAllowedCondition)).To(gomega.Equal(tc.expectRemediated))
+
+			for i := 0; i < tc.unhealthy; i++ {
+				err := r.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: fmt.Sprintf("machine-%d", i)}, &clusterv1.Machine{})
+				g.Expect(apierrors.IsNotFound(err)).To(gomega.Equal(tc.expectRemediated))
+			}
+
+			// Healthy machines are never touched.
+			err = r.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "machine-3"}, &clusterv1.Machine{})
+			g.Expect(err).ToNot(gomega.HaveOccurred())
+		})
+	}
+}
+
+func TestMachineToMachineHealthChecks(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	mhc := &clusterv1.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "mhc", Namespace: "default"},
+		Spec: clusterv1.MachineHealthCheckSpec{
+			ClusterName: "test-cluster",
+			Selector:    metav1.LabelSelector{MatchLabels: map[string]string{"pool": "workers"}},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected int
+	}{
+		{
+			name:     "matching machine",
+			labels:   map[string]string{clusterv1.MachineClusterLabelName: "test-cluster", "pool": "workers"},
+			expected: 1,
+		},
+		{
+			name:     "machine of another cluster",
+			labels:   map[string]string{clusterv1.MachineClusterLabelName: "other-cluster", "pool": "workers"},
+			expected: 0,
+		},
+		{
+			name:     "machine not selected",
+			labels:   map[string]string{clusterv1.MachineClusterLabelName: "test-cluster"},
+			expected: 0,
+		},
+	}
+
+	r := &MachineHealthCheckReconciler{
+		Client: fake.NewFakeClient(mhc),
+		Log:    log.Log,
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default", Labels: tc.labels}}
+			got := r.MachineToMachineHealthChecks(handler.MapObject{Meta: m.GetObjectMeta(), Object: m})
+			if len(got) != tc.expected {
+				t.Errorf("expected %d requests, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func intstrPtr(i intstr.IntOrString) *intstr.IntOrString {
+	return &i
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// healthCheckTarget is a Machine selected by a MachineHealthCheck, together with its Node.
+type healthCheckTarget struct {
+	Machine *clusterv1.Machine
+	// Node is nil if the Machine doesn't have a NodeRef yet or if the Node doesn't exist anymore.
+	Node *corev1.Node
+	MHC  *clusterv1.MachineHealthCheck
+
+	// reason explains why the target was found to need remediation.
+	reason string
+}
+
+// nodeMissing returns true if the Machine has a NodeRef but the Node doesn't exist.
+func (t *healthCheckTarget) nodeMissing() bool {
+	return t.Machine.Status.NodeRef != nil && t.Node == nil
+}
+
+// hasMachineSetOwner returns true if the Machine is controlled by a MachineSet.
+func (t *healthCheckTarget) hasMachineSetOwner() bool {
+	ref := metav1.GetControllerOf(t.Machine)
+	if ref == nil || ref.Kind != "MachineSet" {
+		return false
+	}
+
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	return err == nil && gv.Group == clusterv1.GroupVersion.Group
+}
+
+// needsRemediation returns true if the target is unhealthy. Otherwise, if the target could
+// become unhealthy because of a timeout, it also returns how long to wait before checking again.
+func (t *healthCheckTarget) needsRemediation(timeoutForMachineToHaveNode time.Duration) (bool, time.Duration) {
+	now := time.Now()
+
+	if t.Machine.Status.ErrorReason != nil || t.Machine.Status.ErrorMessage != nil {
+		t.reason = "Machine has failed"
+		return true, 0
+	}
+
+	if t.nodeMissing() {
+		t.reason = fmt.Sprintf("Node %q has been deleted", t.Machine.Status.NodeRef.Name)
+		return true, 0
+	}
+
+	// The Machine doesn't have a Node yet, check how long it has been waiting for one.
+	if t.Node == nil {
+		deadline := t.Machine.CreationTimestamp.Add(timeoutForMachineToHaveNode)
+		if now.After(deadline) {
+			t.reason = fmt.Sprintf("Machine has no Node after %v", timeoutForMachineToHaveNode)
+			return true, 0
+		}
+		return false, deadline.Sub(now)
+	}
+
+	var nextCheck time.Duration
+	for _, c := range t.MHC.Spec.UnhealthyConditions {
+		nodeCondition := getNodeCondition(t.Node, c.Type)
+		if nodeCondition == nil || nodeCondition.Status != c.Status {
+			continue
+		}
+
+		deadline := nodeCondition.LastTransitionTime.Add(c.Timeout.Duration)
+		if now.After(deadline) {
+			t.reason = fmt.Sprintf("Node condition %s has been %s for more than %v", c.Type, c.Status, c.Timeout.Duration)
+			return true, 0
+		}
+
+		if wait := deadline.Sub(now); nextCheck == 0 || wait < nextCheck {
+			nextCheck = wait
+		}
+	}
+
+	return false, nextCheck
+}
+
+// getTargetsFromMHC returns the Machines selected by the MachineHealthCheck, together with their Nodes.
+// Machines that are being deleted are ignored.
+func (r *MachineHealthCheckReconciler) getTargetsFromMHC(ctx context.Context, remoteClient corev1client.CoreV1Interface, mhc *clusterv1.MachineHealthCheck) ([]healthCheckTarget, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&mhc.Spec.Selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build selector")
+	}
+
+	// An empty selector would select every Machine of the Cluster.
+	if selector.Empty() {
+		return nil, nil
+	}
+
+	machineList := &clusterv1.MachineList{}
+	if err := r.Client.List(ctx, machineList,
+		client.InNamespace(mhc.Namespace),
+		client.MatchingLabels(map[string]string{clusterv1.MachineClusterLabelName: mhc.Spec.ClusterName}),
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to list machines")
+	}
+
+	targets := []healthCheckTarget{}
+	for i := range machineList.Items {
+		m := &machineList.Items[i]
+		if !m.DeletionTimestamp.IsZero() || !machineMatchesHealthCheck(mhc, m) {
+			continue
+		}
+
+		target := healthCheckTarget{Machine: m, MHC: mhc}
+		if m.Status.NodeRef != nil {
+			node, err := remoteClient.Nodes().Get(m.Status.NodeRef.Name, metav1.GetOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to get node %q for Machine %q", m.Status.NodeRef.Name, m.Name)
+			}
+			if err == nil {
+				target.Node = node
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// healthCheckTargets splits the targets into healthy and unhealthy ones, and returns how long to wait
+// before the healthy targets that are currently failing a check should be checked again.
+func healthCheckTargets(targets []healthCheckTarget, timeoutForMachineToHaveNode time.Duration) ([]healthCheckTarget, []healthCheckTarget, []time.Duration) {
+	var healthy, unhealthy []healthCheckTarget
+	var nextCheckTimes []time.Duration
+
+	for _, t := range targets {
+		needsRemediation, nextCheck := t.needsRemediation(timeoutForMachineToHaveNode)
+		if needsRemediation {
+			unhealthy = append(unhealthy, t)
+			continue
+		}
+
+		if nextCheck > 0 {
+			nextCheckTimes = append(nextCheckTimes, nextCheck)
+		}
+		healthy = append(healthy, t)
+	}
+
+	return healthy, unhealthy, nextCheckTimes
+}
+
+func getNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+func TestHealthCheckTargets(t *testing.T) {
+	mhc := &clusterv1.MachineHealthCheck{
+		Spec: clusterv1.MachineHealthCheckSpec{
+			UnhealthyConditions: []clusterv1.UnhealthyCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionUnknown, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+				{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+			},
+		},
+	}
+
+	nodeWithReady := func(status corev1.ConditionStatus, since time.Duration) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(time.Now().Add(-since))},
+				},
+			},
+		}
+	}
+
+	machine := func(age time.Duration, hasNodeRef bool) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "machine",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+		}
+		if hasNodeRef {
+			m.Status.NodeRef = &corev1.ObjectReference{Name: "node"}
+		}
+		return m
+	}
+
+	failedMachine := machine(time.Minute, true)
+	failedMachine.Status.ErrorReason = capierrors.MachineStatusErrorPtr(capierrors.CreateMachineError)
+
+	testCases := []struct {
+		name              string
+		target            healthCheckTarget
+		expectUnhealthy   bool
+		expectNextCheckIn bool
+	}{
+		{
+			name:   "healthy node",
+			target: healthCheckTarget{Machine: machine(time.Hour, true), Node: nodeWithReady(corev1.ConditionTrue, time.Hour), MHC: mhc},
+		},
+		{
+			name:              "node not ready, within timeout",
+			target:            healthCheckTarget{Machine: machine(time.Hour, true), Node: nodeWithReady(corev1.ConditionFalse, time.Minute), MHC: mhc},
+			expectNextCheckIn: true,
+		},
+		{
+			name:            "node not ready, timed out",
+			target:          healthCheckTarget{Machine: machine(time.Hour, true), Node: nodeWithReady(corev1.ConditionFalse, 10*time.Minute), MHC: mhc},
+			expectUnhealthy: true,
+		},
+		{
+			name:            "node unreachable, timed out",
+			target:          healthCheckTarget{Machine: machine(time.Hour, true), Node: nodeWithReady(corev1.ConditionUnknown, 10*time.Minute), MHC: mhc},
+			expectUnhealthy: true,
+		},
+		{
+			name:            "node deleted",
+			target:          healthCheckTarget{Machine: machine(time.Hour, true), MHC: mhc},
+			expectUnhealthy: true,
+		},
+		{
+			name:              "no node yet, within startup timeout",
+			target:            healthCheckTarget{Machine: machine(time.Minute, false), MHC: mhc},
+			expectNextCheckIn: true,
+		},
+		{
+			name:            "no node after startup timeout",
+			target:          healthCheckTarget{Machine: machine(time.Hour, false), MHC: mhc},
+			expectUnhealthy: true,
+		},
+		{
+			name:            "machine failed",
+			target:          healthCheckTarget{Machine: failedMachine, Node: nodeWithReady(corev1.ConditionTrue, time.Hour), MHC: mhc},
+			expectUnhealthy: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			healthy, unhealthy, nextCheckTimes := healthCheckTargets([]healthCheckTarget{tc.target}, 10*time.Minute)
+			if tc.expectUnhealthy {
+				g.Expect(unhealthy).To(gomega.HaveLen(1))
+				g.Expect(unhealthy[0].reason).ToNot(gomega.BeEmpty())
+				g.Expect(healthy).To(gomega.BeEmpty())
+			} else {
+				g.Expect(healthy).To(gomega.HaveLen(1))
+				g.Expect(unhealthy).To(gomega.BeEmpty())
+			}
+
+			if tc.expectNextCheckIn {
+				g.Expect(nextCheckTimes).To(gomega.HaveLen(1))
+				g.Expect(nextCheckTimes[0]).To(gomega.BeNumerically(">", 0))
+			} else {
+				g.Expect(nextCheckTimes).To(gomega.BeEmpty())
+			}
+		})
+	}
+}
+
+func TestHasMachineSetOwner(t *testing.T) {
+	isController := true
+
+	testCases := []struct {
+		name     string
+		owners   []metav1.OwnerReference
+		expected bool
+	}{
+		{
+			name:     "no owner",
+			expected: false,
+		},
+		{
+			name: "owned by a MachineSet",
+			owners: []metav1.OwnerReference{
+				{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineSet", Name: "ms", Controller: &isController},
+			},
+			expected: true,
+		},
+		{
+			name: "MachineSet is not the controller",
+			owners: []metav1.OwnerReference{
+				{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineSet", Name: "ms"},
+			},
+			expected: false,
+		},
+		{
+			name: "owned by something else",
+			owners: []metav1.OwnerReference{
+				{APIVersion: "controlplane.example.com/v1", Kind: "MachineSet", Name: "cp", Controller: &isController},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := healthCheckTarget{Machine: &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{OwnerReferences: tc.owners}}}
+			if got := target.hasMachineSetOwner(); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinepools;machinepools/status,verbs=get;list;watch;create;update;patch;delete
+
+// MachinePoolReconciler reconciles a MachinePool object.
+// Unlike a MachineSet, a MachinePool doesn't create one Machine per replica: the
+// infrastructure provider object referenced by the template is responsible for
+// creating and scaling the instances, e.g. through an autoscaling group.
+type MachinePoolReconciler struct {
+	client.Client
+	Log logr.Logger
+
+	// Tracker caches the clients of workload clusters. If unset, a new client is created on every request.
+	Tracker *remote.ClusterCacheTracker
+
+	// WatchFilterValue is the value of the watch label of the objects to reconcile. Every object is reconciled if empty.
+	WatchFilterValue string
+
+	controller       controller.Controller
+	recorder         record.EventRecorder
+	externalWatchers sync.Map
+
+	// remoteClientGetter returns a client for the workload cluster, defaults to the Tracker if set
+	// or remote.NewClusterClient otherwise.
+	remoteClientGetter remote.ClusterClientGetter
+}
+
+func (r *MachinePoolReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.MachinePool{}).
+		WithOptions(options).
+		WithEventFilter(predicates.ResourceHasFilterLabel(r.WatchFilterValue)).
+		Build(r)
+
+	r.controller = c
+	r.recorder = mgr.GetEventRecorderFor("machinepool-controller")
+	return err
+}
+
+// clusterClientGetter returns the function used to create clients for workload clusters.
+func (r *MachinePoolReconciler) clusterClientGetter() remote.ClusterClientGetter {
+	if r.remoteClientGetter != nil {
+		return r.remoteClientGetter
+	}
+	if r.Tracker != nil {
+		return r.Tracker.ClusterClient
+	}
+	return remote.NewClusterClient
+}
+
+func (r *MachinePoolReconciler) Reconcile(req ctrl.Request) (_ ctrl.Result, reterr error) {
+	ctx := context.Background()
+	_ = r.Log.WithValues("machinepool", req.NamespacedName)
+
+	// Fetch the MachinePool instance
+	mp := &clusterv1.MachinePool{}
+	if err := r.Client.Get(ctx, req.NamespacedName, mp); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Object not found, return.  Created objects are automatically garbage collected.
+			// For additional cleanup logic use finalizers.
+			return ctrl.Result{}, nil
+		}
+
+		// Error reading the object - requeue the request.
+		return ctrl.Result{}, err
+	}
+
+	// Ignore the MachinePools without the watch label of this manager, they can still be enqueued by other watches.
+	if !util.HasWatchLabel(mp, r.WatchFilterValue) {
+		return ctrl.Result{}, nil
+	}
+
+	// Initialize the patch helper
+	patchHelper, err := patch.NewHelper(mp, r)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defer func() {
+		// Always reconcile the Status.Phase field.
+		r.reconcilePhase(mp)
+
+		// Always summarize the MachinePool conditions into the Ready condition.
+		r.reconcileReadyCondition(mp)
+
+		// Always attempt to Patch the MachinePool object and status after each reconciliation.
+		if err := patchHelper.Patch(ctx, mp); err != nil {
+			if reterr == nil {
+				reterr = err
+			}
+		}
+	}()
+
+	// Cluster might be nil as some providers might not require a cluster object
+	// for machine management. Without a Cluster, Nodes can't be matched to the pool.
+	cluster, err := util.GetClusterFromMetadata(ctx, r.Client, mp.ObjectMeta)
+	if errors.Cause(err) == util.ErrNoCluster {
+		klog.V(2).Infof("MachinePool %q in namespace %q doesn't specify %q label, assuming nil cluster",
+			mp.Name, mp.Namespace, clusterv1.MachineClusterLabelName)
+	} else if err != nil {
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get cluster %q for MachinePool %q in namespace %q",
+			mp.Labels[clusterv1.MachineClusterLabelName], mp.Name, mp.Namespace)
+	}
+
+	// Handle deletion reconciliation loop.
+	if !mp.ObjectMeta.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, mp)
+	}
+
+	// Handle normal reconciliation loop.
+	return r.reconcile(ctx, cluster, mp)
+}
+
+func (r *MachinePoolReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster, mp *clusterv1.MachinePool) (ctrl.Result, error) {
+	// If the MachinePool belongs to a cluster, add an owner reference.
+	if cluster != nil {
+		mp.OwnerReferences = util.EnsureOwnerRef(mp.OwnerReferences, metav1.OwnerReference{
+			APIVersion: cluster.APIVersion,
+			Kind:       cluster.Kind,
+			Name:       cluster.Name,
+			UID:        cluster.UID,
+		})
+	}
+
+	// If the MachinePool doesn't have a finalizer, add one.
+	if !util.Contains(mp.Finalizers, clusterv1.MachinePoolFinalizer) {
+		mp.Finalizers = append(mp.ObjectMeta.Finalizers, clusterv1.MachinePoolFinalizer)
+	}
+
+	mp.Status.ObservedGeneration = mp.Generation
+
+	// Call the inner reconciliation methods.
+	reconciliationErrors := []error{
+		r.reconcileBootstrap(ctx, mp),
+		r.reconcileInfrastructure(ctx, mp),
+		r.reconcileNodeRefs(ctx, cluster, mp),
+	}
+
+	// Parse the errors, making sure we record if there is a RequeueAfterError.
+	res := ctrl.Result{}
+	errs := []error{}
+	for _, err := range reconciliationErrors {
+		if requeueErr, ok := errors.Cause(err).(capierrors.HasRequeueAfterError); ok {
+			// Only record and log the first RequeueAfterError.
+			if !res.Requeue {
+				res.Requeue = true
+				res.RequeueAfter = requeueErr.GetRequeueAfter()
+				klog.Infof("Reconciliation for MachinePool %q in namespace %q asked to requeue: %v", mp.Name, mp.Namespace, err)
+			}
+			continue
+		}
+
+		errs = append(errs, err)
+	}
+	return res, kerrors.NewAggregate(errs)
+}
+
+func (r *MachinePoolReconciler) reconcileDelete(ctx context.Context, mp *clusterv1.MachinePool) (ctrl.Result, error) {
+	// Deleting the infrastructure object is expected to delete all the instances of the pool,
+	// the Nodes are then removed from the workload cluster by the cloud provider.
+	if ok, err := r.reconcileDeleteExternal(ctx, mp); !ok || err != nil {
+		// Return early and don't remove the finalizer if we got an error or
+		// the external reconciliation deletion isn't ready.
+		return ctrl.Result{}, err
+	}
+
+	// Delete the bootstrap data secret, if it has been created by the controller.
+	if err := deleteBootstrapDataSecret(ctx, r.Client, mp, &mp.Spec.Template.Spec.Bootstrap); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	mp.ObjectMeta.Finalizers = util.Filter(mp.ObjectMeta.Finalizers, clusterv1.MachinePoolFinalizer)
+	return ctrl.Result{}, nil
+}
+
+// reconcileDeleteExternal tries to delete external references, returning true if it cannot find any.
+func (r *MachinePoolReconciler) reconcileDeleteExternal(ctx context.Context, mp *clusterv1.MachinePool) (bool, error) {
+	objects := []*unstructured.Unstructured{}
+	references := []*corev1.ObjectReference{
+		mp.Spec.Template.Spec.Bootstrap.ConfigRef,
+		&mp.Spec.Template.Spec.InfrastructureRef,
+	}
+
+	// Loop over the references and try to retrieve it with the client.
+	for _, ref := range references {
+		if ref == nil {
+			continue
+		}
+
+		obj, err := external.Get(r.Client, ref, mp.Namespace)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, errors.Wrapf(err, "failed to get %s %q for MachinePool %q in namespace %q",
+				ref.GroupVersionKind(), ref.Name, mp.Name, mp.Namespace)
+		}
+		if obj != nil {
+			objects = append(objects, obj)
+		}
+	}
+
+	// Issue a delete request for any object that has been found.
+	for _, obj := range objects {
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return false, errors.Wrapf(err,
+				"failed to delete %v %q for MachinePool %q in namespace %q",
+				obj.GroupVersionKind(), obj.GetName(), mp.Name, mp.Namespace)
+		}
+	}
+
+	// Return true if there are no more external objects.
+	return len(objects) == 0, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apicorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/noderefutil"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+)
+
+// reconcileNodeRefs matches the provider IDs reported by the infrastructure provider to the Nodes
+// of the workload cluster, and updates the NodeRefs and the replica counters of the MachinePool.
+func (r *MachinePoolReconciler) reconcileNodeRefs(ctx context.Context, cluster *clusterv1.Cluster, mp *clusterv1.MachinePool) error {
+	// Check that the MachinePool hasn't been deleted or in the process.
+	if !mp.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	// Check that Cluster isn't nil.
+	if cluster == nil {
+		klog.V(2).Infof("MachinePool %q in namespace %q doesn't have a linked cluster, won't assign NodeRefs", mp.Name, mp.Namespace)
+		return nil
+	}
+
+	// Check that the MachinePool has provider IDs to match.
+	if len(mp.Spec.ProviderIDList) == 0 {
+		klog.V(2).Infof("MachinePool %q in namespace %q doesn't have any ProviderIDs yet", mp.Name, mp.Namespace)
+		return nil
+	}
+
+	clusterClient, err := r.clusterClientGetter()(r.Client, cluster)
+	if err != nil {
+		return err
+	}
+
+	corev1Client, err := clusterClient.CoreV1()
+	if err != nil {
+		return err
+	}
+
+	nodeRefs, nodes, err := r.getNodeReferences(corev1Client, mp.Spec.ProviderIDList)
+	if err != nil {
+		r.recorder.Event(mp, apicorev1.EventTypeWarning, "FailedSetNodeRefs", err.Error())
+		return errors.Wrapf(err, "failed to get Node references for MachinePool %q in namespace %q", mp.Name, mp.Namespace)
+	}
+
+	var minReadySeconds int32
+	if mp.Spec.MinReadySeconds != nil {
+		minReadySeconds = *mp.Spec.MinReadySeconds
+	}
+
+	var readyReplicas, availableReplicas int32
+	now := metav1.Now()
+	for _, node := range nodes {
+		if noderefutil.IsNodeReady(node) {
+			readyReplicas++
+		}
+		if noderefutil.IsNodeAvailable(node, minReadySeconds, now) {
+			availableReplicas++
+		}
+	}
+
+	desiredReplicas := machinePoolReplicas(mp)
+
+	mp.Status.NodeRefs = nodeRefs
+	mp.Status.ReadyReplicas = readyReplicas
+	mp.Status.AvailableReplicas = availableReplicas
+	mp.Status.UnavailableReplicas = desiredReplicas - availableReplicas
+	if mp.Status.UnavailableReplicas < 0 {
+		mp.Status.UnavailableReplicas = 0
+	}
+
+	if len(nodeRefs) != len(mp.Spec.ProviderIDList) {
+		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: 10 * time.Second},
+			"found %d Nodes out of %d ProviderIDs for MachinePool %q in namespace %q",
+			len(nodeRefs), len(mp.Spec.ProviderIDList), mp.Name, mp.Namespace)
+	}
+
+	return nil
+}
+
+// getNodeReferences returns a reference to each Node whose ProviderID is in the given list,
+// in the same order as the list. ProviderIDs without a matching Node are skipped.
+func (r *MachinePoolReconciler) getNodeReferences(client corev1.NodesGetter, providerIDList []string) ([]apicorev1.ObjectReference, []*apicorev1.Node, error) {
+	providerIDs := make([]*noderefutil.ProviderID, 0, len(providerIDList))
+	for _, id := range providerIDList {
+		providerID, err := noderefutil.NewProviderID(id)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid ProviderID %q", id)
+		}
+		providerIDs = append(providerIDs, providerID)
+	}
+
+	nodes := make([]*apicorev1.Node, len(providerIDs))
+	listOpt := metav1.ListOptions{}
+	for {
+		nodeList, err := client.Nodes().List(listOpt)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i := range nodeList.Items {
+			node := &nodeList.Items[i]
+			nodeProviderID, err := noderefutil.NewProviderID(node.Spec.ProviderID)
+			if err != nil {
+				klog.V(3).Infof("Failed to parse ProviderID for Node %q: %v", node.Name, err)
+				continue
+			}
+
+			for j, providerID := range providerIDs {
+				if providerID.Equals(nodeProviderID) {
+					nodes[j] = node
+					break
+				}
+			}
+		}
+
+		listOpt.Continue = nodeList.Continue
+		if listOpt.Continue == "" {
+			break
+		}
+	}
+
+	nodeRefs := []apicorev1.ObjectReference{}
+	matched := []*apicorev1.Node{}
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		nodeRefs = append(nodeRefs, apicorev1.ObjectReference{
+			Kind:       node.Kind,
+			APIVersion: node.APIVersion,
+			Name:       node.Name,
+			UID:        node.UID,
+		})
+		matched = append(matched, node)
+	}
+
+	return nodeRefs, matched, nil
+}
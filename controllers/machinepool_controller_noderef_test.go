@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ reconcile.Reconciler = &MachinePoolReconciler{}
+
+func TestMachinePoolReconcileNodeRefs(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+
+	newNode := func(name, providerID string, ready bool) runtime.Object {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour))},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		providerIDList     []string
+		nodes              []runtime.Object
+		expectRequeueAfter bool
+		expectNodeRefs     []string
+		expectReady        int32
+		expectUnavailable  int32
+	}{
+		{
+			name:           "no provider IDs yet",
+			nodes:          []runtime.Object{newNode("node-1", "aws:///us-east-1a/i-1", true)},
+			expectNodeRefs: nil,
+		},
+		{
+			name:           "all provider IDs match a node",
+			providerIDList: []string{"aws:///us-east-1a/i-2", "aws:///us-east-1a/i-1"},
+			nodes: []runtime.Object{
+				newNode("node-1", "aws:///us-east-1a/i-1", true),
+				newNode("node-2", "aws:///us-east-1a/i-2", false),
+				newNode("node-3", "aws:///us-east-1a/i-3", true),
+			},
+			expectNodeRefs:    []string{"node-2", "node-1"},
+			expectReady:       1,
+			expectUnavailable: 1,
+		},
+		{
+			name:           "a node hasn't joined yet",
+			providerIDList: []string{"aws:///us-east-1a/i-1", "aws:///us-east-1a/i-2"},
+			nodes: []runtime.Object{
+				newNode("node-1", "aws:///us-east-1a/i-1", true),
+			},
+			expectRequeueAfter: true,
+			expectNodeRefs:     []string{"node-1"},
+			expectReady:        1,
+			expectUnavailable:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			mp := &clusterv1.MachinePool{
+				ObjectMeta: metav1.ObjectMeta{Name: "machinepool-test", Namespace: "default"},
+				Spec: clusterv1.MachinePoolSpec{
+					Replicas:       pointer.Int32Ptr(2),
+					ProviderIDList: tc.providerIDList,
+				},
+			}
+
+			r := &MachinePoolReconciler{
+				Log:                log.Log,
+				recorder:           record.NewFakeRecorder(32),
+				remoteClientGetter: fakeClusterClientGetter(fakeclient.NewSimpleClientset(tc.nodes...).CoreV1()),
+			}
+
+			err := r.reconcileNodeRefs(context.Background(), cluster, mp)
+			if tc.expectRequeueAfter {
+				g.Expect(err).To(gomega.HaveOccurred())
+				_, ok := errors.Cause(err).(capierrors.HasRequeueAfterError)
+				g.Expect(ok).To(gomega.BeTrue())
+			} else {
+				g.Expect(err).ToNot(gomega.HaveOccurred())
+			}
+
+			var names []string
+			for _, ref := range mp.Status.NodeRefs {
+				names = append(names, ref.Name)
+			}
+			g.Expect(names).To(gomega.Equal(tc.expectNodeRefs))
+			g.Expect(mp.Status.ReadyReplicas).To(gomega.Equal(tc.expectReady))
+			g.Expect(mp.Status.UnavailableReplicas).To(gomega.Equal(tc.expectUnavailable))
+		})
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+var (
+	// machinePoolKind contains the schema.GroupVersionKind for the MachinePool type.
+	machinePoolKind = clusterv1.GroupVersion.WithKind("MachinePool")
+)
+
+// machinePoolReplicas returns the desired number of replicas of a MachinePool, which defaults to one.
+func machinePoolReplicas(mp *clusterv1.MachinePool) int32 {
+	if mp.Spec.Replicas == nil {
+		return 1
+	}
+	return *mp.Spec.Replicas
+}
+
+func (r *MachinePoolReconciler) reconcilePhase(mp *clusterv1.MachinePool) {
+	// Set the phase to "pending" if nil.
+	if mp.Status.Phase == "" {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhasePending)
+	}
+
+	// Set the phase to "provisioning" if bootstrap is ready and the infrastructure isn't.
+	if mp.Status.BootstrapReady && !mp.Status.InfrastructureReady {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseProvisioning)
+	}
+
+	// Set the phase to "provisioned" if the infrastructure is ready.
+	if mp.Status.InfrastructureReady {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseProvisioned)
+	}
+
+	// Set the phase to "running" if there is a NodeRef for every desired replica.
+	if mp.Status.InfrastructureReady && len(mp.Status.NodeRefs) == int(machinePoolReplicas(mp)) {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseRunning)
+	}
+
+	// Set the phase to "failed" if any of Status.ErrorReason or Status.ErrorMessage is not-nil.
+	if mp.Status.ErrorReason != nil || mp.Status.ErrorMessage != nil {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseFailed)
+	}
+
+	// Set the phase to "deleting" if the deletion timestamp is set.
+	if !mp.DeletionTimestamp.IsZero() {
+		mp.Status.SetTypedPhase(clusterv1.MachinePoolPhaseDeleting)
+	}
+}
+
+// reconcileReadyCondition summarizes the conditions owned by the MachinePool controller into the Ready condition.
+func (r *MachinePoolReconciler) reconcileReadyCondition(mp *clusterv1.MachinePool) {
+	conditions.SetSummary(mp,
+		clusterv1.BootstrapReadyCondition,
+		clusterv1.InfrastructureReadyCondition,
+	)
+}
+
+// reconcileExternal handles generic unstructured objects referenced by a MachinePool.
+// Failures to retrieve or adopt the object are reported on the condition of the given type.
+func (r *MachinePoolReconciler) reconcileExternal(ctx context.Context, mp *clusterv1.MachinePool, ref *corev1.ObjectReference, t clusterv1.ConditionType) (_ *unstructured.Unstructured, reterr error) {
+	defer func() {
+		if reterr != nil {
+			metrics.RecordExternalObjectReconcileError(ref.GroupVersionKind())
+		}
+	}()
+
+	obj, err := external.Get(r.Client, ref, mp.Namespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(mp, t, clusterv1.ExternalObjectNotFoundReason, clusterv1.ConditionSeverityWarning,
+				"%s %q not found", ref.Kind, ref.Name)
+			return nil, errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+				"could not find %v %q for MachinePool %q in namespace %q, requeuing",
+				ref.GroupVersionKind(), ref.Name, mp.Name, mp.Namespace)
+		}
+		conditions.MarkFalse(mp, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+		return nil, err
+	}
+
+	objPatch := client.MergeFrom(obj.DeepCopy())
+
+	// Set external object OwnerReference to the MachinePool.
+	ownerRef := metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "MachinePool",
+		Name:       mp.Name,
+		UID:        mp.UID,
+	}
+
+	// Also set the watch label of the external object, its events are filtered out otherwise.
+	if !util.HasOwnerRef(obj.GetOwnerReferences(), ownerRef) || !util.HasWatchLabel(obj, r.WatchFilterValue) {
+		obj.SetOwnerReferences(util.EnsureOwnerRef(obj.GetOwnerReferences(), ownerRef))
+		util.SetWatchLabel(obj, r.WatchFilterValue)
+		if err := r.Patch(ctx, obj, objPatch); err != nil {
+			conditions.MarkFalse(mp, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+			return nil, errors.Wrapf(err,
+				"failed to set OwnerReference on %v %q for MachinePool %q in namespace %q",
+				obj.GroupVersionKind(), ref.Name, mp.Name, mp.Namespace)
+		}
+	}
+
+	// Add watcher for external object, if there isn't one already.
+	_, loaded := r.externalWatchers.LoadOrStore(obj.GroupVersionKind().String(), struct{}{})
+	if !loaded && r.controller != nil {
+		klog.Infof("Adding watcher on external object %q", obj.GroupVersionKind())
+		err := r.controller.Watch(
+			&source.Kind{Type: obj},
+			&handler.EnqueueRequestForOwner{OwnerType: &clusterv1.MachinePool{}},
+			predicates.ResourceHasFilterLabel(r.WatchFilterValue),
+		)
+		if err != nil {
+			r.externalWatchers.Delete(obj.GroupVersionKind().String())
+			return nil, errors.Wrapf(err, "failed to add watcher on external object %q", obj.GroupVersionKind())
+		}
+	}
+
+	// Set error reason and message, if any.
+	errorReason, errorMessage, err := external.ErrorsFrom(obj)
+	if err != nil {
+		conditions.MarkFalse(mp, t, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+		return nil, err
+	}
+	if errorReason != "" {
+		mp.Status.ErrorReason = capierrors.MachinePoolStatusErrorPtr(capierrors.MachinePoolStatusError(errorReason))
+	}
+	if errorMessage != "" {
+		mp.Status.ErrorMessage = pointer.StringPtr(errorMessage)
+	}
+
+	return obj, nil
+}
+
+// reconcileBootstrap reconciles the Spec.Template.Spec.Bootstrap.ConfigRef object on a MachinePool.
+// The bootstrap data is shared by every instance of the pool.
+func (r *MachinePoolReconciler) reconcileBootstrap(ctx context.Context, mp *clusterv1.MachinePool) error {
+	bootstrap := &mp.Spec.Template.Spec.Bootstrap
+	if bootstrap.ConfigRef == nil && bootstrap.Data == nil && bootstrap.DataSecretName == nil {
+		conditions.MarkFalse(mp, clusterv1.BootstrapReadyCondition, clusterv1.InvalidBootstrapConfigurationReason, clusterv1.ConditionSeverityError,
+			"Expected at least one of Bootstrap.ConfigRef, Bootstrap.Data or Bootstrap.DataSecretName to be populated")
+		return errors.Errorf(
+			"Expected at least one of `Bootstrap.ConfigRef`, `Bootstrap.Data` or `Bootstrap.DataSecretName` to be populated for MachinePool %q in namespace %q",
+			mp.Name, mp.Namespace,
+		)
+	}
+
+	// Move the deprecated inline bootstrap data to a Secret.
+	if err := moveBootstrapDataToSecret(ctx, r.Client, mp, machinePoolKind, bootstrap); err != nil {
+		return err
+	}
+
+	// Call generic external reconciler if we have an external reference.
+	var bootstrapConfig *unstructured.Unstructured
+	if bootstrap.ConfigRef != nil {
+		var err error
+		bootstrapConfig, err = r.reconcileExternal(ctx, mp, bootstrap.ConfigRef, clusterv1.BootstrapReadyCondition)
+		if err != nil {
+			return err
+		}
+	}
+
+	// If the bootstrap data secret is populated, set ready and return.
+	if bootstrap.DataSecretName != nil {
+		mp.Status.BootstrapReady = true
+		conditions.MarkTrue(mp, clusterv1.BootstrapReadyCondition)
+		return nil
+	}
+
+	// If the bootstrap config is being deleted, return early.
+	if !bootstrapConfig.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	// Determine if the bootstrap provider is ready.
+	ready, err := external.IsReady(bootstrapConfig)
+	if err != nil {
+		conditions.MarkFalse(mp, clusterv1.BootstrapReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+		return err
+	} else if !ready {
+		if !conditions.SetMirror(mp, clusterv1.BootstrapReadyCondition, conditions.UnstructuredGetter(bootstrapConfig)) {
+			conditions.MarkFalse(mp, clusterv1.BootstrapReadyCondition, clusterv1.WaitingForBootstrapDataReason, clusterv1.ConditionSeverityInfo,
+				"Waiting for %s %q to generate the bootstrap data", bootstrapConfig.GetKind(), bootstrapConfig.GetName())
+		}
+		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+			"Bootstrap provider for MachinePool %q in namespace %q is not ready, requeuing", mp.Name, mp.Namespace)
+	}
+
+	// Get and set the name of the secret containing the bootstrap data.
+	secretName, err := bootstrapDataSecretFromConfig(ctx, r.Client, mp, machinePoolKind, bootstrapConfig)
+	if err != nil {
+		conditions.MarkFalse(mp, clusterv1.BootstrapReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+		return err
+	}
+
+	bootstrap.DataSecretName = pointer.StringPtr(secretName)
+	mp.Status.BootstrapReady = true
+	conditions.MarkTrue(mp, clusterv1.BootstrapReadyCondition)
+	return nil
+}
+
+// reconcileInfrastructure reconciles the Spec.Template.Spec.InfrastructureRef object on a MachinePool.
+// The infrastructure provider reads the desired replicas from the owning MachinePool and reports back
+// the list of provider IDs in spec.providerIDList and the observed replicas in status.replicas.
+func (r *MachinePoolReconciler) reconcileInfrastructure(ctx context.Context, mp *clusterv1.MachinePool) error {
+	// Call generic external reconciler.
+	infraConfig, err := r.reconcileExternal(ctx, mp, &mp.Spec.Template.Spec.InfrastructureRef, clusterv1.InfrastructureReadyCondition)
+	if infraConfig == nil && err == nil {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if !infraConfig.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	// Determine if the infrastructure provider is ready.
+	ready, err := external.IsReady(infraConfig)
+	if err != nil {
+		conditions.MarkFalse(mp, clusterv1.InfrastructureReadyCondition, clusterv1.ExternalObjectErrorReason, clusterv1.ConditionSeverityError, "%v", err)
+		return err
+	}
+	mp.Status.InfrastructureReady = ready
+	if !ready {
+		if !conditions.SetMirror(mp, clusterv1.InfrastructureReadyCondition, conditions.UnstructuredGetter(infraConfig)) {
+			conditions.MarkFalse(mp, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureReason, clusterv1.ConditionSeverityInfo,
+				"Waiting for %s %q to be ready", infraConfig.GetKind(), infraConfig.GetName())
+		}
+		return errors.Wrapf(&capierrors.RequeueAfterError{RequeueAfter: externalReadyWait},
+			"Infrastructure provider for MachinePool %q in namespace %q is not ready, requeuing", mp.Name, mp.Namespace,
+		)
+	}
+	conditions.MarkTrue(mp, clusterv1.InfrastructureReadyCondition)
+
+	// Get Spec.ProviderIDList from the infrastructure provider.
+	var providerIDList []string
+	if err := util.UnstructuredUnmarshalField(infraConfig, &providerIDList, "spec", "providerIDList"); err != nil && err != util.ErrUnstructuredFieldNotFound {
+		return errors.Wrapf(err, "failed to retrieve providerIDList from infrastructure provider for MachinePool %q in namespace %q", mp.Name, mp.Namespace)
+	}
+
+	// Get Status.Replicas from the infrastructure provider.
+	var replicas int32
+	if err := util.UnstructuredUnmarshalField(infraConfig, &replicas, "status", "replicas"); err != nil && err != util.ErrUnstructuredFieldNotFound {
+		return errors.Wrapf(err, "failed to retrieve replicas from infrastructure provider for MachinePool %q in namespace %q", mp.Name, mp.Namespace)
+	}
+
+	mp.Spec.ProviderIDList = providerIDList
+	mp.Status.Replicas = replicas
+	return nil
+}
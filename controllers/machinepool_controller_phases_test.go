@@ -0,0 +1,229 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMachinePoolReconcilePhase(t *testing.T) {
+	deletionTimestamp := metav1.Now()
+
+	testCases := []struct {
+		name          string
+		unsetReplicas bool
+		status        clusterv1.MachinePoolStatus
+		deleted       bool
+		expected      clusterv1.MachinePoolPhase
+	}{
+		{
+			name:     "new machine pool",
+			expected: clusterv1.MachinePoolPhasePending,
+		},
+		{
+			name:     "bootstrap ready",
+			status:   clusterv1.MachinePoolStatus{BootstrapReady: true},
+			expected: clusterv1.MachinePoolPhaseProvisioning,
+		},
+		{
+			name:     "infrastructure ready, missing nodes",
+			status:   clusterv1.MachinePoolStatus{BootstrapReady: true, InfrastructureReady: true, NodeRefs: []corev1.ObjectReference{{Name: "node-1"}}},
+			expected: clusterv1.MachinePoolPhaseProvisioned,
+		},
+		{
+			name: "a node for every replica",
+			status: clusterv1.MachinePoolStatus{BootstrapReady: true, InfrastructureReady: true,
+				NodeRefs: []corev1.ObjectReference{{Name: "node-1"}, {Name: "node-2"}}},
+			expected: clusterv1.MachinePoolPhaseRunning,
+		},
+		{
+			name:          "replicas not set, a single node",
+			unsetReplicas: true,
+			status:        clusterv1.MachinePoolStatus{BootstrapReady: true, InfrastructureReady: true, NodeRefs: []corev1.ObjectReference{{Name: "node-1"}}},
+			expected:      clusterv1.MachinePoolPhaseRunning,
+		},
+		{
+			name:     "error reported by the provider",
+			status:   clusterv1.MachinePoolStatus{ErrorReason: capierrors.MachinePoolStatusErrorPtr(capierrors.CreateMachinePoolError)},
+			expected: clusterv1.MachinePoolPhaseFailed,
+		},
+		{
+			name:     "deleted",
+			status:   clusterv1.MachinePoolStatus{BootstrapReady: true, InfrastructureReady: true},
+			deleted:  true,
+			expected: clusterv1.MachinePoolPhaseDeleting,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			mp := &clusterv1.MachinePool{
+				Spec:   clusterv1.MachinePoolSpec{Replicas: pointer.Int32Ptr(2)},
+				Status: tc.status,
+			}
+			if tc.unsetReplicas {
+				mp.Spec.Replicas = nil
+			}
+			if tc.deleted {
+				mp.DeletionTimestamp = &deletionTimestamp
+			}
+
+			r := &MachinePoolReconciler{}
+			r.reconcilePhase(mp)
+			g.Expect(mp.Status.GetTypedPhase()).To(gomega.Equal(tc.expected))
+		})
+	}
+}
+
+func TestMachinePoolReconcileInfrastructure(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	defaultMachinePool := clusterv1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machinepool-test",
+			Namespace: "default",
+		},
+		Spec: clusterv1.MachinePoolSpec{
+			Replicas: pointer.Int32Ptr(2),
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					InfrastructureRef: corev1.ObjectReference{
+						APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha2",
+						Kind:       "InfrastructureConfig",
+						Name:       "infra-config1",
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		infraConfig        map[string]interface{}
+		expectRequeueAfter bool
+		expected           func(g *gomega.WithT, mp *clusterv1.MachinePool)
+	}{
+		{
+			name: "infrastructure not ready",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec":   map[string]interface{}{},
+				"status": map[string]interface{}{},
+			},
+			expectRequeueAfter: true,
+			expected: func(g *gomega.WithT, mp *clusterv1.MachinePool) {
+				g.Expect(mp.Status.InfrastructureReady).To(gomega.BeFalse())
+				g.Expect(conditions.GetReason(mp, clusterv1.InfrastructureReadyCondition)).To(gomega.Equal(clusterv1.WaitingForInfrastructureReason))
+			},
+		},
+		{
+			name: "infrastructure ready, reports provider IDs and replicas",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{
+					"providerIDList": []interface{}{
+						"aws:///us-east-1a/i-1",
+						"aws:///us-east-1a/i-2",
+					},
+				},
+				"status": map[string]interface{}{
+					"ready":    true,
+					"replicas": int64(2),
+				},
+			},
+			expected: func(g *gomega.WithT, mp *clusterv1.MachinePool) {
+				g.Expect(mp.Status.InfrastructureReady).To(gomega.BeTrue())
+				g.Expect(conditions.IsTrue(mp, clusterv1.InfrastructureReadyCondition)).To(gomega.BeTrue())
+				g.Expect(mp.Spec.ProviderIDList).To(gomega.Equal([]string{"aws:///us-east-1a/i-1", "aws:///us-east-1a/i-2"}))
+				g.Expect(mp.Status.Replicas).To(gomega.BeEquivalentTo(2))
+			},
+		},
+		{
+			name: "infrastructure is reporting an error",
+			infraConfig: map[string]interface{}{
+				"kind":       "InfrastructureConfig",
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha2",
+				"metadata": map[string]interface{}{
+					"name":      "infra-config1",
+					"namespace": "default",
+				},
+				"spec": map[string]interface{}{},
+				"status": map[string]interface{}{
+					"errorReason":  "CreateError",
+					"errorMessage": "failed to create the autoscaling group",
+				},
+			},
+			expectRequeueAfter: true,
+			expected: func(g *gomega.WithT, mp *clusterv1.MachinePool) {
+				g.Expect(mp.Status.ErrorReason).ToNot(gomega.BeNil())
+				g.Expect(*mp.Status.ErrorReason).To(gomega.Equal(capierrors.CreateMachinePoolError))
+				g.Expect(mp.Status.ErrorMessage).To(gomega.Equal(pointer.StringPtr("failed to create the autoscaling group")))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+
+			mp := defaultMachinePool.DeepCopy()
+			infraConfig := &unstructured.Unstructured{Object: tc.infraConfig}
+
+			r := &MachinePoolReconciler{
+				Client: fake.NewFakeClient(mp, infraConfig),
+				Log:    log.Log,
+			}
+
+			err := r.reconcileInfrastructure(context.Background(), mp)
+			if tc.expectRequeueAfter {
+				g.Expect(err).To(gomega.HaveOccurred())
+				_, ok := errors.Cause(err).(capierrors.HasRequeueAfterError)
+				g.Expect(ok).To(gomega.BeTrue())
+			} else {
+				g.Expect(err).ToNot(gomega.HaveOccurred())
+			}
+
+			tc.expected(g, mp)
+		})
+	}
+}
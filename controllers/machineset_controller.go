@@ -19,7 +19,6 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
@@ -30,15 +29,23 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog"
+	"k8s.io/utils/integer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
@@ -46,12 +53,18 @@ var (
 	// controllerKind contains the schema.GroupVersionKind for this controller type.
 	controllerKind = clusterv1.GroupVersion.WithKind("MachineSet")
 
-	// stateConfirmationTimeout is the amount of time allowed to wait for desired state.
-	stateConfirmationTimeout = 10 * time.Second
+	// slowStartInitialBatchSize is the size of the first batch of Machines created by a reconciliation,
+	// the following batches double in size as long as the creations succeed.
+	slowStartInitialBatchSize = 1
 
-	// stateConfirmationInterval is the amount of time between polling for the desired state.
-	// The polling is against a local memory cache.
-	stateConfirmationInterval = 100 * time.Millisecond
+	// creationBackoffInitial and creationBackoffMax bound the exponential back-off applied to a MachineSet
+	// after it fails to create a Machine.
+	creationBackoffInitial = 10 * time.Second
+	creationBackoffMax     = 5 * time.Minute
+
+	// burstReplicas is the maximum number of Machines created or deleted by a single reconciliation,
+	// the next reconciliations take care of the rest once the expectations are satisfied.
+	burstReplicas = 100
 )
 
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
@@ -65,24 +78,87 @@ type MachineSetReconciler struct {
 	client.Client
 	Log logr.Logger
 
-	recorder record.EventRecorder
+	// Tracker caches the clients of workload clusters. If unset, a new client is created on every request.
+	Tracker *remote.ClusterCacheTracker
+
+	// WatchFilterValue is the value of the watch label of the objects to reconcile. Every object is reconciled if empty.
+	WatchFilterValue string
+
+	// DeletePolicies holds the delete policies that MachineSets can use, defaults to the built-in ones.
+	DeletePolicies *DeletePolicyRegistry
+
+	controller controller.Controller
+	recorder   record.EventRecorder
+
+	// expectations tracks the Machines created and deleted by each MachineSet until the cache observes them.
+	expectations *machineExpectations
+
+	// creationBackoff delays the creation of Machines by the MachineSets that failed to create some, keyed by UID.
+	creationBackoff *flowcontrol.Backoff
+
+	// lastCreationFailures holds the time of the last creation failure of the MachineSets, keyed by UID.
+	lastCreationFailures     map[string]time.Time
+	lastCreationFailuresLock sync.Mutex
+
+	// remoteClientGetter returns a client for the workload cluster, defaults to the Tracker if set
+	// or remote.NewClusterClient otherwise.
+	remoteClientGetter remote.ClusterClientGetter
 }
 
 func (r *MachineSetReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
-	err := ctrl.NewControllerManagedBy(mgr).
+	r.expectations = newMachineExpectations()
+	r.creationBackoff = flowcontrol.NewBackOff(creationBackoffInitial, creationBackoffMax)
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1.MachineSet{}).
-		Owns(&clusterv1.Machine{}).
+		Watches(
+			&source.Kind{Type: &clusterv1.Machine{}},
+			newMachineExpectationsHandler(r.expectations),
+		).
 		Watches(
 			&source.Kind{Type: &clusterv1.Machine{}},
 			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.MachineToMachineSets)},
 		).
 		WithOptions(options).
-		Complete(r)
+		WithEventFilter(predicates.ResourceHasFilterLabel(r.WatchFilterValue)).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	// Reconcile the MachineSets of a Cluster as soon as it's unpaused.
+	err = c.Watch(
+		&source.Kind{Type: &clusterv1.Cluster{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: util.ClusterToObjectsMapFunc(r.Client, &clusterv1.MachineSetList{})},
+		predicates.ClusterUnpaused(),
+		predicates.ResourceHasFilterLabel(r.WatchFilterValue),
+	)
+
+	if err != nil {
+		return err
+	}
 
+	// Forget the back-off of the MachineSets which haven't failed for a while.
+	err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		wait.Until(r.gcCreationBackoff, creationBackoffMax, stop)
+		return nil
+	}))
+
+	r.controller = c
 	r.recorder = mgr.GetEventRecorderFor("machineset-controller")
 	return err
 }
 
+// clusterClientGetter returns the function used to create clients for workload clusters.
+func (r *MachineSetReconciler) clusterClientGetter() remote.ClusterClientGetter {
+	if r.remoteClientGetter != nil {
+		return r.remoteClientGetter
+	}
+	if r.Tracker != nil {
+		return r.Tracker.ClusterClient
+	}
+	return remote.NewClusterClient
+}
+
 func (r *MachineSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	_ = r.Log.WithValues("machineset", req.NamespacedName)
@@ -92,19 +168,29 @@ func (r *MachineSetReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		if apierrors.IsNotFound(err) {
 			// Object not found, return. Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			metrics.DeleteMachineSetReplicas(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		return ctrl.Result{}, err
 	}
 
+	// Ignore the MachineSets without the watch label of this manager, they can still be enqueued by other watches.
+	if !util.HasWatchLabel(machineSet, r.WatchFilterValue) {
+		return ctrl.Result{}, nil
+	}
+
 	// Ignore deleted MachineSets, this can happen when foregroundDeletion
 	// is enabled
 	if machineSet.DeletionTimestamp != nil {
+		r.expectations.delete(machineSet.UID)
+		r.resetCreationBackoff(machineSet)
+		metrics.DeleteMachineSetReplicas(machineSet.Namespace, machineSet.Name)
 		return ctrl.Result{}, nil
 	}
 
 	result, err := r.reconcile(ctx, machineSet)
+	metrics.SetMachineSetReplicas(machineSet)
 	if err != nil {
 		klog.Errorf("Failed to reconcile MachineSet %q: %v", req.NamespacedName, err)
 		r.recorder.Eventf(machineSet, corev1.EventTypeWarning, "ReconcileError", "%v", err)
@@ -116,7 +202,7 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, machineSet *cluste
 	klog.V(4).Infof("Reconcile MachineSet %q in namespace %q", machineSet.Name, machineSet.Namespace)
 
 	// Make sure that label selector can match template's labels.
-	// TODO(vincepri): Move to a validation (admission) webhook when supported.
+	// This is enforced by the validation webhook, but MachineSets could have been created without it.
 	selector, err := metav1.LabelSelectorAsSelector(&machineSet.Spec.Selector)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrapf(err, "failed to parse MachineSet %q label selector", machineSet.Name)
@@ -155,6 +241,23 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, machineSet *cluste
 		return ctrl.Result{}, err
 	}
 
+	// Return early if the MachineSet or its Cluster is paused.
+	if util.IsPaused(cluster, machineSet) {
+		klog.Infof("Reconciliation is paused for MachineSet %q in namespace %q", machineSet.Name, machineSet.Namespace)
+		return ctrl.Result{}, nil
+	}
+
+	// Watch the Nodes of the workload cluster, so that the status is updated as soon as the Nodes become ready.
+	// Scaling doesn't depend on the workload cluster, fall back to polling if the watch can't be established.
+	watchingNodes := false
+	if r.shouldWatchNodes(cluster) {
+		if err := r.watchClusterNodes(cluster); err != nil {
+			klog.Warningf("Failed to watch Nodes for MachineSet %q in namespace %q: %v", machineSet.Name, machineSet.Namespace, err)
+		} else {
+			watchingNodes = true
+		}
+	}
+
 	if cluster != nil && r.shouldAdopt(machineSet) {
 		machineSet.OwnerReferences = util.EnsureOwnerRef(machineSet.OwnerReferences, metav1.OwnerReference{
 			APIVersion: cluster.APIVersion,
@@ -186,9 +289,19 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, machineSet *cluste
 		filteredMachines = append(filteredMachines, machine)
 	}
 
-	syncErr := r.syncReplicas(machineSet, filteredMachines)
-
+	// Don't scale until the Machines created or deleted by the previous reconciliations are in the cache,
+	// the MachineSet would be scaled again from a stale list of Machines otherwise.
+	// The status is calculated from a copy of the MachineSet, syncReplicas sets the MachinesCreated condition on it.
 	ms := machineSet.DeepCopy()
+	var syncErr error
+	expectationsSatisfied := r.expectations.satisfied(machineSet.UID)
+	if expectationsSatisfied {
+		syncErr = r.syncReplicas(cluster, ms, filteredMachines)
+	} else {
+		klog.V(4).Infof("Waiting for the Machines of MachineSet %q in namespace %q to be created or deleted",
+			machineSet.Name, machineSet.Namespace)
+	}
+
 	newStatus := r.calculateStatus(ms, filteredMachines)
 
 	// Always updates status as machines come up or die.
@@ -223,96 +336,94 @@ func (r *MachineSetReconciler) reconcile(ctx context.Context, machineSet *cluste
 		return ctrl.Result{RequeueAfter: time.Duration(updatedMS.Spec.MinReadySeconds) * time.Second}, nil
 	}
 
-	// Quickly rereconcile until the nodes become Ready.
-	if updatedMS.Status.ReadyReplicas != replicas {
+	// Quickly rereconcile until the nodes become Ready, unless the Nodes are watched.
+	if updatedMS.Status.ReadyReplicas != replicas && !watchingNodes {
 		klog.V(4).Info("Some nodes are not ready yet, requeuing until they are ready")
 		return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
 	}
 
+	// The Machine events resync the MachineSet, make sure it's synced once the expectations expire if some are missed.
+	if !expectationsSatisfied {
+		return ctrl.Result{RequeueAfter: expectationsTimeout}, nil
+	}
+
+	// Resume creating Machines once the back-off after a creation failure expires.
+	if delay := r.creationBackoffDelay(updatedMS); delay > 0 && updatedMS.Status.Replicas < replicas {
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
 // syncReplicas scales Machine resources up or down.
-func (r *MachineSetReconciler) syncReplicas(ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
+// When the Cluster reports failure domains, new Machines are spread across them.
+func (r *MachineSetReconciler) syncReplicas(cluster *clusterv1.Cluster, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) error {
 	if ms.Spec.Replicas == nil {
 		return errors.Errorf("the Replicas field in Spec for machineset %v is nil, this should not be allowed", ms.Name)
 	}
 
 	diff := len(machines) - int(*(ms.Spec.Replicas))
 
+	// The MachineSet doesn't need to create Machines anymore, the previous failures don't matter.
+	if diff >= 0 && conditions.IsFalse(ms, clusterv1.MachinesCreatedCondition) {
+		conditions.MarkTrue(ms, clusterv1.MachinesCreatedCondition)
+	}
+
 	if diff < 0 {
 		diff *= -1
+		if diff > burstReplicas {
+			diff = burstReplicas
+		}
+		if backoff := r.creationBackoffDelay(ms); backoff > 0 {
+			klog.V(2).Infof("Backing off creating Machines for MachineSet %q in namespace %q for %v after a failure",
+				ms.Name, ms.Namespace, backoff)
+			return nil
+		}
 		klog.Infof("Too few replicas for %v %s/%s, need %d, creating %d",
 			controllerKind, ms.Namespace, ms.Name, *(ms.Spec.Replicas), diff)
 
-		var machineList []*clusterv1.Machine
-		var errstrings []string
-		for i := 0; i < diff; i++ {
-			klog.Infof("Creating machine %d of %d, ( spec.replicas(%d) > currentMachineCount(%d) )",
-				i+1, diff, *(ms.Spec.Replicas), len(machines))
+		// Expect the creations before creating the Machines, their events could be observed before Create returns.
+		r.expectations.expectCreations(ms.UID, diff)
 
+		// Create the Machines in batches of increasing size, stopping at the first failure, so that
+		// a broken template doesn't produce a large number of failed Machines and orphaned clones.
+		var placedLock sync.Mutex
+		// placed tracks the Machines considered when spreading the new ones across failure domains.
+		placed := append([]*clusterv1.Machine{}, machines...)
+		created, err := slowStartBatch(diff, slowStartInitialBatchSize, func() error {
 			machine := r.getNewMachine(ms)
 
-			// Clone and set the infrastructure and bootstrap references.
-			var (
-				infraConfig, bootstrapConfig *unstructured.Unstructured
-				err                          error
-			)
-
-			infraConfig, err = external.CloneTemplate(r.Client, &machine.Spec.InfrastructureRef, machine.Namespace)
-			if err != nil {
-				return errors.Wrapf(err, "failed to clone infrastructure configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
-			}
-			machine.Spec.InfrastructureRef = corev1.ObjectReference{
-				APIVersion: infraConfig.GetAPIVersion(),
-				Kind:       infraConfig.GetKind(),
-				Namespace:  infraConfig.GetNamespace(),
-				Name:       infraConfig.GetName(),
-			}
-
-			if machine.Spec.Bootstrap.ConfigRef != nil {
-				bootstrapConfig, err = external.CloneTemplate(r.Client, machine.Spec.Bootstrap.ConfigRef, machine.Namespace)
-				if err != nil {
-					return errors.Wrapf(err, "failed to clone bootstrap configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
-				}
-				machine.Spec.Bootstrap.ConfigRef = &corev1.ObjectReference{
-					APIVersion: bootstrapConfig.GetAPIVersion(),
-					Kind:       bootstrapConfig.GetKind(),
-					Namespace:  bootstrapConfig.GetNamespace(),
-					Name:       bootstrapConfig.GetName(),
-				}
+			// Place the Machine in the least populated failure domain, unless the template already picked one.
+			placedLock.Lock()
+			if machine.Spec.FailureDomain == nil && cluster != nil {
+				machine.Spec.FailureDomain = pickFailureDomain(cluster.Status.FailureDomains, placed)
 			}
+			placed = append(placed, machine)
+			placedLock.Unlock()
 
-			if err := r.Client.Create(context.TODO(), machine); err != nil {
-				klog.Errorf("Unable to create Machine %q: %v", machine.Name, err)
-				r.recorder.Eventf(ms, corev1.EventTypeWarning, "FailedCreate", "Failed to create machine %q: %v", machine.Name, err)
-				errstrings = append(errstrings, err.Error())
-				if err := r.Client.Delete(context.TODO(), infraConfig); !apierrors.IsNotFound(err) {
-					klog.Errorf("Failed to cleanup infrastructure configuration object after Machine creation error: %v", err)
-				}
-				if bootstrapConfig != nil {
-					if err := r.Client.Delete(context.TODO(), bootstrapConfig); !apierrors.IsNotFound(err) {
-						klog.Errorf("Failed to cleanup bootstrap configuration object after Machine creation error: %v", err)
-					}
-				}
-				continue
-			}
-			klog.Infof("Created machine %d of %d with name %q", i+1, diff, machine.Name)
-			r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulCreate", "Created machine %q", machine.Name)
+			return r.createMachine(ms, machine)
+		})
 
-			machineList = append(machineList, machine)
-		}
+		// The Machines that failed or that haven't been attempted won't be observed.
+		r.lowerCreationExpectations(ms, diff-created)
 
-		if len(errstrings) > 0 {
-			return errors.New(strings.Join(errstrings, "; "))
+		if err != nil {
+			r.backOffCreation(ms)
+			conditions.MarkFalse(ms, clusterv1.MachinesCreatedCondition, clusterv1.MachineCreationFailedReason, clusterv1.ConditionSeverityError,
+				"Failed to create %d of %d Machines: %v", diff-created, diff, err)
+			return err
 		}
-
-		return r.waitForMachineCreation(machineList)
+		r.resetCreationBackoff(ms)
+		conditions.MarkTrue(ms, clusterv1.MachinesCreatedCondition)
+		return nil
 	} else if diff > 0 {
+		if diff > burstReplicas {
+			diff = burstReplicas
+		}
 		klog.Infof("Too many replicas for %v %s/%s, need %d, deleting %d",
 			controllerKind, ms.Namespace, ms.Name, *(ms.Spec.Replicas), diff)
 
-		deletePriorityFunc, err := getDeletePriorityFunc(ms)
+		deletePriorityFunc, err := r.getDeletePriorityFunc(context.TODO(), cluster, ms, machines)
 		if err != nil {
 			return err
 		}
@@ -320,7 +431,13 @@ func (r *MachineSetReconciler) syncReplicas(ms *clusterv1.MachineSet, machines [
 		// Choose which Machines to delete.
 		machinesToDelete := getMachinesToDeletePrioritized(machines, diff, deletePriorityFunc)
 
-		// TODO: Add cap to limit concurrent delete calls.
+		names := make([]string, 0, len(machinesToDelete))
+		for _, machine := range machinesToDelete {
+			names = append(names, machine.Name)
+		}
+		r.expectations.expectDeletions(ms.UID, names)
+
+		// The number of concurrent delete calls is capped by burstReplicas.
 		errCh := make(chan error, diff)
 		var wg sync.WaitGroup
 		wg.Add(diff)
@@ -328,10 +445,13 @@ func (r *MachineSetReconciler) syncReplicas(ms *clusterv1.MachineSet, machines [
 			go func(targetMachine *clusterv1.Machine) {
 				defer wg.Done()
 				err := r.Client.Delete(context.Background(), targetMachine)
-				if err != nil {
+				if err != nil && !apierrors.IsNotFound(err) {
+					// The deletion won't be observed.
+					r.expectations.deletionObserved(ms.UID, targetMachine.Name)
 					klog.Errorf("Unable to delete Machine %s: %v", targetMachine.Name, err)
 					r.recorder.Eventf(ms, corev1.EventTypeWarning, "FailedDelete", "Failed to delete machine %q: %v", targetMachine.Name, err)
 					errCh <- err
+					return
 				}
 				klog.Infof("Deleted machine %q", targetMachine.Name)
 				r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulDelete", "Deleted machine %q", targetMachine.Name)
@@ -347,34 +467,213 @@ func (r *MachineSetReconciler) syncReplicas(ms *clusterv1.MachineSet, machines [
 			}
 		default:
 		}
+		return nil
+	}
+
+	return nil
+}
+
+// createMachine clones the infrastructure and bootstrap templates of the Machine and creates it.
+// The clones are deleted if the Machine can't be created.
+func (r *MachineSetReconciler) createMachine(ms *clusterv1.MachineSet, machine *clusterv1.Machine) error {
+	var (
+		infraConfig, bootstrapConfig *unstructured.Unstructured
+		err                          error
+	)
+
+	infraConfig, err = external.CloneTemplate(r.Client, &machine.Spec.InfrastructureRef, machine.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to clone infrastructure configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
+	}
+	machine.Spec.InfrastructureRef = corev1.ObjectReference{
+		APIVersion: infraConfig.GetAPIVersion(),
+		Kind:       infraConfig.GetKind(),
+		Namespace:  infraConfig.GetNamespace(),
+		Name:       infraConfig.GetName(),
+	}
 
-		return r.waitForMachineDeletion(machinesToDelete)
+	if machine.Spec.Bootstrap.ConfigRef != nil {
+		bootstrapConfig, err = external.CloneTemplate(r.Client, machine.Spec.Bootstrap.ConfigRef, machine.Namespace)
+		if err != nil {
+			if err := r.Client.Delete(context.TODO(), infraConfig); err != nil && !apierrors.IsNotFound(err) {
+				klog.Errorf("Failed to cleanup infrastructure configuration object after bootstrap configuration cloning error: %v", err)
+			}
+			return errors.Wrapf(err, "failed to clone bootstrap configuration for MachineSet %q in namespace %q", ms.Name, ms.Namespace)
+		}
+		machine.Spec.Bootstrap.ConfigRef = &corev1.ObjectReference{
+			APIVersion: bootstrapConfig.GetAPIVersion(),
+			Kind:       bootstrapConfig.GetKind(),
+			Namespace:  bootstrapConfig.GetNamespace(),
+			Name:       bootstrapConfig.GetName(),
+		}
 	}
 
+	if err := r.Client.Create(context.TODO(), machine); err != nil {
+		klog.Errorf("Unable to create Machine %q: %v", machine.Name, err)
+		r.recorder.Eventf(ms, corev1.EventTypeWarning, "FailedCreate", "Failed to create machine %q: %v", machine.Name, err)
+		if err := r.Client.Delete(context.TODO(), infraConfig); !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to cleanup infrastructure configuration object after Machine creation error: %v", err)
+		}
+		if bootstrapConfig != nil {
+			if err := r.Client.Delete(context.TODO(), bootstrapConfig); !apierrors.IsNotFound(err) {
+				klog.Errorf("Failed to cleanup bootstrap configuration object after Machine creation error: %v", err)
+			}
+		}
+		return err
+	}
+	klog.Infof("Created machine %q for MachineSet %q in namespace %q", machine.Name, ms.Name, ms.Namespace)
+	r.recorder.Eventf(ms, corev1.EventTypeNormal, "SuccessfulCreate", "Created machine %q", machine.Name)
 	return nil
 }
 
+// slowStartBatch calls fn count times, in batches starting with initialBatchSize calls and doubling in size
+// after each batch. The calls of a batch run concurrently, and no more batches are started once a call fails.
+// It returns the number of successful calls and the first error.
+func slowStartBatch(count int, initialBatchSize int, fn func() error) (int, error) {
+	remaining := count
+	successes := 0
+	for batchSize := integer.IntMin(remaining, initialBatchSize); batchSize > 0; batchSize = integer.IntMin(2*batchSize, remaining) {
+		errCh := make(chan error, batchSize)
+		var wg sync.WaitGroup
+		wg.Add(batchSize)
+		for i := 0; i < batchSize; i++ {
+			go func() {
+				defer wg.Done()
+				if err := fn(); err != nil {
+					errCh <- err
+				}
+			}()
+		}
+		wg.Wait()
+		curSuccesses := batchSize - len(errCh)
+		successes += curSuccesses
+		if len(errCh) > 0 {
+			return successes, <-errCh
+		}
+		remaining -= batchSize
+	}
+	return successes, nil
+}
+
+// creationBackoffDelay returns how long the MachineSet must wait before creating Machines again,
+// or zero if it isn't backing off.
+func (r *MachineSetReconciler) creationBackoffDelay(ms *clusterv1.MachineSet) time.Duration {
+	if r.creationBackoff == nil {
+		return 0
+	}
+	id := string(ms.UID)
+	r.lastCreationFailuresLock.Lock()
+	lastFailure, ok := r.lastCreationFailures[id]
+	r.lastCreationFailuresLock.Unlock()
+	if !ok || !r.creationBackoff.IsInBackOffSince(id, lastFailure) {
+		return 0
+	}
+	return r.creationBackoff.Get(id) - r.creationBackoff.Clock.Since(lastFailure)
+}
+
+// backOffCreation extends the creation back-off of the MachineSet after a failure.
+func (r *MachineSetReconciler) backOffCreation(ms *clusterv1.MachineSet) {
+	if r.creationBackoff == nil {
+		return
+	}
+	id := string(ms.UID)
+	now := r.creationBackoff.Clock.Now()
+	r.creationBackoff.Next(id, now)
+
+	r.lastCreationFailuresLock.Lock()
+	defer r.lastCreationFailuresLock.Unlock()
+	if r.lastCreationFailures == nil {
+		r.lastCreationFailures = map[string]time.Time{}
+	}
+	r.lastCreationFailures[id] = now
+}
+
+// resetCreationBackoff forgets the creation back-off of the MachineSet.
+func (r *MachineSetReconciler) resetCreationBackoff(ms *clusterv1.MachineSet) {
+	if r.creationBackoff == nil {
+		return
+	}
+	id := string(ms.UID)
+	r.creationBackoff.Reset(id)
+
+	r.lastCreationFailuresLock.Lock()
+	defer r.lastCreationFailuresLock.Unlock()
+	delete(r.lastCreationFailures, id)
+}
+
+// gcCreationBackoff forgets the creation back-off of the MachineSets which haven't failed
+// to create Machines for twice the maximum back-off.
+func (r *MachineSetReconciler) gcCreationBackoff() {
+	if r.creationBackoff == nil {
+		return
+	}
+	r.creationBackoff.GC()
+
+	r.lastCreationFailuresLock.Lock()
+	defer r.lastCreationFailuresLock.Unlock()
+	for id := range r.lastCreationFailures {
+		if r.creationBackoff.Get(id) == 0 {
+			delete(r.lastCreationFailures, id)
+		}
+	}
+}
+
+// lowerCreationExpectations lowers the creation expectations of the MachineSet for the Machines that
+// won't be created, since their creation won't be observed.
+func (r *MachineSetReconciler) lowerCreationExpectations(ms *clusterv1.MachineSet, count int) {
+	for i := 0; i < count; i++ {
+		r.expectations.creationObserved(ms.UID)
+	}
+}
+
 // getNewMachine creates a new Machine object. The name of the newly created resource is going
 // to be created by the API server, we set the generateName field.
 func (r *MachineSetReconciler) getNewMachine(machineSet *clusterv1.MachineSet) *clusterv1.Machine {
 	gv := clusterv1.GroupVersion
+	// Copy the template, the Machines are created concurrently and decoded into by the client.
+	template := machineSet.Spec.Template.DeepCopy()
 	machine := &clusterv1.Machine{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       gv.WithKind("Machine").Kind,
 			APIVersion: gv.String(),
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Labels:      machineSet.Spec.Template.Labels,
-			Annotations: machineSet.Spec.Template.Annotations,
+			Labels:      template.Labels,
+			Annotations: template.Annotations,
 		},
-		Spec: machineSet.Spec.Template.Spec,
+		Spec: template.Spec,
 	}
 	machine.ObjectMeta.GenerateName = fmt.Sprintf("%s-", machineSet.Name)
 	machine.ObjectMeta.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, controllerKind)}
 	machine.Namespace = machineSet.Namespace
+	// Set the watch label of the Machine, its events are filtered out otherwise.
+	util.SetWatchLabel(machine, r.WatchFilterValue)
 	return machine
 }
 
+// pickFailureDomain returns the failure domain with the fewest of the given Machines, or nil if there are
+// no failure domains. Ties are broken by picking the first failure domain in alphabetical order.
+func pickFailureDomain(failureDomains clusterv1.FailureDomains, machines []*clusterv1.Machine) *string {
+	if len(failureDomains) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int, len(failureDomains))
+	for _, m := range machines {
+		if m.Spec.FailureDomain != nil {
+			counts[*m.Spec.FailureDomain]++
+		}
+	}
+
+	var picked string
+	for i, id := range failureDomains.GetIDs() {
+		if i == 0 || counts[id] < counts[picked] {
+			picked = id
+		}
+	}
+	return &picked
+}
+
 // shouldExcludeMachine returns true if the machine should be filtered out, false otherwise.
 func shouldExcludeMachine(machineSet *clusterv1.MachineSet, machine *clusterv1.Machine) bool {
 	if metav1.GetControllerOf(machine) != nil && !metav1.IsControlledBy(machine, machineSet) {
@@ -391,53 +690,6 @@ func (r *MachineSetReconciler) adoptOrphan(machineSet *clusterv1.MachineSet, mac
 	return r.Client.Update(context.Background(), machine)
 }
 
-func (r *MachineSetReconciler) waitForMachineCreation(machineList []*clusterv1.Machine) error {
-	for _, machine := range machineList {
-		pollErr := util.PollImmediate(stateConfirmationInterval, stateConfirmationTimeout, func() (bool, error) {
-			key := client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}
-
-			if err := r.Client.Get(context.Background(), key, &clusterv1.Machine{}); err != nil {
-				if apierrors.IsNotFound(err) {
-					return false, nil
-				}
-				klog.Error(err)
-				return false, err
-			}
-
-			return true, nil
-		})
-
-		if pollErr != nil {
-			klog.Error(pollErr)
-			return errors.Wrap(pollErr, "failed waiting for machine object to be created")
-		}
-	}
-
-	return nil
-}
-
-func (r *MachineSetReconciler) waitForMachineDeletion(machineList []*clusterv1.Machine) error {
-	for _, machine := range machineList {
-		pollErr := util.PollImmediate(stateConfirmationInterval, stateConfirmationTimeout, func() (bool, error) {
-			m := &clusterv1.Machine{}
-			key := client.ObjectKey{Namespace: machine.Namespace, Name: machine.Name}
-
-			err := r.Client.Get(context.Background(), key, m)
-			if apierrors.IsNotFound(err) || !m.DeletionTimestamp.IsZero() {
-				return true, nil
-			}
-
-			return false, err
-		})
-
-		if pollErr != nil {
-			klog.Error(pollErr)
-			return errors.Wrap(pollErr, "failed waiting for machine object to be deleted")
-		}
-	}
-	return nil
-}
-
 // MachineToMachineSets is a handler.ToRequestsFunc to be used to enqeue requests for reconciliation
 // for MachineSets that might adopt an orphaned Machine.
 func (r *MachineSetReconciler) MachineToMachineSets(o handler.MapObject) []ctrl.Request {
@@ -471,6 +723,52 @@ func (r *MachineSetReconciler) MachineToMachineSets(o handler.MapObject) []ctrl.
 	return result
 }
 
+// shouldWatchNodes returns true if the Nodes of the given Cluster can be watched, i.e. if the workload
+// cluster clients are cached and the control plane of the cluster is up.
+func (r *MachineSetReconciler) shouldWatchNodes(cluster *clusterv1.Cluster) bool {
+	return r.Tracker != nil && r.controller != nil && cluster != nil && cluster.Status.ControlPlaneInitialized
+}
+
+// watchClusterNodes starts watching the Nodes of the workload cluster.
+func (r *MachineSetReconciler) watchClusterNodes(cluster *clusterv1.Cluster) error {
+	return r.Tracker.Watch(r.Client, remote.WatchInput{
+		Name:         "machineset-watchNodes",
+		Cluster:      cluster,
+		Watcher:      r.controller,
+		Kind:         &corev1.Node{},
+		EventHandler: &handler.EnqueueRequestsFromMapFunc{ToRequests: r.nodeToMachineSets(cluster)},
+	})
+}
+
+// nodeToMachineSets returns a handler.ToRequestsFunc that maps the Nodes of the given Cluster to the
+// MachineSets controlling the Machines with a matching ProviderID.
+func (r *MachineSetReconciler) nodeToMachineSets(cluster *clusterv1.Cluster) handler.ToRequestsFunc {
+	clusterKey := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}
+	return func(o handler.MapObject) []ctrl.Request {
+		node, ok := o.Object.(*corev1.Node)
+		if !ok {
+			klog.Errorf("expected a Node but got a %T", o.Object)
+			return nil
+		}
+
+		machines, err := getMachinesForNode(context.Background(), r.Client, clusterKey, node)
+		if err != nil {
+			klog.V(4).Infof("Failed to find Machines for Node %q in Cluster %q: %v", node.Name, clusterKey, err)
+			return nil
+		}
+
+		result := []ctrl.Request{}
+		for _, m := range machines {
+			ref := metav1.GetControllerOf(m)
+			if ref == nil || ref.Kind != controllerKind.Kind {
+				continue
+			}
+			result = append(result, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: m.Namespace, Name: ref.Name}})
+		}
+		return result
+	}
+}
+
 func (r *MachineSetReconciler) getMachineSetsForMachine(m *clusterv1.Machine) []*clusterv1.MachineSet {
 	if len(m.Labels) == 0 {
 		klog.Warningf("No machine sets found for Machine %v because it has no labels", m.Name)
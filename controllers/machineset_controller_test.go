@@ -17,7 +17,9 @@ limitations under the License.
 package controllers
 
 import (
+	"errors"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -27,9 +29,13 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -491,3 +497,275 @@ func TestHasMatchingLabels(t *testing.T) {
 		}
 	}
 }
+
+func TestMachineSetCalculateStatusConditions(t *testing.T) {
+	readyNode := func(name string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+	machineWithNode := func(name, nodeName string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: clusterv1.MachineStatus{
+				NodeRef: &corev1.ObjectReference{Name: nodeName},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		replicas       int32
+		machines       []*clusterv1.Machine
+		expectedStatus corev1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "all replicas ready",
+			replicas:       2,
+			machines:       []*clusterv1.Machine{machineWithNode("m1", "node-1"), machineWithNode("m2", "node-2")},
+			expectedStatus: corev1.ConditionTrue,
+		},
+		{
+			name:           "some replicas not ready",
+			replicas:       2,
+			machines:       []*clusterv1.Machine{machineWithNode("m1", "node-1"), machineWithNode("m2", "missing-node")},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: clusterv1.WaitingForReadyReplicasReason,
+		},
+	}
+
+	clusterv1.AddToScheme(scheme.Scheme)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			ms := &clusterv1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "ms", Namespace: "default"},
+				Spec:       clusterv1.MachineSetSpec{Replicas: pointer.Int32Ptr(tc.replicas)},
+			}
+			r := &MachineSetReconciler{
+				Client: fake.NewFakeClient(readyNode("node-1"), readyNode("node-2")),
+				Log:    log.Log,
+			}
+
+			newStatus := r.calculateStatus(ms, tc.machines)
+			holder := &clusterv1.MachineSet{Status: newStatus}
+
+			for _, conditionType := range []clusterv1.ConditionType{clusterv1.MachinesReadyCondition, clusterv1.ReadyCondition} {
+				condition := conditions.Get(holder, conditionType)
+				g.Expect(condition).ToNot(BeNil())
+				g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+				g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+			}
+
+			// The MachineSet passed in is left untouched.
+			g.Expect(ms.Status.Conditions).To(BeEmpty())
+		})
+	}
+}
+
+func TestPickFailureDomain(t *testing.T) {
+	machineIn := func(failureDomain string) *clusterv1.Machine {
+		return &clusterv1.Machine{Spec: clusterv1.MachineSpec{FailureDomain: pointer.StringPtr(failureDomain)}}
+	}
+	failureDomains := clusterv1.FailureDomains{
+		"us-east-1a": clusterv1.FailureDomainSpec{ControlPlane: true},
+		"us-east-1b": clusterv1.FailureDomainSpec{},
+		"us-east-1c": clusterv1.FailureDomainSpec{},
+	}
+
+	testCases := []struct {
+		name           string
+		failureDomains clusterv1.FailureDomains
+		machines       []*clusterv1.Machine
+		expected       *string
+	}{
+		{
+			name:     "no failure domains",
+			machines: []*clusterv1.Machine{machineIn("us-east-1a")},
+			expected: nil,
+		},
+		{
+			name:           "no machines, first failure domain in alphabetical order",
+			failureDomains: failureDomains,
+			expected:       pointer.StringPtr("us-east-1a"),
+		},
+		{
+			name:           "least populated failure domain",
+			failureDomains: failureDomains,
+			machines: []*clusterv1.Machine{
+				machineIn("us-east-1a"), machineIn("us-east-1a"), machineIn("us-east-1b"), machineIn("us-east-1c"),
+			},
+			expected: pointer.StringPtr("us-east-1b"),
+		},
+		{
+			name:           "machines in unknown failure domains are ignored",
+			failureDomains: failureDomains,
+			machines: []*clusterv1.Machine{
+				machineIn("us-east-1a"), machineIn("us-east-1b"), {}, machineIn("us-west-2a"),
+			},
+			expected: pointer.StringPtr("us-east-1c"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(pickFailureDomain(tc.failureDomains, tc.machines)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestNodeToMachineSets(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+
+	controller := true
+	newMachine := func(name, providerID string, owners ...metav1.OwnerReference) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Labels:          map[string]string{clusterv1.MachineClusterLabelName: "test-cluster"},
+				OwnerReferences: owners,
+			},
+			Spec: clusterv1.MachineSpec{ProviderID: &providerID},
+		}
+	}
+
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+	r := &MachineSetReconciler{
+		Client: fake.NewFakeClient(
+			newMachine("owned", "aws:///id-node-1", metav1.OwnerReference{Kind: "MachineSet", Name: "ms", Controller: &controller}),
+			newMachine("orphan", "aws:///id-node-2"),
+		),
+		Log: log.Log,
+	}
+
+	testCases := []struct {
+		providerID string
+		expected   []reconcile.Request
+	}{
+		{
+			providerID: "aws:///id-node-1",
+			expected:   []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: "default", Name: "ms"}}},
+		},
+		{
+			providerID: "aws:///id-node-2",
+			expected:   []reconcile.Request{},
+		},
+	}
+
+	for _, tc := range testCases {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Spec:       corev1.NodeSpec{ProviderID: tc.providerID},
+		}
+
+		got := r.nodeToMachineSets(cluster)(handler.MapObject{Meta: node, Object: node})
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Case %s. Got: %v, expected: %v", tc.providerID, got, tc.expected)
+		}
+	}
+}
+
+func TestSlowStartBatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lock sync.Mutex
+	calls := 0
+	failAfter := func(n int) func() error {
+		return func() error {
+			lock.Lock()
+			defer lock.Unlock()
+			calls++
+			if calls > n {
+				return errors.New("boom")
+			}
+			return nil
+		}
+	}
+
+	successes, err := slowStartBatch(10, 1, failAfter(10))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(successes).To(Equal(10))
+	g.Expect(calls).To(Equal(10))
+
+	// The batches are 1, 2 and 4 calls, the third one fails and no more batches are started.
+	calls = 0
+	successes, err = slowStartBatch(10, 1, failAfter(5))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(successes).To(Equal(5))
+	g.Expect(calls).To(Equal(7))
+
+	calls = 0
+	successes, err = slowStartBatch(0, 1, failAfter(0))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(successes).To(Equal(0))
+	g.Expect(calls).To(Equal(0))
+}
+
+func TestSyncReplicasCreationBackoff(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	clusterv1.AddToScheme(scheme.Scheme)
+	ms := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "ms", Namespace: "default", UID: "ms-uid"},
+		Spec: clusterv1.MachineSetSpec{
+			Replicas: pointer.Int32Ptr(10),
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					// The infrastructure template doesn't exist, cloning it fails.
+					InfrastructureRef: corev1.ObjectReference{
+						APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha2",
+						Kind:       "InfrastructureMachineTemplate",
+						Name:       "missing",
+					},
+				},
+			},
+		},
+	}
+
+	fakeClock := clock.NewFakeClock(time.Now())
+	r := &MachineSetReconciler{
+		Client:          fake.NewFakeClient(ms),
+		Log:             log.Log,
+		recorder:        record.NewFakeRecorder(32),
+		expectations:    newMachineExpectations(),
+		creationBackoff: flowcontrol.NewFakeBackOff(10*time.Second, time.Minute, fakeClock),
+	}
+
+	g.Expect(r.syncReplicas(nil, ms, nil)).NotTo(Succeed())
+	g.Expect(conditions.IsFalse(ms, clusterv1.MachinesCreatedCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(ms, clusterv1.MachinesCreatedCondition)).To(Equal(clusterv1.MachineCreationFailedReason))
+	g.Expect(conditions.GetMessage(ms, clusterv1.MachinesCreatedCondition)).To(HavePrefix("Failed to create 10 of 10 Machines"))
+
+	// Only the first Machine has been attempted, and none is expected anymore.
+	g.Expect(r.expectations.satisfied(ms.UID)).To(BeTrue())
+
+	// Creations are skipped while backing off, and retried once the back-off expires.
+	g.Expect(r.creationBackoffDelay(ms)).To(Equal(10 * time.Second))
+	g.Expect(r.syncReplicas(nil, ms, nil)).To(Succeed())
+
+	fakeClock.Step(4 * time.Second)
+	g.Expect(r.creationBackoffDelay(ms)).To(Equal(6 * time.Second))
+
+	fakeClock.Step(7 * time.Second)
+	g.Expect(r.creationBackoffDelay(ms)).To(BeZero())
+	g.Expect(r.syncReplicas(nil, ms, nil)).NotTo(Succeed())
+	g.Expect(r.creationBackoffDelay(ms)).To(Equal(20 * time.Second))
+
+	// The condition is cleared once the MachineSet doesn't need more Machines.
+	ms.Spec.Replicas = pointer.Int32Ptr(0)
+	g.Expect(r.syncReplicas(nil, ms, nil)).To(Succeed())
+	g.Expect(conditions.IsTrue(ms, clusterv1.MachinesCreatedCondition)).To(BeTrue())
+
+	// The back-off is garbage collected once the MachineSet hasn't failed for twice the maximum back-off.
+	fakeClock.Step(2*time.Minute + time.Second)
+	r.gcCreationBackoff()
+	g.Expect(r.creationBackoff.Get(string(ms.UID))).To(BeZero())
+	g.Expect(r.lastCreationFailures).To(BeEmpty())
+}
@@ -17,43 +17,110 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"math"
 	"sort"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/noderefutil"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type (
-	deletePriority     float64
-	deletePriorityFunc func(machine *clusterv1.Machine) deletePriority
+	// DeletePriority is the priority of a Machine to be deleted when its MachineSet scales down,
+	// from 0 for Machines that must not be deleted to 100 for Machines that must be deleted.
+	DeletePriority float64
+
+	// DeletePriorityFunc returns the delete priority of a Machine.
+	DeletePriorityFunc func(machine *clusterv1.Machine) DeletePriority
+
+	// DeletePolicy returns the DeletePriorityFunc used to choose the Machines to delete when a
+	// MachineSet scales down.
+	DeletePolicy func(ctx context.Context, input *DeletePolicyInput) (DeletePriorityFunc, error)
 )
 
+// DeletePolicyInput holds what delete policies can use to prioritize the Machines of a MachineSet.
+type DeletePolicyInput struct {
+	MachineSet *clusterv1.MachineSet
+
+	// Machines are the Machines of the MachineSet.
+	Machines []*clusterv1.Machine
+
+	// ClusterClient gives access to the workload cluster, it's nil if the MachineSet doesn't
+	// belong to a Cluster or if the workload cluster can't be reached.
+	ClusterClient remote.ClusterClient
+}
+
 const (
 	// DeleteNodeAnnotation marks nodes that will be given priority for deletion
 	// when a machineset scales down. This annotation is given top priority on all delete policies.
 	DeleteNodeAnnotation = "cluster.k8s.io/delete-machine"
 
-	mustDelete    deletePriority = 100.0
-	betterDelete  deletePriority = 50.0
-	couldDelete   deletePriority = 20.0
-	mustNotDelete deletePriority = 0.0
+	mustDelete    DeletePriority = 100.0
+	betterDelete  DeletePriority = 50.0
+	couldDelete   DeletePriority = 20.0
+	mustNotDelete DeletePriority = 0.0
 
 	secondsPerTenDays float64 = 864000
 )
 
-// maps the creation timestamp onto the 0-100 priority range
-func oldestDeletePriority(machine *clusterv1.Machine) deletePriority {
-	if machine.DeletionTimestamp != nil && !machine.DeletionTimestamp.IsZero() {
-		return mustDelete
+// defaultDeletePolicies is used by the MachineSet controllers that don't have their own registry.
+var defaultDeletePolicies = NewDeletePolicyRegistry()
+
+// DeletePolicyRegistry maps the values of MachineSet.Spec.DeletePolicy to delete policies.
+// Controller managers embedding the MachineSet controller can register their own policies.
+type DeletePolicyRegistry struct {
+	policies map[clusterv1.MachineSetDeletePolicy]DeletePolicy
+}
+
+// NewDeletePolicyRegistry returns a registry with the built-in delete policies.
+func NewDeletePolicyRegistry() *DeletePolicyRegistry {
+	r := &DeletePolicyRegistry{policies: map[clusterv1.MachineSetDeletePolicy]DeletePolicy{}}
+	r.Register(clusterv1.RandomMachineSetDeletePolicy, staticDeletePolicy(randomDeletePolicy))
+	r.Register(clusterv1.NewestMachineSetDeletePolicy, staticDeletePolicy(newestDeletePriority))
+	r.Register(clusterv1.OldestMachineSetDeletePolicy, staticDeletePolicy(oldestDeletePriority))
+	r.Register(clusterv1.UnreadyNodeMachineSetDeletePolicy, unreadyNodeDeletePolicy)
+	r.Register(clusterv1.NoNodeRefMachineSetDeletePolicy, staticDeletePolicy(noNodeRefDeletePriority))
+	r.Register(clusterv1.FewestPodsMachineSetDeletePolicy, fewestPodsDeletePolicy)
+	r.Register(clusterv1.MostCrowdedFailureDomainMachineSetDeletePolicy, mostCrowdedFailureDomainDeletePolicy)
+	return r
+}
+
+// Register registers a delete policy, replacing the policy registered with the same name if any.
+// Machines being deleted, marked with the DeleteNodeAnnotation or failed are deleted first
+// whatever the policy.
+func (r *DeletePolicyRegistry) Register(name clusterv1.MachineSetDeletePolicy, policy DeletePolicy) {
+	r.policies[name] = policy
+}
+
+// Get returns the delete policy registered with the given name, the Random policy is used if the name is empty.
+func (r *DeletePolicyRegistry) Get(name clusterv1.MachineSetDeletePolicy) (DeletePolicy, error) {
+	if name == "" {
+		name = clusterv1.RandomMachineSetDeletePolicy
 	}
-	if machine.ObjectMeta.Annotations != nil && machine.ObjectMeta.Annotations[DeleteNodeAnnotation] != "" {
-		return mustDelete
+	policy, ok := r.policies[name]
+	if !ok {
+		return nil, errors.Errorf("Unsupported delete policy %s", name)
 	}
-	if machine.Status.ErrorReason != nil || machine.Status.ErrorMessage != nil {
-		return mustDelete
+	return policy, nil
+}
+
+// staticDeletePolicy returns a delete policy that always uses the given DeletePriorityFunc.
+func staticDeletePolicy(fun DeletePriorityFunc) DeletePolicy {
+	return func(_ context.Context, _ *DeletePolicyInput) (DeletePriorityFunc, error) {
+		return fun, nil
 	}
+}
+
+// maps the creation timestamp onto the 0-100 priority range
+func oldestDeletePriority(machine *clusterv1.Machine) DeletePriority {
 	if machine.ObjectMeta.CreationTimestamp.Time.IsZero() {
 		return mustNotDelete
 	}
@@ -61,38 +128,148 @@ func oldestDeletePriority(machine *clusterv1.Machine) deletePriority {
 	if d.Seconds() < 0 {
 		return mustNotDelete
 	}
-	return deletePriority(float64(mustDelete) * (1.0 - math.Exp(-d.Seconds()/secondsPerTenDays)))
+	return DeletePriority(float64(mustDelete) * (1.0 - math.Exp(-d.Seconds()/secondsPerTenDays)))
 }
 
-func newestDeletePriority(machine *clusterv1.Machine) deletePriority {
-	if machine.DeletionTimestamp != nil && !machine.DeletionTimestamp.IsZero() {
-		return mustDelete
+func newestDeletePriority(machine *clusterv1.Machine) DeletePriority {
+	return mustDelete - oldestDeletePriority(machine)
+}
+
+func randomDeletePolicy(machine *clusterv1.Machine) DeletePriority {
+	return couldDelete
+}
+
+func noNodeRefDeletePriority(machine *clusterv1.Machine) DeletePriority {
+	if machine.Status.NodeRef == nil {
+		return betterDelete
 	}
-	if machine.ObjectMeta.Annotations != nil && machine.ObjectMeta.Annotations[DeleteNodeAnnotation] != "" {
-		return mustDelete
+	return couldDelete
+}
+
+// unreadyNodeDeletePolicy prioritizes the Machines whose Node isn't ready or doesn't exist.
+func unreadyNodeDeletePolicy(ctx context.Context, input *DeletePolicyInput) (DeletePriorityFunc, error) {
+	if input.ClusterClient == nil {
+		klog.V(2).Infof("Unable to check the Nodes of MachineSet %q in namespace %q, falling back to random deletion",
+			input.MachineSet.Name, input.MachineSet.Namespace)
+		return randomDeletePolicy, nil
 	}
-	if machine.Status.ErrorReason != nil || machine.Status.ErrorMessage != nil {
-		return mustDelete
+
+	c, err := input.ClusterClient.Client()
+	if err != nil {
+		return nil, err
 	}
-	return mustDelete - oldestDeletePriority(machine)
+
+	unready := map[string]bool{}
+	for _, m := range input.Machines {
+		if m.Status.NodeRef == nil {
+			unready[m.Name] = true
+			continue
+		}
+		node := &corev1.Node{}
+		if err := c.Get(ctx, client.ObjectKey{Name: m.Status.NodeRef.Name}, node); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to get Node %q of Machine %q in namespace %q",
+					m.Status.NodeRef.Name, m.Name, m.Namespace)
+			}
+			unready[m.Name] = true
+			continue
+		}
+		unready[m.Name] = !noderefutil.IsNodeReady(node)
+	}
+
+	return func(machine *clusterv1.Machine) DeletePriority {
+		if unready[machine.Name] {
+			return betterDelete
+		}
+		return couldDelete
+	}, nil
 }
 
-func randomDeletePolicy(machine *clusterv1.Machine) deletePriority {
-	if machine.DeletionTimestamp != nil && !machine.DeletionTimestamp.IsZero() {
-		return mustDelete
+// fewestPodsDeletePolicy prioritizes the Machines whose Node runs the fewest pods.
+func fewestPodsDeletePolicy(_ context.Context, input *DeletePolicyInput) (DeletePriorityFunc, error) {
+	if input.ClusterClient == nil {
+		klog.V(2).Infof("Unable to count the pods of MachineSet %q in namespace %q, falling back to random deletion",
+			input.MachineSet.Name, input.MachineSet.Namespace)
+		return randomDeletePolicy, nil
 	}
-	if machine.ObjectMeta.Annotations != nil && machine.ObjectMeta.Annotations[DeleteNodeAnnotation] != "" {
-		return betterDelete
+
+	coreV1, err := input.ClusterClient.CoreV1()
+	if err != nil {
+		return nil, err
 	}
-	if machine.Status.ErrorReason != nil || machine.Status.ErrorMessage != nil {
-		return betterDelete
+
+	podsPerNode := map[string]int{}
+	for _, m := range input.Machines {
+		if m.Status.NodeRef == nil {
+			continue
+		}
+		nodeName := m.Status.NodeRef.Name
+		if _, ok := podsPerNode[nodeName]; ok {
+			continue
+		}
+
+		// Only list the pods of the Node, the workload cluster may run a lot of pods.
+		pods, err := coreV1.Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the pods of Node %q of Machine %q in namespace %q",
+				nodeName, m.Name, m.Namespace)
+		}
+		podsPerNode[nodeName] = 0
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			podsPerNode[nodeName]++
+		}
 	}
-	return couldDelete
+
+	return func(machine *clusterv1.Machine) DeletePriority {
+		if machine.Status.NodeRef == nil {
+			return betterDelete
+		}
+		return betterDelete / DeletePriority(1+podsPerNode[machine.Status.NodeRef.Name])
+	}, nil
+}
+
+// mostCrowdedFailureDomainDeletePolicy prioritizes the Machines in the failure domains with the most
+// Machines of the MachineSet, so that the remaining Machines stay spread across failure domains.
+// Within a failure domain, the oldest Machines come first.
+func mostCrowdedFailureDomainDeletePolicy(_ context.Context, input *DeletePolicyInput) (DeletePriorityFunc, error) {
+	machinesPerFailureDomain := map[string][]*clusterv1.Machine{}
+	maxCount := 0
+	for _, m := range input.Machines {
+		id := failureDomainOf(m)
+		machinesPerFailureDomain[id] = append(machinesPerFailureDomain[id], m)
+		if len(machinesPerFailureDomain[id]) > maxCount {
+			maxCount = len(machinesPerFailureDomain[id])
+		}
+	}
+
+	// Deleting the i-th oldest Machine of a failure domain with n Machines leaves n-i-1 Machines in it:
+	// the higher n-i, the more crowded the failure domain is once the Machines before it are deleted.
+	remaining := map[*clusterv1.Machine]int{}
+	for _, machines := range machinesPerFailureDomain {
+		sort.SliceStable(machines, func(i, j int) bool {
+			return oldestDeletePriority(machines[i]) > oldestDeletePriority(machines[j])
+		})
+		for i, m := range machines {
+			remaining[m] = len(machines) - i
+		}
+	}
+
+	return func(machine *clusterv1.Machine) DeletePriority {
+		if maxCount == 0 {
+			return couldDelete
+		}
+		return betterDelete * DeletePriority(remaining[machine]) / DeletePriority(maxCount)
+	}, nil
 }
 
 type sortableMachines struct {
 	machines []*clusterv1.Machine
-	priority deletePriorityFunc
+	priority DeletePriorityFunc
 }
 
 func (m sortableMachines) Len() int      { return len(m.machines) }
@@ -101,7 +278,9 @@ func (m sortableMachines) Less(i, j int) bool {
 	return m.priority(m.machines[j]) < m.priority(m.machines[i]) // high to low
 }
 
-func getMachinesToDeletePrioritized(filteredMachines []*clusterv1.Machine, diff int, fun deletePriorityFunc) []*clusterv1.Machine {
+// getMachinesToDeletePrioritized returns the diff Machines to delete first.
+// Machines which are being deleted, are marked for deletion or have failed always come first.
+func getMachinesToDeletePrioritized(filteredMachines []*clusterv1.Machine, diff int, fun DeletePriorityFunc) []*clusterv1.Machine {
 	if diff >= len(filteredMachines) {
 		return filteredMachines
 	} else if diff <= 0 {
@@ -110,25 +289,63 @@ func getMachinesToDeletePrioritized(filteredMachines []*clusterv1.Machine, diff
 
 	sortable := sortableMachines{
 		machines: filteredMachines,
-		priority: fun,
+		priority: honorDeletePreferences(fun),
 	}
 	sort.Sort(sortable)
 
 	return sortable.machines[:diff]
 }
 
-func getDeletePriorityFunc(ms *clusterv1.MachineSet) (deletePriorityFunc, error) {
-	// Map the Spec.DeletePolicy value to the appropriate delete priority function
-	switch msdp := clusterv1.MachineSetDeletePolicy(ms.Spec.DeletePolicy); msdp {
-	case clusterv1.RandomMachineSetDeletePolicy:
-		return randomDeletePolicy, nil
-	case clusterv1.NewestMachineSetDeletePolicy:
-		return newestDeletePriority, nil
-	case clusterv1.OldestMachineSetDeletePolicy:
-		return oldestDeletePriority, nil
-	case "":
-		return randomDeletePolicy, nil
-	default:
-		return nil, errors.Errorf("Unsupported delete policy %s. Must be one of 'Random', 'Newest', or 'Oldest'", msdp)
+// isDeletePreferred returns true if the Machine should be deleted before any other,
+// whatever the delete policy.
+func isDeletePreferred(machine *clusterv1.Machine) bool {
+	if machine.DeletionTimestamp != nil && !machine.DeletionTimestamp.IsZero() {
+		return true
+	}
+	if machine.ObjectMeta.Annotations != nil && machine.ObjectMeta.Annotations[DeleteNodeAnnotation] != "" {
+		return true
+	}
+	return machine.Status.ErrorReason != nil || machine.Status.ErrorMessage != nil
+}
+
+// failureDomainOf returns the failure domain of the Machine, or an empty string if it has none.
+func failureDomainOf(machine *clusterv1.Machine) string {
+	if machine.Spec.FailureDomain == nil {
+		return ""
+	}
+	return *machine.Spec.FailureDomain
+}
+
+// honorDeletePreferences returns a DeletePriorityFunc giving the top priority to the Machines that
+// should be deleted before any other, and using the given DeletePriorityFunc for the other Machines.
+func honorDeletePreferences(fun DeletePriorityFunc) DeletePriorityFunc {
+	return func(machine *clusterv1.Machine) DeletePriority {
+		if isDeletePreferred(machine) {
+			return mustDelete
+		}
+		return fun(machine)
+	}
+}
+
+// getDeletePriorityFunc returns the DeletePriorityFunc of the delete policy of the MachineSet.
+func (r *MachineSetReconciler) getDeletePriorityFunc(ctx context.Context, cluster *clusterv1.Cluster, ms *clusterv1.MachineSet, machines []*clusterv1.Machine) (DeletePriorityFunc, error) {
+	registry := r.DeletePolicies
+	if registry == nil {
+		registry = defaultDeletePolicies
+	}
+	policy, err := registry.Get(clusterv1.MachineSetDeletePolicy(ms.Spec.DeletePolicy))
+	if err != nil {
+		return nil, err
+	}
+
+	input := &DeletePolicyInput{MachineSet: ms, Machines: machines}
+	if cluster != nil {
+		clusterClient, err := r.clusterClientGetter()(r.Client, cluster)
+		if err != nil {
+			klog.V(2).Infof("Unable to access the workload cluster of MachineSet %q in namespace %q: %v", ms.Name, ms.Namespace, err)
+		} else {
+			input.ClusterClient = clusterClient
+		}
 	}
+	return policy(ctx, input)
 }
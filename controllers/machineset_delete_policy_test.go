@@ -17,12 +17,20 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	capierrors "sigs.k8s.io/cluster-api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestMachineToDelete(t *testing.T) {
@@ -301,3 +309,276 @@ func TestMachineOldestDelete(t *testing.T) {
 		}
 	}
 }
+
+func TestMachineDeleteFailureDomains(t *testing.T) {
+	currentTime := metav1.Now()
+	msg := "something wrong with the machine"
+	newMachine := func(failureDomain string, daysOld int) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(currentTime.Time.AddDate(0, 0, -daysOld))},
+			Spec:       clusterv1.MachineSpec{FailureDomain: &failureDomain},
+		}
+	}
+	oldestInA := newMachine("a", 8)
+	oldInA := newMachine("a", 6)
+	newInA := newMachine("a", 2)
+	oldestInB := newMachine("b", 10)
+	newInB := newMachine("b", 1)
+	unhealthyInB := newMachine("b", 1)
+	unhealthyInB.Status.ErrorMessage = &msg
+
+	tests := []struct {
+		desc     string
+		machines []*clusterv1.Machine
+		diff     int
+		expect   []*clusterv1.Machine
+	}{
+		{
+			desc: "most crowded failure domain first, diff=1",
+			diff: 1,
+			machines: []*clusterv1.Machine{
+				oldestInB, newInA, oldInA, oldestInA,
+			},
+			expect: []*clusterv1.Machine{oldestInA},
+		},
+		{
+			desc: "most crowded failure domain first, diff=2",
+			diff: 2,
+			machines: []*clusterv1.Machine{
+				oldestInB, newInA, oldInA, oldestInA,
+			},
+			expect: []*clusterv1.Machine{oldestInA, oldInA},
+		},
+		{
+			desc: "oldest machines of each failure domain, diff=3",
+			diff: 3,
+			machines: []*clusterv1.Machine{
+				oldestInB, newInB, newInA, oldInA, oldestInA,
+			},
+			expect: []*clusterv1.Machine{oldestInA, oldestInB, oldInA},
+		},
+		{
+			desc: "unhealthy machines first, regardless of failure domain",
+			diff: 2,
+			machines: []*clusterv1.Machine{
+				unhealthyInB, newInA, oldInA, oldestInA,
+			},
+			expect: []*clusterv1.Machine{unhealthyInB, oldestInA},
+		},
+	}
+
+	for _, test := range tests {
+		fun, err := mostCrowdedFailureDomainDeletePolicy(context.Background(), &DeletePolicyInput{Machines: test.machines})
+		if err != nil {
+			t.Fatalf("[case %s] unexpected error: %v", test.desc, err)
+		}
+		result := getMachinesToDeletePrioritized(test.machines, test.diff, fun)
+		if len(result) != len(test.expect) {
+			t.Errorf("[case %s] expected %d machines, got %d", test.desc, len(test.expect), len(result))
+			continue
+		}
+		// Machines with the same priority can be deleted in any order.
+		deleted := map[*clusterv1.Machine]bool{}
+		for _, m := range result {
+			deleted[m] = true
+		}
+		for _, m := range test.expect {
+			if !deleted[m] {
+				t.Errorf("[case %s]", test.desc)
+				break
+			}
+		}
+	}
+}
+
+func TestMachineDeletePriorityIgnoresFailureDomains(t *testing.T) {
+	currentTime := metav1.Now()
+	newMachine := func(failureDomain string, daysOld int) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(currentTime.Time.AddDate(0, 0, -daysOld))},
+			Spec:       clusterv1.MachineSpec{FailureDomain: &failureDomain},
+		}
+	}
+	oldestInB := newMachine("b", 10)
+	newInA := newMachine("a", 2)
+	oldInA := newMachine("a", 6)
+
+	result := getMachinesToDeletePrioritized([]*clusterv1.Machine{newInA, oldInA, oldestInB}, 1, oldestDeletePriority)
+	if !reflect.DeepEqual(result, []*clusterv1.Machine{oldestInB}) {
+		t.Errorf("expected the Oldest policy to delete the oldest Machine whatever its failure domain")
+	}
+}
+
+func TestDeletePolicyRegistry(t *testing.T) {
+	registry := NewDeletePolicyRegistry()
+
+	for _, name := range []clusterv1.MachineSetDeletePolicy{
+		"",
+		clusterv1.RandomMachineSetDeletePolicy,
+		clusterv1.NewestMachineSetDeletePolicy,
+		clusterv1.OldestMachineSetDeletePolicy,
+		clusterv1.UnreadyNodeMachineSetDeletePolicy,
+		clusterv1.NoNodeRefMachineSetDeletePolicy,
+		clusterv1.FewestPodsMachineSetDeletePolicy,
+		clusterv1.MostCrowdedFailureDomainMachineSetDeletePolicy,
+	} {
+		if _, err := registry.Get(name); err != nil {
+			t.Errorf("expected delete policy %q to be registered, got %v", name, err)
+		}
+	}
+
+	if _, err := registry.Get("Custom"); err == nil {
+		t.Errorf("expected an error for an unregistered delete policy")
+	}
+
+	// Custom policies honor the DeleteNodeAnnotation like the built-in ones.
+	preferred := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "preferred"}}
+	annotated := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "annotated", Annotations: map[string]string{DeleteNodeAnnotation: "yes"}}}
+	registry.Register("Custom", staticDeletePolicy(func(machine *clusterv1.Machine) DeletePriority {
+		if machine.Name == "preferred" {
+			return betterDelete
+		}
+		return mustNotDelete
+	}))
+	policy, err := registry.Get("Custom")
+	if err != nil {
+		t.Fatalf("expected the custom delete policy to be registered, got %v", err)
+	}
+	fun, err := policy(context.Background(), &DeletePolicyInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := getMachinesToDeletePrioritized([]*clusterv1.Machine{preferred, {}, annotated}, 2, fun)
+	if !reflect.DeepEqual(result, []*clusterv1.Machine{annotated, preferred}) {
+		t.Errorf("expected the annotated Machine to be deleted first, got %v", result)
+	}
+}
+
+func TestMachineNoNodeRefDelete(t *testing.T) {
+	withNode := &clusterv1.Machine{Status: clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: "node-1"}}}
+	withoutNode := &clusterv1.Machine{}
+
+	result := getMachinesToDeletePrioritized([]*clusterv1.Machine{withNode, withoutNode}, 1, noNodeRefDeletePriority)
+	if !reflect.DeepEqual(result, []*clusterv1.Machine{withoutNode}) {
+		t.Errorf("expected the Machine without a Node to be deleted, got %v", result)
+	}
+}
+
+func TestMachineUnreadyNodeDelete(t *testing.T) {
+	newNode := func(name string, status corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+			},
+		}
+	}
+	newMachine := func(name, nodeName string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: nodeName}},
+		}
+	}
+	ready := newMachine("ready", "ready-node")
+	unready := newMachine("unready", "unready-node")
+	missing := newMachine("missing", "missing-node")
+
+	input := &DeletePolicyInput{
+		MachineSet: &clusterv1.MachineSet{},
+		Machines:   []*clusterv1.Machine{ready, unready, missing},
+		ClusterClient: &fakeClusterClient{
+			client: fake.NewFakeClient(newNode("ready-node", corev1.ConditionTrue), newNode("unready-node", corev1.ConditionFalse)),
+		},
+	}
+	fun, err := unreadyNodeDeletePolicy(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := getMachinesToDeletePrioritized([]*clusterv1.Machine{ready, unready, missing}, 2, fun)
+	if !reflect.DeepEqual(result, []*clusterv1.Machine{unready, missing}) {
+		t.Errorf("expected the Machines with unready or missing Nodes to be deleted, got %v", result)
+	}
+
+	// Without access to the workload cluster, the policy falls back to random deletion.
+	input.ClusterClient = nil
+	if _, err := unreadyNodeDeletePolicy(context.Background(), input); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMachineFewestPodsDelete(t *testing.T) {
+	newPod := func(name, nodeName string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	newMachine := func(nodeName string) *clusterv1.Machine {
+		return &clusterv1.Machine{Status: clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: nodeName}}}
+	}
+	busy := newMachine("busy-node")
+	quiet := newMachine("quiet-node")
+	idle := newMachine("idle-node")
+
+	pods := []*corev1.Pod{
+		newPod("pod-1", "busy-node", corev1.PodRunning),
+		newPod("pod-2", "busy-node", corev1.PodRunning),
+		newPod("pod-3", "quiet-node", corev1.PodRunning),
+		newPod("pod-4", "idle-node", corev1.PodSucceeded),
+	}
+	workloadClient := fakeclient.NewSimpleClientset()
+	// The fake clientset ignores field selectors, the pods must be listed per Node.
+	workloadClient.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		selector := action.(clienttesting.ListAction).GetListRestrictions().Fields
+		if selector == nil || selector.Empty() {
+			return true, nil, errors.New("expected the pods to be listed with a field selector")
+		}
+		list := &corev1.PodList{}
+		for _, pod := range pods {
+			if selector.Matches(fields.Set{"spec.nodeName": pod.Spec.NodeName}) {
+				list.Items = append(list.Items, *pod)
+			}
+		}
+		return true, list, nil
+	})
+
+	input := &DeletePolicyInput{
+		MachineSet:    &clusterv1.MachineSet{},
+		Machines:      []*clusterv1.Machine{busy, quiet, idle},
+		ClusterClient: &fakeClusterClient{coreV1: workloadClient.CoreV1()},
+	}
+	fun, err := fewestPodsDeletePolicy(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := getMachinesToDeletePrioritized([]*clusterv1.Machine{busy, quiet, idle}, 2, fun)
+	if !reflect.DeepEqual(result, []*clusterv1.Machine{idle, quiet}) {
+		t.Errorf("expected the Machines with the fewest pods to be deleted, got %v", result)
+	}
+}
+
+func TestMachineMostCrowdedFailureDomainDelete(t *testing.T) {
+	newMachine := func(failureDomain string) *clusterv1.Machine {
+		return &clusterv1.Machine{Spec: clusterv1.MachineSpec{FailureDomain: &failureDomain}}
+	}
+	inA := newMachine("a")
+	inB1 := newMachine("b")
+	inB2 := newMachine("b")
+	machines := []*clusterv1.Machine{inA, inB1, inB2}
+
+	fun, err := mostCrowdedFailureDomainDeletePolicy(context.Background(), &DeletePolicyInput{Machines: machines})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fun(inB1) <= fun(inA) {
+		t.Errorf("expected Machines in the most crowded failure domain to have a higher priority")
+	}
+
+	result := getMachinesToDeletePrioritized(machines, 1, fun)
+	if failureDomainOf(result[0]) != "b" {
+		t.Errorf("expected a Machine in the most crowded failure domain to be deleted, got one in %q", failureDomainOf(result[0]))
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+// expectationsTimeout is how long the expectations of a MachineSet are awaited. Once expired, the
+// expectations are considered satisfied, in case the events of some Machines have been missed.
+var expectationsTimeout = 5 * time.Minute
+
+// machineExpectations tracks the Machines created and deleted by MachineSets until the creations and
+// deletions are observed in the cache, so that MachineSets aren't scaled again from a stale cache.
+// Expectations are keyed by the UID of the MachineSet. A nil machineExpectations is always satisfied.
+type machineExpectations struct {
+	lock    sync.Mutex
+	entries map[types.UID]*machineExpectation
+	now     func() time.Time
+}
+
+type machineExpectation struct {
+	// creations is the number of Machine creations that haven't been observed yet.
+	creations int
+
+	// deletions holds the names of the Machines whose deletion hasn't been observed yet.
+	deletions map[string]bool
+
+	// timestamp is the time the expectations have last been raised.
+	timestamp time.Time
+}
+
+func newMachineExpectations() *machineExpectations {
+	return &machineExpectations{
+		entries: map[types.UID]*machineExpectation{},
+		now:     time.Now,
+	}
+}
+
+// expectCreations records that the MachineSet is about to create the given number of Machines.
+func (e *machineExpectations) expectCreations(key types.UID, count int) {
+	if e == nil || count <= 0 {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	entry := e.raiseLocked(key)
+	entry.creations += count
+}
+
+// expectDeletions records that the MachineSet is about to delete the given Machines.
+func (e *machineExpectations) expectDeletions(key types.UID, names []string) {
+	if e == nil || len(names) == 0 {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	entry := e.raiseLocked(key)
+	for _, name := range names {
+		entry.deletions[name] = true
+	}
+}
+
+// creationObserved records the creation of a Machine of the MachineSet, or the failure to create it.
+func (e *machineExpectations) creationObserved(key types.UID) {
+	if e == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if entry, ok := e.entries[key]; ok && entry.creations > 0 {
+		entry.creations--
+	}
+}
+
+// deletionObserved records the deletion of a Machine of the MachineSet, or the failure to delete it.
+// Observing the deletion of the same Machine more than once is a no-op.
+func (e *machineExpectations) deletionObserved(key types.UID, name string) {
+	if e == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if entry, ok := e.entries[key]; ok {
+		delete(entry.deletions, name)
+	}
+}
+
+// satisfied returns true if all the creations and deletions of the MachineSet have been observed,
+// or if its expectations have expired.
+func (e *machineExpectations) satisfied(key types.UID) bool {
+	if e == nil {
+		return true
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	entry, ok := e.entries[key]
+	if !ok {
+		return true
+	}
+	if entry.creations <= 0 && len(entry.deletions) == 0 {
+		delete(e.entries, key)
+		return true
+	}
+	if e.now().Sub(entry.timestamp) > expectationsTimeout {
+		klog.V(2).Infof("Expectations of MachineSet with UID %q expired with %d creations and %d deletions pending",
+			key, entry.creations, len(entry.deletions))
+		delete(e.entries, key)
+		return true
+	}
+	return false
+}
+
+// delete forgets the expectations of the MachineSet.
+func (e *machineExpectations) delete(key types.UID) {
+	if e == nil {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	delete(e.entries, key)
+}
+
+// raiseLocked returns the expectations of the MachineSet, refreshing their timestamp, and drops the
+// expired expectations of the other MachineSets, which may have been deleted in the meantime.
+func (e *machineExpectations) raiseLocked(key types.UID) *machineExpectation {
+	now := e.now()
+	for k, entry := range e.entries {
+		if k != key && now.Sub(entry.timestamp) > expectationsTimeout {
+			delete(e.entries, k)
+		}
+	}
+
+	entry, ok := e.entries[key]
+	if !ok {
+		entry = &machineExpectation{deletions: map[string]bool{}}
+		e.entries[key] = entry
+	}
+	entry.timestamp = now
+	return entry
+}
+
+// machineExpectationsHandler enqueues the MachineSet controlling a Machine, after recording the creation
+// or the deletion of the Machine in the expectations of the MachineSet.
+type machineExpectationsHandler struct {
+	*handler.EnqueueRequestForOwner
+	expectations *machineExpectations
+}
+
+func newMachineExpectationsHandler(expectations *machineExpectations) *machineExpectationsHandler {
+	return &machineExpectationsHandler{
+		EnqueueRequestForOwner: &handler.EnqueueRequestForOwner{OwnerType: &clusterv1.MachineSet{}, IsController: true},
+		expectations:           expectations,
+	}
+}
+
+func (h *machineExpectationsHandler) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	if ref := machineSetControllerOf(evt.Meta); ref != nil {
+		h.expectations.creationObserved(ref.UID)
+	}
+	h.EnqueueRequestForOwner.Create(evt, q)
+}
+
+func (h *machineExpectationsHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	// Machines have finalizers, their deletion is observed as soon as they're marked for deletion.
+	if evt.MetaNew != nil && evt.MetaNew.GetDeletionTimestamp() != nil {
+		if ref := machineSetControllerOf(evt.MetaNew); ref != nil {
+			h.expectations.deletionObserved(ref.UID, evt.MetaNew.GetName())
+		}
+	}
+	h.EnqueueRequestForOwner.Update(evt, q)
+}
+
+func (h *machineExpectationsHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	if ref := machineSetControllerOf(evt.Meta); ref != nil {
+		h.expectations.deletionObserved(ref.UID, evt.Meta.GetName())
+	}
+	h.EnqueueRequestForOwner.Delete(evt, q)
+}
+
+// machineSetControllerOf returns the controller reference of the object if it's a MachineSet.
+func machineSetControllerOf(obj metav1.Object) *metav1.OwnerReference {
+	if obj == nil {
+		return nil
+	}
+	ref := metav1.GetControllerOf(obj)
+	if ref == nil || ref.Kind != controllerKind.Kind {
+		return nil
+	}
+	if gv, err := schema.ParseGroupVersion(ref.APIVersion); err != nil || gv.Group != controllerKind.Group {
+		return nil
+	}
+	return ref
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestMachineExpectations(t *testing.T) {
+	key := types.UID("ms-uid")
+	now := time.Now()
+	e := newMachineExpectations()
+	e.now = func() time.Time { return now }
+
+	if !e.satisfied(key) {
+		t.Fatal("expected unknown MachineSet to be satisfied")
+	}
+
+	e.expectCreations(key, 2)
+	e.expectDeletions(key, []string{"machine-a"})
+	e.creationObserved(key)
+	e.deletionObserved(key, "machine-a")
+	if e.satisfied(key) {
+		t.Fatal("expected MachineSet with a pending creation not to be satisfied")
+	}
+
+	// Observing the same deletion twice doesn't lower the other expectations.
+	e.deletionObserved(key, "machine-a")
+	e.creationObserved(key)
+	if !e.satisfied(key) {
+		t.Fatal("expected MachineSet to be satisfied once all creations and deletions are observed")
+	}
+
+	e.expectDeletions(key, []string{"machine-b"})
+	if e.satisfied(key) {
+		t.Fatal("expected MachineSet with a pending deletion not to be satisfied")
+	}
+	now = now.Add(expectationsTimeout + time.Second)
+	if !e.satisfied(key) {
+		t.Fatal("expected expired expectations to be satisfied")
+	}
+
+	e.expectCreations(key, 1)
+	e.delete(key)
+	if !e.satisfied(key) {
+		t.Fatal("expected deleted expectations to be satisfied")
+	}
+
+	var nilExpectations *machineExpectations
+	nilExpectations.expectCreations(key, 1)
+	if !nilExpectations.satisfied(key) {
+		t.Fatal("expected nil expectations to be satisfied")
+	}
+}
+
+func TestMachineExpectationsHandler(t *testing.T) {
+	ms := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "ms", Namespace: "default", UID: "ms-uid"},
+	}
+	newMachine := func(name string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ms, controllerKind)},
+			},
+		}
+	}
+
+	if err := clusterv1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatalf("failed to add the v1alpha2 APIs to the scheme: %v", err)
+	}
+
+	e := newMachineExpectations()
+	h := newMachineExpectationsHandler(e)
+	if err := h.InjectScheme(scheme.Scheme); err != nil {
+		t.Fatalf("failed to inject scheme: %v", err)
+	}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{clusterv1.GroupVersion})
+	mapper.Add(controllerKind, meta.RESTScopeNamespace)
+	if err := h.InjectMapper(mapper); err != nil {
+		t.Fatalf("failed to inject mapper: %v", err)
+	}
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	created := newMachine("created")
+	e.expectCreations(ms.UID, 1)
+	h.Create(event.CreateEvent{Meta: created, Object: created}, q)
+	if !e.satisfied(ms.UID) {
+		t.Fatal("expected the creation to be observed")
+	}
+
+	deleting := newMachine("deleting")
+	e.expectDeletions(ms.UID, []string{deleting.Name})
+	updated := deleting.DeepCopy()
+	now := metav1.Now()
+	updated.DeletionTimestamp = &now
+	h.Update(event.UpdateEvent{MetaOld: deleting, ObjectOld: deleting, MetaNew: updated, ObjectNew: updated}, q)
+	if !e.satisfied(ms.UID) {
+		t.Fatal("expected the deletion to be observed once the Machine is marked for deletion")
+	}
+
+	deleted := newMachine("deleted")
+	e.expectDeletions(ms.UID, []string{deleted.Name})
+	h.Delete(event.DeleteEvent{Meta: deleted, Object: deleted}, q)
+	if !e.satisfied(ms.UID) {
+		t.Fatal("expected the deletion to be observed")
+	}
+
+	// Machines that aren't controlled by a MachineSet are ignored.
+	orphan := newMachine("orphan")
+	orphan.OwnerReferences = nil
+	e.expectCreations(ms.UID, 1)
+	h.Create(event.CreateEvent{Meta: orphan, Object: orphan}, q)
+	if e.satisfied(ms.UID) {
+		t.Fatal("expected the creation of an orphan Machine not to be observed")
+	}
+
+	if q.Len() != 1 {
+		t.Fatalf("expected the MachineSet to be enqueued once, got %d items", q.Len())
+	}
+	item, _ := q.Get()
+	expected := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "ms"}}
+	if item != expected {
+		t.Fatalf("expected %v to be enqueued, got %v", expected, item)
+	}
+}
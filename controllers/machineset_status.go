@@ -19,6 +19,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,8 +27,8 @@ import (
 	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers/noderefutil"
-	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -37,7 +38,7 @@ const (
 )
 
 func (r *MachineSetReconciler) calculateStatus(ms *clusterv1.MachineSet, filteredMachines []*clusterv1.Machine) clusterv1.MachineSetStatus {
-	newStatus := ms.Status
+	newStatus := *ms.Status.DeepCopy()
 
 	// Count the number of machines that have labels matching the labels of the machine
 	// template of the replica set, the matching machines may have more
@@ -82,7 +83,28 @@ func (r *MachineSetReconciler) calculateStatus(ms *clusterv1.MachineSet, filtere
 	newStatus.FullyLabeledReplicas = int32(fullyLabeledReplicasCount)
 	newStatus.ReadyReplicas = int32(readyReplicasCount)
 	newStatus.AvailableReplicas = int32(availableReplicasCount)
-	return newStatus
+
+	var desiredReplicas int32
+	if ms.Spec.Replicas != nil {
+		desiredReplicas = *ms.Spec.Replicas
+	}
+
+	// Set the conditions through a throwaway MachineSet, given that newStatus is a copy.
+	statusHolder := &clusterv1.MachineSet{Status: newStatus}
+	setMachinesReadyCondition(statusHolder, desiredReplicas, newStatus.ReadyReplicas)
+	return statusHolder.Status
+}
+
+// setMachinesReadyCondition sets the MachinesReady condition given the number of desired and ready replicas,
+// and summarizes it into the Ready condition along with the MachinesCreated condition, if any.
+func setMachinesReadyCondition(to conditions.Setter, desiredReplicas, readyReplicas int32) {
+	if readyReplicas >= desiredReplicas {
+		conditions.MarkTrue(to, clusterv1.MachinesReadyCondition)
+	} else {
+		conditions.MarkFalse(to, clusterv1.MachinesReadyCondition, clusterv1.WaitingForReadyReplicasReason, clusterv1.ConditionSeverityInfo,
+			"%d of %d replicas are ready", readyReplicas, desiredReplicas)
+	}
+	conditions.SetSummary(to, clusterv1.MachinesReadyCondition, clusterv1.MachinesCreatedCondition)
 }
 
 // updateMachineSetStatus attempts to update the Status.Replicas of the given MachineSet, with a single GET/PUT retry.
@@ -94,6 +116,7 @@ func updateMachineSetStatus(c client.Client, ms *clusterv1.MachineSet, newStatus
 		ms.Status.FullyLabeledReplicas == newStatus.FullyLabeledReplicas &&
 		ms.Status.ReadyReplicas == newStatus.ReadyReplicas &&
 		ms.Status.AvailableReplicas == newStatus.AvailableReplicas &&
+		reflect.DeepEqual(ms.Status.Conditions, newStatus.Conditions) &&
 		ms.Generation == ms.Status.ObservedGeneration {
 		return ms, nil
 	}
@@ -146,15 +169,17 @@ func (r *MachineSetReconciler) getMachineNode(cluster *clusterv1.Cluster, machin
 	}
 
 	// Otherwise, proceed to get the remote cluster client and get the Node.
-	remoteClient, err := remote.NewClusterClient(r.Client, cluster)
+	remoteClient, err := r.clusterClientGetter()(r.Client, cluster)
 	if err != nil {
 		return nil, err
 	}
 
-	corev1Remote, err := remoteClient.CoreV1()
+	workloadClient, err := remoteClient.Client()
 	if err != nil {
 		return nil, err
 	}
 
-	return corev1Remote.Nodes().Get(machine.Status.NodeRef.Name, metav1.GetOptions{})
+	node := &corev1.Node{}
+	err = workloadClient.Get(context.Background(), client.ObjectKey{Name: machine.Status.NodeRef.Name}, node)
+	return node, err
 }
@@ -20,9 +20,11 @@ import (
 	"fmt"
 	"hash"
 	"hash/fnv"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	v1 "k8s.io/api/core/v1"
@@ -34,6 +36,7 @@ import (
 	"k8s.io/klog"
 	"k8s.io/utils/integer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/conditions"
 )
 
 const (
@@ -59,6 +62,31 @@ const (
 	// PausedDeployReason is added in a deployment when it is paused. Lack of progress shouldn't be
 	// estimated once a deployment is paused.
 	PausedDeployReason = "DeploymentPaused"
+	// ResumedDeployReason is added in a deployment when it is resumed.
+	ResumedDeployReason = "DeploymentResumed"
+	// NewMachineSetReason is added in a machine deployment when it creates a new machine set.
+	NewMachineSetReason = "NewMachineSetCreated"
+	// MachineSetUpdatedReason is added in a machine deployment when one of its machine sets is updated as part
+	// of the rollout process.
+	MachineSetUpdatedReason = "MachineSetUpdated"
+	// NewMSAvailableReason is added in a machine deployment when its newest machine set is made available
+	// ie. the number of new machines that have passed readiness checks and run for at least minReadySeconds
+	// is at least the minimum available machines that need to run for the deployment.
+	NewMSAvailableReason = "NewMachineSetAvailable"
+	// TimedOutReason is added in a machine deployment when its newest machine set fails to show any progress
+	// within the given deadline (progressDeadlineSeconds).
+	TimedOutReason = "ProgressDeadlineExceeded"
+
+	//
+	// Rollback:
+	//
+	// RollbackRevisionNotFound is emitted when the revision a deployment is rolled back to can't be found.
+	RollbackRevisionNotFound = "DeploymentRollbackRevisionNotFound"
+	// RollbackTemplateUnchanged is emitted when the template of the revision a deployment is rolled back
+	// to is the same as the current one.
+	RollbackTemplateUnchanged = "DeploymentRollbackTemplateUnchanged"
+	// RollbackDone is emitted when a deployment has been rolled back.
+	RollbackDone = "DeploymentRollback"
 
 	//
 	// Available:
@@ -137,6 +165,23 @@ func MaxRevision(allMSs []*clusterv1.MachineSet) int64 {
 	return max
 }
 
+// LastRevision finds the second max revision number in all machine sets (the last revision).
+func LastRevision(allMSs []*clusterv1.MachineSet) int64 {
+	max, secMax := int64(0), int64(0)
+	for _, ms := range allMSs {
+		if v, err := Revision(ms); err != nil {
+			// Skip the machine sets when it failed to parse their revision information
+			klog.V(4).Infof("Error: %v. Couldn't parse revision for machine set %#v, deployment controller will skip it when reconciling revisions.", err, ms)
+		} else if v >= max {
+			secMax = max
+			max = v
+		} else if v > secMax {
+			secMax = v
+		}
+	}
+	return secMax
+}
+
 // Revision returns the revision number of the input object.
 func Revision(obj runtime.Object) (int64, error) {
 	acc, err := meta.Accessor(obj)
@@ -186,6 +231,34 @@ func copyDeploymentAnnotationsToMachineSet(deployment *clusterv1.MachineDeployme
 	return msAnnotationsChanged
 }
 
+// SetDeploymentAnnotationsTo sets deployment's annotations as given machine set's annotations.
+// This action should be done if and only if the deployment is rolling back to this machine set.
+// Note that apply and revision annotations are not changed.
+func SetDeploymentAnnotationsTo(deployment *clusterv1.MachineDeployment, rollbackToMS *clusterv1.MachineSet) {
+	if deployment.Annotations == nil {
+		deployment.Annotations = make(map[string]string)
+	}
+	for k := range deployment.Annotations {
+		if !skipCopyAnnotation(k) {
+			delete(deployment.Annotations, k)
+		}
+	}
+	for k, v := range rollbackToMS.Annotations {
+		if !skipCopyAnnotation(k) {
+			deployment.Annotations[k] = v
+		}
+	}
+}
+
+// SetFromMachineSetTemplate sets the desired machine template from a machine set template to the given deployment.
+func SetFromMachineSetTemplate(deployment *clusterv1.MachineDeployment, template clusterv1.MachineTemplateSpec) {
+	deployment.Spec.Template.ObjectMeta = template.ObjectMeta
+	deployment.Spec.Template.Spec = template.Spec
+	deployment.Spec.Template.ObjectMeta.Labels = CloneAndRemoveLabel(
+		deployment.Spec.Template.ObjectMeta.Labels,
+		DefaultMachineDeploymentUniqueLabelKey)
+}
+
 // GetDesiredReplicasAnnotation returns the number of desired replicas
 func GetDesiredReplicasAnnotation(ms *clusterv1.MachineSet) (int32, bool) {
 	return getIntFromAnnotation(ms, DesiredReplicasAnnotation)
@@ -500,6 +573,67 @@ func DeploymentComplete(deployment *clusterv1.MachineDeployment, newStatus *clus
 		newStatus.ObservedGeneration >= deployment.Generation
 }
 
+// HasProgressDeadline checks if the deployment has a progress deadline set.
+func HasProgressDeadline(deployment *clusterv1.MachineDeployment) bool {
+	return deployment.Spec.ProgressDeadlineSeconds != nil && *deployment.Spec.ProgressDeadlineSeconds != math.MaxInt32
+}
+
+// DeploymentProgressing reports progress for a deployment. Progress is estimated by comparing the
+// current with the new status of the deployment that the controller is observing. More specifically,
+// when new machines are scaled up or become ready or available, or old machines are scaled down, then
+// we consider the deployment is progressing.
+func DeploymentProgressing(deployment *clusterv1.MachineDeployment, newStatus *clusterv1.MachineDeploymentStatus) bool {
+	oldStatus := deployment.Status
+
+	// Old replicas that need to be scaled down
+	oldStatusOldReplicas := oldStatus.Replicas - oldStatus.UpdatedReplicas
+	newStatusOldReplicas := newStatus.Replicas - newStatus.UpdatedReplicas
+
+	return (newStatus.UpdatedReplicas > oldStatus.UpdatedReplicas) ||
+		(newStatusOldReplicas < oldStatusOldReplicas) ||
+		newStatus.ReadyReplicas > deployment.Status.ReadyReplicas ||
+		newStatus.AvailableReplicas > deployment.Status.AvailableReplicas
+}
+
+// used for unit testing
+var nowFn = func() time.Time { return time.Now() }
+
+// DeploymentTimedOut considers a deployment to have timed out once its condition that reports progress
+// is older than progressDeadlineSeconds or a Progressing condition with a TimedOutReason reason already
+// exists.
+//
+// Conditions only record the time of their last transition, so the Progressing condition is replaced
+// every time the deployment makes progress and its LastTransitionTime is the last time progress was made.
+func DeploymentTimedOut(deployment *clusterv1.MachineDeployment, newStatus *clusterv1.MachineDeploymentStatus) bool {
+	if !HasProgressDeadline(deployment) {
+		return false
+	}
+
+	// Look for the Progressing condition. If it doesn't exist, we have no base to estimate progress.
+	// If it's already set with a TimedOutReason reason, we have already timed out, no need to check
+	// again.
+	condition := conditions.Get(&clusterv1.MachineDeployment{Status: *newStatus}, clusterv1.ProgressingCondition)
+	if condition == nil {
+		return false
+	}
+
+	// If the previous condition has been a successful rollout then we shouldn't try to
+	// estimate any progress.
+	if condition.Reason == NewMSAvailableReason {
+		return false
+	}
+	if condition.Reason == TimedOutReason {
+		return true
+	}
+
+	from := condition.LastTransitionTime
+	delta := time.Duration(*deployment.Spec.ProgressDeadlineSeconds) * time.Second
+	timedOut := from.Add(delta).Before(nowFn())
+
+	klog.V(4).Infof("MachineDeployment %q timed out (%t) [last progress check: %v - now: %v]", deployment.Name, timedOut, from, nowFn())
+	return timedOut
+}
+
 // NewMSNewReplicas calculates the number of replicas a deployment's new MS should have.
 // When one of the following is true, we're rolling out the deployment; otherwise, we're scaling it.
 // 1) The new MS is saturated: newMS's replicas == deployment's replicas
@@ -524,6 +658,9 @@ func NewMSNewReplicas(deployment *clusterv1.MachineDeployment, allMSs []*cluster
 		// Do not exceed the number of desired replicas.
 		scaleUpCount = integer.Int32Min(scaleUpCount, *(deployment.Spec.Replicas)-*(newMS.Spec.Replicas))
 		return *(newMS.Spec.Replicas) + scaleUpCount, nil
+	case clusterv1.RecreateMachineDeploymentStrategyType:
+		// The new MachineSet is only scaled up once all the old Machines are gone.
+		return *(deployment.Spec.Replicas), nil
 	default:
 		// Check if we can scale up.
 		maxSurge, err := intstrutil.GetValueFromIntOrPercent(deployment.Spec.Strategy.RollingUpdate.MaxSurge, int(*(deployment.Spec.Replicas)), true)
@@ -631,6 +768,22 @@ func CloneAndAddLabel(labels map[string]string, labelKey, labelValue string) map
 	return newLabels
 }
 
+// Clones the given map and returns a new map with the given key removed.
+// Returns the given map, if labelKey is empty.
+func CloneAndRemoveLabel(labels map[string]string, labelKey string) map[string]string {
+	if labelKey == "" {
+		// Don't need to remove a label.
+		return labels
+	}
+	// Clone.
+	newLabels := map[string]string{}
+	for key, value := range labels {
+		newLabels[key] = value
+	}
+	delete(newLabels, labelKey)
+	return newLabels
+}
+
 // Clones the given selector and returns a new selector with the given key and value added.
 // Returns the given selector, if labelKey is empty.
 func CloneSelectorAndAddLabel(selector *metav1.LabelSelector, labelKey, labelValue string) *metav1.LabelSelector {
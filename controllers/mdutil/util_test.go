@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -440,6 +441,11 @@ func TestNewMSNewReplicas(t *testing.T) {
 			clusterv1.RollingUpdateMachineDeploymentStrategyType,
 			6, 2, 10, 6,
 		},
+		{
+			"recreate - to depReplicas",
+			clusterv1.RecreateMachineDeploymentStrategyType,
+			3, 1, 1, 3,
+		},
 	}
 	newDeployment := generateDeployment("nginx")
 	newRC := generateMS(newDeployment)
@@ -549,6 +555,157 @@ func TestDeploymentComplete(t *testing.T) {
 	}
 }
 
+func TestDeploymentProgressing(t *testing.T) {
+	deployment := func(current, updated, ready, available int32) *clusterv1.MachineDeployment {
+		return &clusterv1.MachineDeployment{
+			Status: clusterv1.MachineDeploymentStatus{
+				Replicas:          current,
+				UpdatedReplicas:   updated,
+				ReadyReplicas:     ready,
+				AvailableReplicas: available,
+			},
+		}
+	}
+	newStatus := func(current, updated, ready, available int32) clusterv1.MachineDeploymentStatus {
+		return clusterv1.MachineDeploymentStatus{
+			Replicas:          current,
+			UpdatedReplicas:   updated,
+			ReadyReplicas:     ready,
+			AvailableReplicas: available,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		d         *clusterv1.MachineDeployment
+		newStatus clusterv1.MachineDeploymentStatus
+		expected  bool
+	}{
+		{
+			name:      "progressing: updated machines",
+			d:         deployment(10, 4, 4, 4),
+			newStatus: newStatus(10, 6, 4, 4),
+			expected:  true,
+		},
+		{
+			name:      "not progressing",
+			d:         deployment(10, 4, 4, 4),
+			newStatus: newStatus(10, 4, 4, 4),
+			expected:  false,
+		},
+		{
+			name:      "progressing: old machines removed",
+			d:         deployment(10, 4, 6, 6),
+			newStatus: newStatus(8, 4, 6, 6),
+			expected:  true,
+		},
+		{
+			name:      "progressing: ready machines increased",
+			d:         deployment(10, 10, 4, 4),
+			newStatus: newStatus(10, 10, 5, 4),
+			expected:  true,
+		},
+		{
+			name:      "progressing: available machines increased",
+			d:         deployment(10, 10, 4, 4),
+			newStatus: newStatus(10, 10, 4, 5),
+			expected:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DeploymentProgressing(test.d, &test.newStatus); got != test.expected {
+				t.Errorf("expected progressing: %t, got: %t", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestDeploymentTimedOut(t *testing.T) {
+	var (
+		null *int32
+		ten  = int32(10)
+	)
+
+	timeFn := func(min, sec int) time.Time {
+		return time.Date(2016, 1, 1, 0, min, sec, 0, time.UTC)
+	}
+	deployment := func(condType clusterv1.ConditionType, status v1.ConditionStatus, reason string, pds *int32, from time.Time) clusterv1.MachineDeployment {
+		return clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: clusterv1.MachineDeploymentSpec{
+				ProgressDeadlineSeconds: pds,
+			},
+			Status: clusterv1.MachineDeploymentStatus{
+				Conditions: clusterv1.Conditions{
+					{
+						Type:               condType,
+						Status:             status,
+						Reason:             reason,
+						LastTransitionTime: metav1.Time{Time: from},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		d        clusterv1.MachineDeployment
+		nowFn    func() time.Time
+		expected bool
+	}{
+		{
+			name:     "no progressDeadlineSeconds specified - no timeout",
+			d:        deployment(clusterv1.ProgressingCondition, v1.ConditionTrue, "", null, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: false,
+		},
+		{
+			name:     "progressDeadlineSeconds: 10s, now - started => 00:01:20 - 00:01:09 => 11s",
+			d:        deployment(clusterv1.ProgressingCondition, v1.ConditionTrue, "", &ten, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: true,
+		},
+		{
+			name:     "progressDeadlineSeconds: 10s, now - started => 00:01:20 - 00:01:11 => 9s",
+			d:        deployment(clusterv1.ProgressingCondition, v1.ConditionTrue, "", &ten, timeFn(1, 11)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: false,
+		},
+		{
+			name:     "previous rollout completed - no timeout",
+			d:        deployment(clusterv1.ProgressingCondition, v1.ConditionTrue, NewMSAvailableReason, &ten, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: false,
+		},
+		{
+			name:     "already timed out",
+			d:        deployment(clusterv1.ProgressingCondition, v1.ConditionFalse, TimedOutReason, &ten, timeFn(1, 19)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: true,
+		},
+		{
+			name:     "no Progressing condition - no timeout",
+			d:        deployment(clusterv1.AvailableCondition, v1.ConditionTrue, "", &ten, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nowFn = test.nowFn
+			defer func() { nowFn = time.Now }()
+
+			if got := DeploymentTimedOut(&test.d, &test.d.Status); got != test.expected {
+				t.Errorf("expected timeout: %t, got: %t", test.expected, got)
+			}
+		})
+	}
+}
+
 func TestMaxUnavailable(t *testing.T) {
 	deployment := func(replicas int32, maxUnavailable intstr.IntOrString) clusterv1.MachineDeployment {
 		return clusterv1.MachineDeployment{
@@ -748,3 +905,40 @@ func TestReplicasAnnotationsNeedUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestLastRevision(t *testing.T) {
+	newMS := func(revision string) *clusterv1.MachineSet {
+		ms := generateMS(generateDeployment("nginx"))
+		ms.Annotations = map[string]string{RevisionAnnotation: revision}
+		return &ms
+	}
+
+	tests := []struct {
+		name     string
+		msList   []*clusterv1.MachineSet
+		expected int64
+	}{
+		{
+			name:     "no machine sets",
+			expected: 0,
+		},
+		{
+			name:     "only one revision",
+			msList:   []*clusterv1.MachineSet{newMS("3")},
+			expected: 0,
+		},
+		{
+			name:     "second max revision",
+			msList:   []*clusterv1.MachineSet{newMS("2"), newMS("5"), newMS("4")},
+			expected: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if rev := LastRevision(test.msList); rev != test.expected {
+				t.Errorf("LastRevision() = %d, expected %d", rev, test.expected)
+			}
+		})
+	}
+}
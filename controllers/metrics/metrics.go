@@ -0,0 +1,196 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics contains the Prometheus collectors of the Cluster API controllers. They are registered
+// to the controller-runtime registry, and served on the metrics endpoint of the manager.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "capi"
+
+var (
+	// provisioningBuckets range from 10 seconds to about 45 minutes.
+	provisioningBuckets = prometheus.ExponentialBuckets(10, 1.5, 15)
+
+	// MachineBootstrapReadySeconds observes the time from the creation of a Machine to its bootstrap data being ready.
+	MachineBootstrapReadySeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "machine_bootstrap_ready_seconds",
+		Help:      "Time in seconds from the creation of a Machine to its bootstrap data being ready.",
+		Buckets:   provisioningBuckets,
+	})
+
+	// MachineInfrastructureReadySeconds observes the time from the creation of a Machine to its infrastructure being ready.
+	MachineInfrastructureReadySeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "machine_infrastructure_ready_seconds",
+		Help:      "Time in seconds from the creation of a Machine to its infrastructure being ready.",
+		Buckets:   provisioningBuckets,
+	})
+
+	// MachineNodeRefSeconds observes the time from the creation of a Machine to it being linked to its Node.
+	MachineNodeRefSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "machine_noderef_seconds",
+		Help:      "Time in seconds from the creation of a Machine to its NodeRef being set.",
+		Buckets:   provisioningBuckets,
+	})
+
+	// MachineSetReplicas, MachineSetReadyReplicas and MachineSetAvailableReplicas report the status of
+	// each MachineSet, MachineSetDesiredReplicas reports its spec.
+	MachineSetDesiredReplicas   = newReplicasGauge("machineset", "desired", "Number of desired replicas of a MachineSet.")
+	MachineSetReplicas          = newReplicasGauge("machineset", "", "Number of replicas of a MachineSet.")
+	MachineSetReadyReplicas     = newReplicasGauge("machineset", "ready", "Number of ready replicas of a MachineSet.")
+	MachineSetAvailableReplicas = newReplicasGauge("machineset", "available", "Number of available replicas of a MachineSet.")
+
+	// MachineDeploymentDesiredReplicas, MachineDeploymentReplicas, MachineDeploymentUpdatedReplicas,
+	// MachineDeploymentReadyReplicas and MachineDeploymentAvailableReplicas report the replicas of each MachineDeployment.
+	MachineDeploymentDesiredReplicas   = newReplicasGauge("machinedeployment", "desired", "Number of desired replicas of a MachineDeployment.")
+	MachineDeploymentReplicas          = newReplicasGauge("machinedeployment", "", "Number of replicas of a MachineDeployment.")
+	MachineDeploymentUpdatedReplicas   = newReplicasGauge("machinedeployment", "updated", "Number of up-to-date replicas of a MachineDeployment.")
+	MachineDeploymentReadyReplicas     = newReplicasGauge("machinedeployment", "ready", "Number of ready replicas of a MachineDeployment.")
+	MachineDeploymentAvailableReplicas = newReplicasGauge("machinedeployment", "available", "Number of available replicas of a MachineDeployment.")
+
+	// ExternalObjectReconcileErrors counts the errors reconciling the provider objects referenced by
+	// Cluster API objects, by group, version and kind of the provider object.
+	ExternalObjectReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "external_object_reconcile_errors_total",
+		Help:      "Number of errors reconciling external objects, by group, version and kind.",
+	}, []string{"group", "version", "kind"})
+
+	// RemoteClusterClientErrors counts the errors creating or using clients for workload clusters, by Cluster.
+	RemoteClusterClientErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "remote_cluster_client_errors_total",
+		Help:      "Number of errors creating or using clients for workload clusters, by Cluster.",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		MachineBootstrapReadySeconds,
+		MachineInfrastructureReadySeconds,
+		MachineNodeRefSeconds,
+		MachineSetDesiredReplicas,
+		MachineSetReplicas,
+		MachineSetReadyReplicas,
+		MachineSetAvailableReplicas,
+		MachineDeploymentDesiredReplicas,
+		MachineDeploymentReplicas,
+		MachineDeploymentUpdatedReplicas,
+		MachineDeploymentReadyReplicas,
+		MachineDeploymentAvailableReplicas,
+		ExternalObjectReconcileErrors,
+		RemoteClusterClientErrors,
+	)
+}
+
+func newReplicasGauge(subsystem, kind, help string) *prometheus.GaugeVec {
+	name := "replicas"
+	if kind != "" {
+		name = kind + "_" + name
+	}
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, []string{"namespace", "name"})
+}
+
+// ObserveMachineProvisioning observes the provisioning milestones reached by the Machine since its previous
+// status, measured from the creation of the Machine.
+func ObserveMachineProvisioning(previous *clusterv1.MachineStatus, m *clusterv1.Machine) {
+	elapsed := time.Since(m.CreationTimestamp.Time).Seconds()
+	if !previous.BootstrapReady && m.Status.BootstrapReady {
+		MachineBootstrapReadySeconds.Observe(elapsed)
+	}
+	if !previous.InfrastructureReady && m.Status.InfrastructureReady {
+		MachineInfrastructureReadySeconds.Observe(elapsed)
+	}
+	if previous.NodeRef == nil && m.Status.NodeRef != nil {
+		MachineNodeRefSeconds.Observe(elapsed)
+	}
+}
+
+// SetMachineSetReplicas reports the replicas of the MachineSet.
+func SetMachineSetReplicas(ms *clusterv1.MachineSet) {
+	var desired int32
+	if ms.Spec.Replicas != nil {
+		desired = *ms.Spec.Replicas
+	}
+	MachineSetDesiredReplicas.WithLabelValues(ms.Namespace, ms.Name).Set(float64(desired))
+	MachineSetReplicas.WithLabelValues(ms.Namespace, ms.Name).Set(float64(ms.Status.Replicas))
+	MachineSetReadyReplicas.WithLabelValues(ms.Namespace, ms.Name).Set(float64(ms.Status.ReadyReplicas))
+	MachineSetAvailableReplicas.WithLabelValues(ms.Namespace, ms.Name).Set(float64(ms.Status.AvailableReplicas))
+}
+
+// DeleteMachineSetReplicas stops reporting the replicas of a deleted MachineSet.
+func DeleteMachineSetReplicas(namespace, name string) {
+	for _, g := range []*prometheus.GaugeVec{
+		MachineSetDesiredReplicas, MachineSetReplicas, MachineSetReadyReplicas, MachineSetAvailableReplicas,
+	} {
+		g.DeleteLabelValues(namespace, name)
+	}
+}
+
+// SetMachineDeploymentReplicas reports the replicas of the MachineDeployment.
+func SetMachineDeploymentReplicas(d *clusterv1.MachineDeployment) {
+	var desired int32
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	MachineDeploymentDesiredReplicas.WithLabelValues(d.Namespace, d.Name).Set(float64(desired))
+	MachineDeploymentReplicas.WithLabelValues(d.Namespace, d.Name).Set(float64(d.Status.Replicas))
+	MachineDeploymentUpdatedReplicas.WithLabelValues(d.Namespace, d.Name).Set(float64(d.Status.UpdatedReplicas))
+	MachineDeploymentReadyReplicas.WithLabelValues(d.Namespace, d.Name).Set(float64(d.Status.ReadyReplicas))
+	MachineDeploymentAvailableReplicas.WithLabelValues(d.Namespace, d.Name).Set(float64(d.Status.AvailableReplicas))
+}
+
+// DeleteMachineDeploymentReplicas stops reporting the replicas of a deleted MachineDeployment.
+func DeleteMachineDeploymentReplicas(namespace, name string) {
+	for _, g := range []*prometheus.GaugeVec{
+		MachineDeploymentDesiredReplicas, MachineDeploymentReplicas, MachineDeploymentUpdatedReplicas,
+		MachineDeploymentReadyReplicas, MachineDeploymentAvailableReplicas,
+	} {
+		g.DeleteLabelValues(namespace, name)
+	}
+}
+
+// RecordExternalObjectReconcileError counts an error reconciling an external object of the given kind.
+func RecordExternalObjectReconcileError(gvk schema.GroupVersionKind) {
+	ExternalObjectReconcileErrors.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Inc()
+}
+
+// RecordRemoteClusterClientError counts an error creating or using a client for the workload cluster
+// of the Cluster with the given namespace and name.
+func RecordRemoteClusterClientError(namespace, name string) {
+	RemoteClusterClientErrors.WithLabelValues(namespace, name).Inc()
+}
+
+// DeleteRemoteClusterClientErrors stops reporting the workload cluster client errors of a deleted Cluster.
+func DeleteRemoteClusterClientErrors(namespace, name string) {
+	RemoteClusterClientErrors.DeleteLabelValues(namespace, name)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMachineSetReplicas(t *testing.T) {
+	ms := &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "ms", Namespace: "default"},
+		Spec:       clusterv1.MachineSetSpec{Replicas: pointer.Int32Ptr(3)},
+		Status:     clusterv1.MachineSetStatus{Replicas: 2, ReadyReplicas: 1},
+	}
+
+	SetMachineSetReplicas(ms)
+	if got := testutil.ToFloat64(MachineSetDesiredReplicas.WithLabelValues("default", "ms")); got != 3 {
+		t.Errorf("expected 3 desired replicas, got %v", got)
+	}
+	if got := testutil.ToFloat64(MachineSetReplicas.WithLabelValues("default", "ms")); got != 2 {
+		t.Errorf("expected 2 replicas, got %v", got)
+	}
+	if got := testutil.ToFloat64(MachineSetReadyReplicas.WithLabelValues("default", "ms")); got != 1 {
+		t.Errorf("expected 1 ready replica, got %v", got)
+	}
+
+	DeleteMachineSetReplicas("default", "ms")
+	if err := testutil.CollectAndCompare(MachineSetReplicas, strings.NewReader("")); err != nil {
+		t.Errorf("expected the replicas of the deleted MachineSet not to be reported: %v", err)
+	}
+}
+
+func TestRemoteClusterClientErrors(t *testing.T) {
+	RecordRemoteClusterClientError("default", "cluster")
+	RecordRemoteClusterClientError("default", "cluster")
+	if got := testutil.ToFloat64(RemoteClusterClientErrors.WithLabelValues("default", "cluster")); got != 2 {
+		t.Errorf("expected 2 errors, got %v", got)
+	}
+
+	DeleteRemoteClusterClientErrors("default", "cluster")
+	if err := testutil.CollectAndCompare(RemoteClusterClientErrors, strings.NewReader("")); err != nil {
+		t.Errorf("expected the errors of the deleted Cluster not to be reported: %v", err)
+	}
+}
+
+func TestPhaseCollector(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+	c := fake.NewFakeClient(
+		&clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"},
+			Status:     clusterv1.ClusterStatus{Phase: string(clusterv1.ClusterPhaseProvisioned)},
+		},
+		&clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+			Status:     clusterv1.MachineStatus{Phase: string(clusterv1.MachinePhaseRunning)},
+		},
+		&clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default"},
+		},
+	)
+
+	expected := `
+# HELP capi_clusters Number of Clusters by phase.
+# TYPE capi_clusters gauge
+capi_clusters{phase="deleting"} 0
+capi_clusters{phase="failed"} 0
+capi_clusters{phase="pending"} 0
+capi_clusters{phase="provisioned"} 1
+capi_clusters{phase="provisioning"} 0
+# HELP capi_machines Number of Machines by phase.
+# TYPE capi_machines gauge
+capi_machines{phase="deleting"} 0
+capi_machines{phase="failed"} 0
+capi_machines{phase="pending"} 0
+capi_machines{phase="provisioned"} 0
+capi_machines{phase="provisioning"} 0
+capi_machines{phase="running"} 1
+capi_machines{phase="unknown"} 1
+`
+	if err := testutil.CollectAndCompare(&phaseCollector{reader: c}, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	clusterPhasesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "clusters"),
+		"Number of Clusters by phase.",
+		[]string{"phase"}, nil,
+	)
+
+	machinePhasesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "machines"),
+		"Number of Machines by phase.",
+		[]string{"phase"}, nil,
+	)
+
+	clusterPhases = []clusterv1.ClusterPhase{
+		clusterv1.ClusterPhasePending,
+		clusterv1.ClusterPhaseProvisioning,
+		clusterv1.ClusterPhaseProvisioned,
+		clusterv1.ClusterPhaseDeleting,
+		clusterv1.ClusterPhaseFailed,
+	}
+
+	machinePhases = []clusterv1.MachinePhase{
+		clusterv1.MachinePhasePending,
+		clusterv1.MachinePhaseProvisioning,
+		clusterv1.MachinePhaseProvisioned,
+		clusterv1.MachinePhaseRunning,
+		clusterv1.MachinePhaseDeleting,
+		clusterv1.MachinePhaseFailed,
+	}
+)
+
+// unknownPhase is the phase reported for the objects that don't have a phase yet.
+const unknownPhase = "unknown"
+
+// phaseCollector counts the Clusters and Machines by phase when scraped, listing them from the given reader,
+// usually the cached client of the manager.
+type phaseCollector struct {
+	reader client.Reader
+}
+
+// RegisterPhaseCollector registers a collector counting the Clusters and Machines by phase.
+func RegisterPhaseCollector(reader client.Reader) error {
+	return ctrlmetrics.Registry.Register(&phaseCollector{reader: reader})
+}
+
+func (c *phaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- clusterPhasesDesc
+	ch <- machinePhasesDesc
+}
+
+func (c *phaseCollector) Collect(ch chan<- prometheus.Metric) {
+	clusters := &clusterv1.ClusterList{}
+	if err := c.reader.List(context.Background(), clusters); err != nil {
+		klog.Errorf("Failed to list Clusters for metrics: %v", err)
+		ch <- prometheus.NewInvalidMetric(clusterPhasesDesc, err)
+	} else {
+		counts := map[string]int{}
+		for _, phase := range clusterPhases {
+			counts[string(phase)] = 0
+		}
+		for i := range clusters.Items {
+			counts[phaseLabel(clusters.Items[i].Status.Phase)]++
+		}
+		collectCounts(ch, clusterPhasesDesc, counts)
+	}
+
+	machines := &clusterv1.MachineList{}
+	if err := c.reader.List(context.Background(), machines); err != nil {
+		klog.Errorf("Failed to list Machines for metrics: %v", err)
+		ch <- prometheus.NewInvalidMetric(machinePhasesDesc, err)
+	} else {
+		counts := map[string]int{}
+		for _, phase := range machinePhases {
+			counts[string(phase)] = 0
+		}
+		for i := range machines.Items {
+			counts[phaseLabel(machines.Items[i].Status.Phase)]++
+		}
+		collectCounts(ch, machinePhasesDesc, counts)
+	}
+}
+
+func phaseLabel(phase string) string {
+	if phase == "" {
+		return unknownPhase
+	}
+	return phase
+}
+
+func collectCounts(ch chan<- prometheus.Metric, desc *prometheus.Desc, counts map[string]int) {
+	for phase, count := range counts {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(count), phase)
+	}
+}
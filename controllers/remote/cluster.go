@@ -18,10 +18,12 @@ package remote
 
 import (
 	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes/scheme"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
 	kcfg "sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -29,18 +31,36 @@ import (
 // ClusterClient is an interface encapsulating methods
 // to access a remote cluster.
 type ClusterClient interface {
+	// RESTConfig returns the configuration used to connect to the workload cluster.
 	RESTConfig() *restclient.Config
+
+	// CoreV1 returns a typed client that always talks directly to the workload cluster API server.
 	CoreV1() (corev1.CoreV1Interface, error)
+
+	// Client returns a controller-runtime client, which may read objects from informers.
+	Client() (client.Client, error)
 }
 
+// ClusterClientGetter returns a ClusterClient for the given Cluster.
+// NewClusterClient is the default implementation, which can be replaced in tests.
+type ClusterClientGetter func(c client.Client, cluster *clusterv1.Cluster) (ClusterClient, error)
+
 // clusterClient is a helper struct to connect to remote workload clusters.
 type clusterClient struct {
 	restConfig *restclient.Config
 	cluster    *clusterv1.Cluster
 }
 
+var _ ClusterClientGetter = NewClusterClient
+
 // NewClusterClient creates a new ClusterClient.
-func NewClusterClient(c client.Client, cluster *clusterv1.Cluster) (ClusterClient, error) {
+func NewClusterClient(c client.Client, cluster *clusterv1.Cluster) (_ ClusterClient, reterr error) {
+	defer func() {
+		if reterr != nil {
+			metrics.RecordRemoteClusterClientError(cluster.Namespace, cluster.Name)
+		}
+	}()
+
 	kubeconfig, err := kcfg.FromSecret(c, cluster)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to retrieve kubeconfig secret for Cluster %q in namespace %q",
@@ -68,3 +88,8 @@ func (c *clusterClient) RESTConfig() *restclient.Config {
 func (c *clusterClient) CoreV1() (corev1.CoreV1Interface, error) {
 	return corev1.NewForConfig(c.RESTConfig())
 }
+
+// Client returns a new controller-runtime client, reading objects directly from the API server.
+func (c *clusterClient) Client() (client.Client, error) {
+	return client.New(c.RESTConfig(), client.Options{Scheme: scheme.Scheme})
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	apicorev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	defaultHealthCheckInterval         = 10 * time.Second
+	defaultHealthCheckTimeout          = 10 * time.Second
+	defaultHealthCheckFailureThreshold = 5
+	defaultUnreachableBackoff          = time.Minute
+)
+
+// ErrClusterUnreachable is returned when the API server of a workload cluster failed too many health checks in a row.
+var ErrClusterUnreachable = errors.New("cluster is unreachable")
+
+// ClusterCacheTrackerOptions defines the options of a ClusterCacheTracker.
+type ClusterCacheTrackerOptions struct {
+	// Scheme is the scheme used by the workload cluster clients, defaults to the client-go scheme.
+	Scheme *runtime.Scheme
+
+	// HealthCheckInterval is the interval between health checks of the workload cluster API servers.
+	HealthCheckInterval time.Duration
+
+	// HealthCheckTimeout is the timeout of a single health check.
+	HealthCheckTimeout time.Duration
+
+	// HealthCheckFailureThreshold is the number of consecutive failed health checks after which
+	// a workload cluster is considered unreachable and its client is torn down.
+	HealthCheckFailureThreshold int
+
+	// UnreachableBackoff is the time during which no new client is created for an unreachable cluster.
+	UnreachableBackoff time.Duration
+}
+
+// ClusterCacheTracker keeps the clients of each workload cluster, so that reconcilers don't have to build
+// new clients for every request. Only the controller-runtime client returned by Client reads from informers,
+// the CoreV1 client is reused but still sends every request to the workload cluster API server.
+//
+// A client is invalidated when the kubeconfig Secret of its Cluster changes, torn down when the API
+// server of the workload cluster fails repeated health checks, and removed when the Cluster is deleted.
+type ClusterCacheTracker struct {
+	log     logr.Logger
+	client  client.Client
+	options ClusterCacheTrackerOptions
+
+	lock             sync.Mutex
+	clusterAccessors map[client.ObjectKey]*clusterAccessor
+	unreachable      map[client.ObjectKey]time.Time
+}
+
+// NewClusterCacheTracker creates a new ClusterCacheTracker, using the given client to read the kubeconfig Secrets.
+func NewClusterCacheTracker(log logr.Logger, c client.Client, options ClusterCacheTrackerOptions) *ClusterCacheTracker {
+	if options.Scheme == nil {
+		options.Scheme = scheme.Scheme
+	}
+	if options.HealthCheckInterval == 0 {
+		options.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if options.HealthCheckTimeout == 0 {
+		options.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+	if options.HealthCheckFailureThreshold == 0 {
+		options.HealthCheckFailureThreshold = defaultHealthCheckFailureThreshold
+	}
+	if options.UnreachableBackoff == 0 {
+		options.UnreachableBackoff = defaultUnreachableBackoff
+	}
+
+	return &ClusterCacheTracker{
+		log:              log,
+		client:           c,
+		options:          options,
+		clusterAccessors: make(map[client.ObjectKey]*clusterAccessor),
+		unreachable:      make(map[client.ObjectKey]time.Time),
+	}
+}
+
+var _ ClusterClientGetter = (&ClusterCacheTracker{}).ClusterClient
+
+// ClusterClient returns the cached ClusterClient for the given Cluster, creating it if needed.
+// It can be used wherever a ClusterClientGetter is expected.
+func (t *ClusterCacheTracker) ClusterClient(c client.Client, cluster *clusterv1.Cluster) (ClusterClient, error) {
+	kubeconfigSecret, err := t.getKubeconfigSecret(c, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.getClusterAccessorLocked(cluster, kubeconfigSecret)
+}
+
+// WatchInput specifies the parameters used to establish a new watch for a workload cluster.
+type WatchInput struct {
+	// Name identifies the watch, a watch with the same name is only established once per workload cluster client.
+	Name string
+
+	// Cluster is the Cluster to watch.
+	Cluster *clusterv1.Cluster
+
+	// Watcher is the watcher, usually a controller, that receives the events.
+	Watcher Watcher
+
+	// Kind is the type of the objects to watch in the workload cluster.
+	Kind runtime.Object
+
+	// EventHandler maps the events to reconcile requests.
+	EventHandler handler.EventHandler
+
+	// Predicates filter the events.
+	Predicates []predicate.Predicate
+}
+
+// Watcher is a scoped-down interface from controller.Controller, used to add watches.
+type Watcher interface {
+	Watch(src source.Source, eventhandler handler.EventHandler, predicates ...predicate.Predicate) error
+}
+
+// Watch establishes a watch on the given kind in the workload cluster, backed by the informers of its cached
+// client. The watch stops when the client is torn down, and is established again on the next call to Watch
+// once the client has been recreated; callers are expected to call Watch on every reconcile.
+func (t *ClusterCacheTracker) Watch(c client.Client, input WatchInput) error {
+	if input.Name == "" {
+		return errors.New("input.Name is required")
+	}
+
+	kubeconfigSecret, err := t.getKubeconfigSecret(c, input.Cluster)
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	accessor, err := t.getClusterAccessorLocked(input.Cluster, kubeconfigSecret)
+	if err != nil {
+		t.lock.Unlock()
+		return err
+	}
+	if _, ok := accessor.watches[input.Name]; ok {
+		t.lock.Unlock()
+		return nil
+	}
+	accessor.watches[input.Name] = struct{}{}
+	t.lock.Unlock()
+
+	// Starting the watch waits for the informer to sync, don't hold the lock in the meantime.
+	// If the workload cluster is unreachable, the health check eventually tears down the client,
+	// which unblocks the call.
+	src := &source.Kind{Type: input.Kind}
+	if err := src.InjectCache(accessor.cache); err != nil {
+		return err
+	}
+	if err := input.Watcher.Watch(src, input.EventHandler, input.Predicates...); err != nil {
+		t.lock.Lock()
+		delete(accessor.watches, input.Name)
+		t.lock.Unlock()
+		return errors.Wrapf(err, "failed to add watch %q for Cluster %q in namespace %q",
+			input.Name, input.Cluster.Name, input.Cluster.Namespace)
+	}
+	return nil
+}
+
+// getKubeconfigSecret reads the kubeconfig Secret of the given Cluster. It's called before taking the lock,
+// so that a slow read from the management cluster doesn't block the clients of the other Clusters.
+func (t *ClusterCacheTracker) getKubeconfigSecret(c client.Client, cluster *clusterv1.Cluster) (*apicorev1.Secret, error) {
+	kubeconfigSecret, err := secret.Get(c, cluster, secret.Kubeconfig)
+	if err != nil {
+		metrics.RecordRemoteClusterClientError(cluster.Namespace, cluster.Name)
+		return nil, errors.Wrapf(err, "failed to retrieve kubeconfig secret for Cluster %q in namespace %q",
+			cluster.Name, cluster.Namespace)
+	}
+	return kubeconfigSecret, nil
+}
+
+func (t *ClusterCacheTracker) getClusterAccessorLocked(cluster *clusterv1.Cluster, kubeconfigSecret *apicorev1.Secret) (_ *clusterAccessor, reterr error) {
+	defer func() {
+		if reterr != nil {
+			metrics.RecordRemoteClusterClientError(cluster.Namespace, cluster.Name)
+		}
+	}()
+
+	key := client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}
+
+	if accessor, ok := t.clusterAccessors[key]; ok {
+		if accessor.kubeconfigVersion == kubeconfigSecret.ResourceVersion {
+			return accessor, nil
+		}
+		// The kubeconfig has changed, the client needs to be recreated.
+		t.log.Info("Kubeconfig of workload cluster has changed, recreating its client", "cluster", key.String())
+		t.deleteAccessorLocked(key)
+	}
+
+	if since, ok := t.unreachable[key]; ok && time.Since(since) < t.options.UnreachableBackoff {
+		return nil, errors.Wrapf(ErrClusterUnreachable, "failed to create client for Cluster %q in namespace %q",
+			cluster.Name, cluster.Namespace)
+	}
+
+	accessor, err := t.newClusterAccessor(kubeconfigSecret.Data[secret.KubeconfigDataName])
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create client for Cluster %q in namespace %q",
+			cluster.Name, cluster.Namespace)
+	}
+	accessor.kubeconfigVersion = kubeconfigSecret.ResourceVersion
+
+	t.clusterAccessors[key] = accessor
+	delete(t.unreachable, key)
+
+	go accessor.cache.Start(accessor.stop)
+	go t.healthCheckCluster(key, accessor)
+
+	return accessor, nil
+}
+
+// DeleteAccessor stops and removes the cached client of the given Cluster, if any.
+func (t *ClusterCacheTracker) DeleteAccessor(key client.ObjectKey) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.deleteAccessorLocked(key)
+	delete(t.unreachable, key)
+}
+
+func (t *ClusterCacheTracker) deleteAccessorLocked(key client.ObjectKey) {
+	accessor, ok := t.clusterAccessors[key]
+	if !ok {
+		return
+	}
+
+	close(accessor.stop)
+	delete(t.clusterAccessors, key)
+}
+
+func (t *ClusterCacheTracker) newClusterAccessor(kubeconfig []byte) (*clusterAccessor, error) {
+	if len(kubeconfig) == 0 {
+		return nil, errors.Errorf("missing key %q in secret data", secret.KubeconfigDataName)
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	coreV1, err := corev1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use a lazy mapper, so that the workload cluster isn't contacted until the client is used.
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	c, err := client.New(restConfig, client.Options{Scheme: t.options.Scheme, Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+
+	informers, err := cache.New(restConfig, cache.Options{Scheme: t.options.Scheme, Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+
+	return &clusterAccessor{
+		restConfig: restConfig,
+		coreV1:     coreV1,
+		cache:      informers,
+		client: &client.DelegatingClient{
+			Reader: &client.DelegatingReader{
+				CacheReader:  informers,
+				ClientReader: c,
+			},
+			Writer:       c,
+			StatusClient: c,
+		},
+		watches: make(map[string]struct{}),
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// healthCheckCluster periodically checks the API server of the workload cluster, until the client is torn down.
+func (t *ClusterCacheTracker) healthCheckCluster(key client.ObjectKey, accessor *clusterAccessor) {
+	failures := 0
+	_ = wait.PollUntil(t.options.HealthCheckInterval, func() (bool, error) {
+		_, err := accessor.coreV1.RESTClient().Get().AbsPath("/").Timeout(t.options.HealthCheckTimeout).DoRaw()
+		if err == nil {
+			failures = 0
+			return false, nil
+		}
+
+		failures++
+		metrics.RecordRemoteClusterClientError(key.Namespace, key.Name)
+		t.log.V(4).Info("Workload cluster failed health check", "cluster", key.String(), "failures", failures, "error", err.Error())
+		return t.healthCheckFailed(key, accessor, failures), nil
+	}, accessor.stop)
+}
+
+// healthCheckFailed marks the given cluster as unreachable and tears down its client once the number of
+// consecutive failed health checks reaches the threshold. It returns true if the client has been torn down.
+func (t *ClusterCacheTracker) healthCheckFailed(key client.ObjectKey, accessor *clusterAccessor, failures int) bool {
+	if failures < t.options.HealthCheckFailureThreshold {
+		return false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// The client could have been replaced in the meantime.
+	if t.clusterAccessors[key] == accessor {
+		t.log.Info("Workload cluster is unreachable, removing its client", "cluster", key.String(), "failures", failures)
+		t.deleteAccessorLocked(key)
+		t.unreachable[key] = time.Now()
+	}
+	return true
+}
+
+// clusterAccessor holds the cached clients of a workload cluster.
+type clusterAccessor struct {
+	restConfig        *restclient.Config
+	coreV1            corev1.CoreV1Interface
+	cache             cache.Cache
+	client            client.Client
+	kubeconfigVersion string
+	watches           map[string]struct{}
+	stop              chan struct{}
+}
+
+var _ ClusterClient = &clusterAccessor{}
+
+// RESTConfig returns the configuration used to connect to the workload cluster.
+func (a *clusterAccessor) RESTConfig() *restclient.Config {
+	return a.restConfig
+}
+
+// CoreV1 returns the Kubernetes CoreV1 client of the workload cluster. The client is created once and reused,
+// but it isn't backed by the informers: every call reaches the workload cluster API server.
+func (a *clusterAccessor) CoreV1() (corev1.CoreV1Interface, error) {
+	return a.coreV1, nil
+}
+
+// Client returns the cached client, which reads objects from informers started on first use.
+func (a *clusterAccessor) Client() (client.Client, error) {
+	return a.client, nil
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func newTestTracker(c client.Client) *ClusterCacheTracker {
+	return NewClusterCacheTracker(log.Log, c, ClusterCacheTrackerOptions{
+		// Keep the health checks out of the way, they are exercised through healthCheckFailed.
+		HealthCheckInterval:         time.Hour,
+		HealthCheckFailureThreshold: 2,
+	})
+}
+
+func isClosed(stop chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestClusterCacheTrackerClusterClient(t *testing.T) {
+	key := client.ObjectKey{Namespace: clusterWithValidKubeConfig.Namespace, Name: clusterWithValidKubeConfig.Name}
+
+	t.Run("client is cached", func(t *testing.T) {
+		c := fake.NewFakeClient(validSecret.DeepCopy())
+		tracker := newTestTracker(c)
+		defer tracker.DeleteAccessor(key)
+
+		first, err := tracker.ClusterClient(c, clusterWithValidKubeConfig)
+		if err != nil {
+			t.Fatalf("Expected no errors, got %v", err)
+		}
+		if first.RESTConfig().Host != "https://test-cluster-api:6443" {
+			t.Fatalf("Unexpected Host value in RESTConfig: %q", first.RESTConfig().Host)
+		}
+		if _, err := first.Client(); err != nil {
+			t.Fatalf("Expected no errors, got %v", err)
+		}
+
+		second, err := tracker.ClusterClient(c, clusterWithValidKubeConfig)
+		if err != nil {
+			t.Fatalf("Expected no errors, got %v", err)
+		}
+		if first != second {
+			t.Fatal("Expected the client to be reused")
+		}
+	})
+
+	t.Run("client is recreated when the kubeconfig changes", func(t *testing.T) {
+		c := fake.NewFakeClient(validSecret.DeepCopy())
+		tracker := newTestTracker(c)
+		defer tracker.DeleteAccessor(key)
+
+		first, err := tracker.ClusterClient(c, clusterWithValidKubeConfig)
+		if err != nil {
+			t.Fatalf("Expected no errors, got %v", err)
+		}
+
+		updated := validSecret.DeepCopy()
+		updated.ResourceVersion = "2"
+		updated.Data[secret.KubeconfigDataName] = []byte(strings.Replace(validKubeConfig, "test-cluster-api:6443", "test-cluster-api:7443", 1))
+		if err := c.Update(context.Background(), updated); err != nil {
+			t.Fatal(err)
+		}
+
+		second, err := tracker.ClusterClient(c, clusterWithValidKubeConfig)
+		if err != nil {
+			t.Fatalf("Expected no errors, got %v", err)
+		}
+		if second.RESTConfig().Host != "https://test-cluster-api:7443" {
+			t.Fatalf("Unexpected Host value in RESTConfig: %q", second.RESTConfig().Host)
+		}
+		if !isClosed(first.(*clusterAccessor).stop) {
+			t.Fatal("Expected the previous client to be stopped")
+		}
+	})
+
+	t.Run("cluster with no kubeconfig", func(t *testing.T) {
+		c := fake.NewFakeClient()
+		tracker := newTestTracker(c)
+
+		_, err := tracker.ClusterClient(c, clusterWithNoKubeConfig)
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("Expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("cluster is unreachable after repeated health check failures", func(t *testing.T) {
+		c := fake.NewFakeClient(validSecret.DeepCopy())
+		tracker := newTestTracker(c)
+		defer tracker.DeleteAccessor(key)
+
+		cc, err := tracker.ClusterClient(c, clusterWithValidKubeConfig)
+		if err != nil {
+			t.Fatalf("Expected no errors, got %v", err)
+		}
+		accessor := cc.(*clusterAccessor)
+
+		if tracker.healthCheckFailed(key, accessor, 1) {
+			t.Fatal("Expected the client to be kept below the failure threshold")
+		}
+		if !tracker.healthCheckFailed(key, accessor, 2) {
+			t.Fatal("Expected the client to be torn down at the failure threshold")
+		}
+		if !isClosed(accessor.stop) {
+			t.Fatal("Expected the client to be stopped")
+		}
+
+		_, err = tracker.ClusterClient(c, clusterWithValidKubeConfig)
+		if errors.Cause(err) != ErrClusterUnreachable {
+			t.Fatalf("Expected unreachable error, got %v", err)
+		}
+
+		// Deleting the accessor clears the unreachable mark.
+		tracker.DeleteAccessor(key)
+		if _, err := tracker.ClusterClient(c, clusterWithValidKubeConfig); err != nil {
+			t.Fatalf("Expected no errors, got %v", err)
+		}
+	})
+}
+
+func TestClusterCacheReconciler(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+	key := client.ObjectKey{Namespace: clusterWithValidKubeConfig.Namespace, Name: clusterWithValidKubeConfig.Name}
+
+	c := fake.NewFakeClient(validSecret.DeepCopy(), clusterWithValidKubeConfig.DeepCopy())
+	tracker := newTestTracker(c)
+	defer tracker.DeleteAccessor(key)
+
+	cc, err := tracker.ClusterClient(c, clusterWithValidKubeConfig)
+	if err != nil {
+		t.Fatalf("Expected no errors, got %v", err)
+	}
+
+	r := &ClusterCacheReconciler{Client: c, Log: log.Log, Tracker: tracker}
+
+	// The client is kept while the Cluster exists.
+	if _, err := r.Reconcile(ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Expected no errors, got %v", err)
+	}
+	if isClosed(cc.(*clusterAccessor).stop) {
+		t.Fatal("Expected the client to be kept")
+	}
+
+	if err := c.Delete(context.Background(), clusterWithValidKubeConfig.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("Expected no errors, got %v", err)
+	}
+	if !isClosed(cc.(*clusterAccessor).stop) {
+		t.Fatal("Expected the client to be stopped")
+	}
+}
+
+type testWatcher struct {
+	watches int
+}
+
+func (w *testWatcher) Watch(_ source.Source, _ handler.EventHandler, _ ...predicate.Predicate) error {
+	w.watches++
+	return nil
+}
+
+func TestClusterCacheTrackerWatch(t *testing.T) {
+	key := client.ObjectKey{Namespace: clusterWithValidKubeConfig.Namespace, Name: clusterWithValidKubeConfig.Name}
+
+	c := fake.NewFakeClient(validSecret.DeepCopy())
+	tracker := newTestTracker(c)
+	defer tracker.DeleteAccessor(key)
+
+	watcher := &testWatcher{}
+	input := WatchInput{
+		Name:         "test-watchNodes",
+		Cluster:      clusterWithValidKubeConfig,
+		Watcher:      watcher,
+		Kind:         &corev1.Node{},
+		EventHandler: &handler.EnqueueRequestForObject{},
+	}
+
+	// The watch is only established once per client.
+	for i := 0; i < 2; i++ {
+		if err := tracker.Watch(c, input); err != nil {
+			t.Fatalf("Expected no errors, got %v", err)
+		}
+	}
+	if watcher.watches != 1 {
+		t.Fatalf("Expected 1 watch, got %d", watcher.watches)
+	}
+
+	// The watch is established again once the client has been recreated.
+	tracker.DeleteAccessor(key)
+	if err := tracker.Watch(c, input); err != nil {
+		t.Fatalf("Expected no errors, got %v", err)
+	}
+	if watcher.watches != 2 {
+		t.Fatalf("Expected 2 watches, got %d", watcher.watches)
+	}
+
+	input.Name = ""
+	if err := tracker.Watch(c, input); err == nil {
+		t.Fatal("Expected an error for a watch without a name")
+	}
+}
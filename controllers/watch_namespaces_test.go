@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Manager watching a list of namespaces", func() {
+	watched := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "watched-test"}}
+	unwatched := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unwatched-test"}}
+
+	var (
+		c    client.Client
+		stop chan struct{}
+	)
+
+	BeforeEach(func() {
+		Expect(k8sClient.Create(ctx, watched)).To(Succeed())
+		Expect(k8sClient.Create(ctx, unwatched)).To(Succeed())
+
+		// Build the client of a manager started with --namespaces=watched-test.
+		multiNamespacedCache, err := cache.MultiNamespacedCacheBuilder([]string{watched.Name})(cfg, cache.Options{Scheme: scheme.Scheme})
+		Expect(err).NotTo(HaveOccurred())
+		apiClient, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(err).NotTo(HaveOccurred())
+		c = &client.DelegatingClient{
+			Reader: &client.DelegatingReader{
+				CacheReader:  multiNamespacedCache,
+				ClientReader: apiClient,
+			},
+			Writer:       apiClient,
+			StatusClient: apiClient,
+		}
+
+		stop = make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			Expect(multiNamespacedCache.Start(stop)).To(Succeed())
+		}()
+		Expect(multiNamespacedCache.WaitForCacheSync(stop)).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		close(stop)
+		Expect(k8sClient.Delete(ctx, watched)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, unwatched)).To(Succeed())
+	})
+
+	It("Should read the kubeconfig Secrets in the watched namespaces and the external objects in any namespace", func() {
+		for _, ns := range []string{watched.Name, unwatched.Name} {
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secret.Name("cluster", secret.Kubeconfig), Namespace: ns},
+				Data:       map[string][]byte{secret.KubeconfigDataName: []byte("kubeconfig")},
+			})).To(Succeed())
+		}
+
+		inWatched := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: watched.Name}}
+		Eventually(func() error {
+			_, err := secret.Get(c, inWatched, secret.Kubeconfig)
+			return err
+		}, timeout).Should(Succeed())
+
+		// The cache of the manager has no informer for the namespaces it doesn't watch.
+		inUnwatched := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: unwatched.Name}}
+		_, err := secret.Get(c, inUnwatched, secret.Kubeconfig)
+		Expect(err).To(HaveOccurred())
+
+		ref := &corev1.ObjectReference{
+			APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha2",
+			Kind:       "InfrastructureMachine",
+			Name:       "infra-config",
+		}
+		for _, ns := range []string{watched.Name, unwatched.Name} {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion(ref.APIVersion)
+			obj.SetKind(ref.Kind)
+			obj.SetName(ref.Name)
+			obj.SetNamespace(ns)
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+		}
+
+		// Unstructured objects aren't cached, the namespaced Roles of the manager decide which ones it can read.
+		for _, ns := range []string{watched.Name, unwatched.Name} {
+			obj, err := external.Get(c, ref, ns)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.GetNamespace()).To(Equal(ns))
+		}
+	})
+})
@@ -111,3 +111,23 @@ const (
 	// Example: the ProviderSpec specifies an instance type that doesn't exist.
 	InvalidConfigurationMachineSetError MachineSetStatusError = "InvalidConfiguration"
 )
+
+type MachinePoolStatusError string
+
+const (
+	// Represents that the combination of configuration in the MachineTemplateSpec
+	// is not supported by this cluster. This is not a transient error, but
+	// indicates a state that must be fixed before progress can be made.
+	//
+	// Example: the ProviderSpec specifies an instance type that doesn't exist.
+	InvalidConfigurationMachinePoolError MachinePoolStatusError = "InvalidConfiguration"
+
+	// CreateMachinePoolError indicates an error while trying to create the infrastructure of a MachinePool.
+	CreateMachinePoolError MachinePoolStatusError = "CreateError"
+
+	// UpdateMachinePoolError indicates an error while trying to update the infrastructure of a MachinePool.
+	UpdateMachinePoolError MachinePoolStatusError = "UpdateError"
+
+	// DeleteMachinePoolError indicates an error while trying to delete the infrastructure of a MachinePool.
+	DeleteMachinePoolError MachinePoolStatusError = "DeleteError"
+)
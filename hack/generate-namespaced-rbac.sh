@@ -0,0 +1,97 @@
+#!/usr/bin/env bash
+# Copyright 2019 The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Generates a Role and a RoleBinding per namespace granting the rules of the manager ClusterRole,
+# for a manager started with --namespaces instead of the ClusterRoleBinding, and the leader election
+# Role and RoleBinding in the namespace of the manager.
+#
+# Usage: hack/generate-namespaced-rbac.sh team-a,team-b > namespaced-rbac.yaml
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+KUBE_ROOT=$(dirname "${BASH_SOURCE[0]}")/..
+
+# The defaults match the names of config/default.
+ROLE_NAME=${ROLE_NAME:-capi-manager-role}
+LEADER_ELECTION_ROLE_NAME=${LEADER_ELECTION_ROLE_NAME:-capi-leader-election-role}
+SERVICE_ACCOUNT=${SERVICE_ACCOUNT:-default}
+SERVICE_ACCOUNT_NAMESPACE=${SERVICE_ACCOUNT_NAMESPACE:-capi-system}
+
+if [[ $# -ne 1 || -z "$1" ]]; then
+  echo "usage: $0 <namespace>[,<namespace>...]" >&2
+  exit 1
+fi
+
+IFS=',' read -r -a namespaces <<< "$1"
+for ns in "${namespaces[@]}"; do
+  if [[ -z "${ns}" ]]; then
+    continue
+  fi
+
+  # The rules on cluster-scoped resources, like Nodes, have no effect in a Role.
+  echo "---"
+  awk -v name="${ROLE_NAME}" -v ns="${ns}" '
+    /^(---)?$/ { next }
+    /^kind: ClusterRole$/ { print "kind: Role"; next }
+    /^  creationTimestamp: null$/ { next }
+    /^  name: manager-role$/ { print "  name: " name; print "  namespace: " ns; next }
+    { print }
+  ' "${KUBE_ROOT}/config/rbac/role.yaml"
+
+  cat <<EOT
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: ${ROLE_NAME}binding
+  namespace: ${ns}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: ${ROLE_NAME}
+subjects:
+- kind: ServiceAccount
+  name: ${SERVICE_ACCOUNT}
+  namespace: ${SERVICE_ACCOUNT_NAMESPACE}
+EOT
+done
+
+# The leader election lock is a ConfigMap in the namespace of the manager, which isn't necessarily watched.
+echo "---"
+awk -v name="${LEADER_ELECTION_ROLE_NAME}" -v ns="${SERVICE_ACCOUNT_NAMESPACE}" '
+  /^#/ { next }
+  /^(---)?$/ { next }
+  /^  name: leader-election-role$/ { print "  name: " name; print "  namespace: " ns; next }
+  { print }
+' "${KUBE_ROOT}/config/rbac/leader_election_role.yaml"
+
+cat <<EOT
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: ${LEADER_ELECTION_ROLE_NAME}binding
+  namespace: ${SERVICE_ACCOUNT_NAMESPACE}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: ${LEADER_ELECTION_ROLE_NAME}
+subjects:
+- kind: ServiceAccount
+  name: ${SERVICE_ACCOUNT}
+  namespace: ${SERVICE_ACCOUNT_NAMESPACE}
+EOT
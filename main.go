@@ -17,17 +17,25 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog"
 	"k8s.io/klog/klogr"
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/api/v1alpha1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/cluster-api/controllers"
+	"sigs.k8s.io/cluster-api/controllers/metrics"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	"sigs.k8s.io/cluster-api/util/healthz"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	// +kubebuilder:scaffold:imports
 )
@@ -37,36 +45,56 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+// defaultLeaderElectionID is the default ID of controller-runtime, it's kept for the managers without
+// a watch filter so that they still compete with the managers of previous versions.
+const defaultLeaderElectionID = "controller-leader-election-helper"
+
 func init() {
 	klog.InitFlags(nil)
 
 	_ = clientgoscheme.AddToScheme(scheme)
 	_ = clusterv1.AddToScheme(scheme)
+	_ = clusterv1alpha1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var (
-		metricsAddr                  string
-		enableLeaderElection         bool
-		watchNamespace               string
-		profilerAddress              string
-		clusterConcurrency           int
-		machineConcurrency           int
-		machineSetConcurrency        int
-		machineDeploymentConcurrency int
-		syncPeriod                   time.Duration
+		metricsAddr                   string
+		healthAddr                    string
+		enableLeaderElection          bool
+		watchNamespace                string
+		watchNamespaces               string
+		watchFilterValue              string
+		profilerAddress               string
+		clusterConcurrency            int
+		machineConcurrency            int
+		machineSetConcurrency         int
+		machineDeploymentConcurrency  int
+		machineHealthCheckConcurrency int
+		machinePoolConcurrency        int
+		syncPeriod                    time.Duration
+		webhookPort                   int
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080",
 		"The address the metric endpoint binds to.")
 
+	flag.StringVar(&healthAddr, "health-addr", ":9440",
+		"The address the health and readiness probe endpoints bind to.")
+
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
 
 	flag.StringVar(&watchNamespace, "namespace", "",
 		"Namespace that the controller watches to reconcile cluster-api objects. If unspecified, the controller watches for cluster-api objects across all namespaces.")
 
+	flag.StringVar(&watchNamespaces, "namespaces", "",
+		"Comma-separated list of namespaces that the controller watches to reconcile cluster-api objects (e.g. team-a,team-b). Cannot be combined with --namespace.")
+
+	flag.StringVar(&watchFilterValue, "watch-filter", "",
+		fmt.Sprintf("Label value that the controller watches to reconcile cluster-api objects. Label key is always %s. If unspecified, the controller watches for all cluster-api objects.", clusterv1.WatchLabel))
+
 	flag.StringVar(&profilerAddress, "profiler-address", "",
 		"Bind address to expose the pprof profiler (e.g. localhost:6060)")
 
@@ -82,9 +110,18 @@ func main() {
 	flag.IntVar(&machineDeploymentConcurrency, "machinedeployment-concurrency", 1,
 		"Number of machine deployments to process simultaneously")
 
+	flag.IntVar(&machineHealthCheckConcurrency, "machinehealthcheck-concurrency", 1,
+		"Number of machine health checks to process simultaneously")
+
+	flag.IntVar(&machinePoolConcurrency, "machinepool-concurrency", 1,
+		"Number of machine pools to process simultaneously")
+
 	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Minute,
 		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
 
+	flag.IntVar(&webhookPort, "webhook-port", 0,
+		"Webhook Server port, the admission and conversion webhooks are disabled if unspecified (e.g. 9443)")
+
 	flag.Parse()
 
 	ctrl.SetLogger(klogr.New())
@@ -96,55 +133,170 @@ func main() {
 		}()
 	}
 
+	// Watch an explicit list of namespaces with a cache per namespace, so that the manager only needs
+	// namespaced Roles. The default cache watches a single namespace, or all of them.
+	var newCache cache.NewCacheFunc
+	if namespaces := splitNamespaces(watchNamespaces); len(namespaces) > 0 {
+		if watchNamespace != "" {
+			setupLog.Error(errors.New("--namespace and --namespaces are mutually exclusive"), "invalid flags")
+			os.Exit(1)
+		}
+		setupLog.Info("Watching cluster-api objects in namespaces", "namespaces", namespaces)
+		newCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
+		LeaderElectionID:   leaderElectionID(watchFilterValue),
 		Namespace:          watchNamespace,
+		NewCache:           newCache,
 		SyncPeriod:         &syncPeriod,
+		Port:               webhookPort,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	// Count the Clusters and Machines by phase when the metrics are scraped.
+	if err := metrics.RegisterPhaseCollector(mgr.GetClient()); err != nil {
+		setupLog.Error(err, "unable to register metrics collector", "collector", "phases")
+		os.Exit(1)
+	}
+
+	// Set up a ClusterCacheTracker to provide cached clients for the workload clusters.
+	tracker := remote.NewClusterCacheTracker(ctrl.Log.WithName("remote").WithName("ClusterCacheTracker"), mgr.GetClient(), remote.ClusterCacheTrackerOptions{})
+	if err = (&remote.ClusterCacheReconciler{
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("remote").WithName("ClusterCacheReconciler"),
+		Tracker:          tracker,
+		WatchFilterValue: watchFilterValue,
+	}).SetupWithManager(mgr, concurrency(clusterConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterCacheReconciler")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.ClusterReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Cluster"),
+		Client:           mgr.GetClient(),
+		APIReader:        mgr.GetAPIReader(),
+		Log:              ctrl.Log.WithName("controllers").WithName("Cluster"),
+		WatchFilterValue: watchFilterValue,
 	}).SetupWithManager(mgr, concurrency(clusterConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
 	}
 	if err = (&controllers.MachineReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Machine"),
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("Machine"),
+		Tracker:          tracker,
+		WatchFilterValue: watchFilterValue,
 	}).SetupWithManager(mgr, concurrency(machineConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Machine")
 		os.Exit(1)
 	}
 	if err = (&controllers.MachineSetReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("MachineSet"),
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("MachineSet"),
+		Tracker:          tracker,
+		WatchFilterValue: watchFilterValue,
 	}).SetupWithManager(mgr, concurrency(machineSetConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineSet")
 		os.Exit(1)
 	}
 	if err = (&controllers.MachineDeploymentReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("MachineDeployment"),
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("MachineDeployment"),
+		WatchFilterValue: watchFilterValue,
 	}).SetupWithManager(mgr, concurrency(machineDeploymentConcurrency)); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineDeployment")
 		os.Exit(1)
 	}
+	if err = (&controllers.MachineHealthCheckReconciler{
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("MachineHealthCheck"),
+		Tracker:          tracker,
+		WatchFilterValue: watchFilterValue,
+	}).SetupWithManager(mgr, concurrency(machineHealthCheckConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MachineHealthCheck")
+		os.Exit(1)
+	}
+	if err = (&controllers.MachinePoolReconciler{
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("MachinePool"),
+		Tracker:          tracker,
+		WatchFilterValue: watchFilterValue,
+	}).SetupWithManager(mgr, concurrency(machinePoolConcurrency)); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MachinePool")
+		os.Exit(1)
+	}
+
+	if webhookPort != 0 {
+		if err = (&clusterv1.Cluster{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Cluster")
+			os.Exit(1)
+		}
+		if err = (&clusterv1.Machine{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Machine")
+			os.Exit(1)
+		}
+		if err = (&clusterv1.MachineSet{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MachineSet")
+			os.Exit(1)
+		}
+		if err = (&clusterv1.MachineDeployment{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MachineDeployment")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	// Serve the probes outside of the manager, which only starts its Runnables once its caches have synced.
+	healthServer := healthz.NewServer(healthAddr)
+	if err := healthz.AddManagerReadinessChecks(healthServer, mgr); err != nil {
+		setupLog.Error(err, "unable to set up readiness checks")
+		os.Exit(1)
+	}
+	stop := ctrl.SetupSignalHandler()
+	go func() {
+		if err := healthServer.Start(stop); err != nil {
+			setupLog.Error(err, "problem serving health probes")
+			os.Exit(1)
+		}
+	}()
+
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(stop); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
 
+// leaderElectionID returns the ID of the leader election lock, managers with different watch filters
+// reconcile disjoint sets of objects and must not compete for the same lock.
+func leaderElectionID(watchFilterValue string) string {
+	if watchFilterValue == "" {
+		return defaultLeaderElectionID
+	}
+	return defaultLeaderElectionID + "-" + watchFilterValue
+}
+
+// splitNamespaces returns the unique, non-empty namespaces of a comma-separated list.
+func splitNamespaces(list string) []string {
+	var namespaces []string
+	seen := map[string]bool{}
+	for _, ns := range strings.Split(list, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
 func concurrency(c int) controller.Options {
 	return controller.Options{MaxConcurrentReconciles: c}
 }
@@ -24,4 +24,7 @@ const (
 
 	// DefaultCertDuration is the default lifespan used when creating certificates.
 	DefaultCertDuration = time.Hour * 24 * 365
+
+	// DefaultCACertDuration is the default lifespan used when creating CA certificates.
+	DefaultCACertDuration = DefaultCertDuration * 10
 )
@@ -91,3 +91,30 @@ type AltNames struct {
 	DNSNames []string
 	IPs      []net.IP
 }
+
+// NewSelfSignedCACert creates a CA certificate signed by the given key.
+func NewSelfSignedCACert(key *rsa.PrivateKey, commonName string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate random integer for CA certificate")
+	}
+
+	now := time.Now().UTC()
+	tmpl := x509.Certificate{
+		Subject: pkix.Name{
+			CommonName: commonName,
+		},
+		SerialNumber:          serial,
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(DefaultCACertDuration),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	b, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, key.Public(), key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create self-signed CA certificate: %+v", tmpl)
+	}
+	return x509.ParseCertificate(b)
+}
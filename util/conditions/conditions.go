@@ -0,0 +1,283 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conditions implements helpers to read and write the Conditions of Cluster API objects.
+package conditions
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+)
+
+// Getter is implemented by objects that expose Conditions.
+type Getter interface {
+	GetConditions() clusterv1.Conditions
+}
+
+// Setter is implemented by objects whose Conditions can be modified.
+type Setter interface {
+	Getter
+	SetConditions(clusterv1.Conditions)
+}
+
+// Get returns the condition with the given type, or nil if it doesn't exist.
+func Get(from Getter, t clusterv1.ConditionType) *clusterv1.Condition {
+	for _, condition := range from.GetConditions() {
+		if condition.Type == t {
+			c := condition
+			return &c
+		}
+	}
+	return nil
+}
+
+// Has returns true if a condition with the given type exists.
+func Has(from Getter, t clusterv1.ConditionType) bool {
+	return Get(from, t) != nil
+}
+
+// IsTrue returns true if the condition with the given type is True.
+func IsTrue(from Getter, t clusterv1.ConditionType) bool {
+	if c := Get(from, t); c != nil {
+		return c.Status == corev1.ConditionTrue
+	}
+	return false
+}
+
+// IsFalse returns true if the condition with the given type is False.
+func IsFalse(from Getter, t clusterv1.ConditionType) bool {
+	if c := Get(from, t); c != nil {
+		return c.Status == corev1.ConditionFalse
+	}
+	return false
+}
+
+// GetReason returns the reason of the condition with the given type, if any.
+func GetReason(from Getter, t clusterv1.ConditionType) string {
+	if c := Get(from, t); c != nil {
+		return c.Reason
+	}
+	return ""
+}
+
+// GetMessage returns the message of the condition with the given type, if any.
+func GetMessage(from Getter, t clusterv1.ConditionType) string {
+	if c := Get(from, t); c != nil {
+		return c.Message
+	}
+	return ""
+}
+
+// TrueCondition returns a condition with Status=True and the given type.
+func TrueCondition(t clusterv1.ConditionType) *clusterv1.Condition {
+	return &clusterv1.Condition{
+		Type:   t,
+		Status: corev1.ConditionTrue,
+	}
+}
+
+// FalseCondition returns a condition with Status=False and the given type.
+func FalseCondition(t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) *clusterv1.Condition {
+	return &clusterv1.Condition{
+		Type:     t,
+		Status:   corev1.ConditionFalse,
+		Reason:   reason,
+		Severity: severity,
+		Message:  fmt.Sprintf(messageFormat, messageArgs...),
+	}
+}
+
+// UnknownCondition returns a condition with Status=Unknown and the given type.
+func UnknownCondition(t clusterv1.ConditionType, reason string, messageFormat string, messageArgs ...interface{}) *clusterv1.Condition {
+	return &clusterv1.Condition{
+		Type:    t,
+		Status:  corev1.ConditionUnknown,
+		Reason:  reason,
+		Message: fmt.Sprintf(messageFormat, messageArgs...),
+	}
+}
+
+// Set sets the given condition, replacing any existing condition with the same type.
+//
+// LastTransitionTime is updated only if the status of the condition changes.
+// Conditions are kept sorted by type, with the Ready condition always first.
+func Set(to Setter, condition *clusterv1.Condition) {
+	if to == nil || condition == nil {
+		return
+	}
+
+	conditions := to.GetConditions()
+	newCondition := *condition
+
+	exists := false
+	for i := range conditions {
+		existing := conditions[i]
+		if existing.Type != newCondition.Type {
+			continue
+		}
+		exists = true
+		if existing.Status == newCondition.Status {
+			newCondition.LastTransitionTime = existing.LastTransitionTime
+		} else {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		conditions[i] = newCondition
+		break
+	}
+
+	if !exists {
+		if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		conditions = append(conditions, newCondition)
+	}
+
+	sort.SliceStable(conditions, func(i, j int) bool {
+		return lexicographicLess(&conditions[i], &conditions[j])
+	})
+
+	to.SetConditions(conditions)
+}
+
+// MarkTrue sets Status=True for the condition with the given type.
+func MarkTrue(to Setter, t clusterv1.ConditionType) {
+	Set(to, TrueCondition(t))
+}
+
+// MarkFalse sets Status=False for the condition with the given type.
+func MarkFalse(to Setter, t clusterv1.ConditionType, reason string, severity clusterv1.ConditionSeverity, messageFormat string, messageArgs ...interface{}) {
+	Set(to, FalseCondition(t, reason, severity, messageFormat, messageArgs...))
+}
+
+// MarkUnknown sets Status=Unknown for the condition with the given type.
+func MarkUnknown(to Setter, t clusterv1.ConditionType, reason string, messageFormat string, messageArgs ...interface{}) {
+	Set(to, UnknownCondition(t, reason, messageFormat, messageArgs...))
+}
+
+// Delete removes the condition with the given type.
+func Delete(to Setter, t clusterv1.ConditionType) {
+	if to == nil {
+		return
+	}
+
+	conditions := to.GetConditions()
+	newConditions := make(clusterv1.Conditions, 0, len(conditions))
+	for _, condition := range conditions {
+		if condition.Type != t {
+			newConditions = append(newConditions, condition)
+		}
+	}
+	to.SetConditions(newConditions)
+}
+
+// Mirror returns a copy of the Ready condition of the source object, using the given type.
+// It returns nil if the source object doesn't have a Ready condition.
+func Mirror(from Getter, t clusterv1.ConditionType) *clusterv1.Condition {
+	ready := Get(from, clusterv1.ReadyCondition)
+	if ready == nil {
+		return nil
+	}
+
+	ready.Type = t
+	return ready
+}
+
+// SetMirror sets a condition of the given type on the target object, mirroring the Ready
+// condition of the source object. It returns false if there was nothing to mirror.
+func SetMirror(to Setter, t clusterv1.ConditionType, from Getter) bool {
+	mirror := Mirror(from, t)
+	if mirror == nil {
+		return false
+	}
+
+	Set(to, mirror)
+	return true
+}
+
+// Summary returns a Ready condition summarizing the conditions of the given types.
+//
+// The summary is False if any of the conditions is False, taking reason and message from the
+// condition with the highest severity; it is Unknown if none is False but some are Unknown,
+// and True otherwise. Conditions that don't exist on the object are ignored, and nil is returned
+// if none of them exist. If no types are given, all the conditions other than Ready are summarized.
+func Summary(from Getter, types ...clusterv1.ConditionType) *clusterv1.Condition {
+	if len(types) == 0 {
+		for _, condition := range from.GetConditions() {
+			if condition.Type != clusterv1.ReadyCondition {
+				types = append(types, condition.Type)
+			}
+		}
+	}
+
+	found := false
+	var worstFalse, firstUnknown *clusterv1.Condition
+	for _, t := range types {
+		condition := Get(from, t)
+		if condition == nil {
+			continue
+		}
+		found = true
+
+		switch condition.Status {
+		case corev1.ConditionFalse:
+			if worstFalse == nil || severityRank(condition.Severity) > severityRank(worstFalse.Severity) {
+				worstFalse = condition
+			}
+		case corev1.ConditionUnknown:
+			if firstUnknown == nil {
+				firstUnknown = condition
+			}
+		}
+	}
+
+	switch {
+	case !found:
+		return nil
+	case worstFalse != nil:
+		return FalseCondition(clusterv1.ReadyCondition, worstFalse.Reason, worstFalse.Severity, "%s", worstFalse.Message)
+	case firstUnknown != nil:
+		return UnknownCondition(clusterv1.ReadyCondition, firstUnknown.Reason, "%s", firstUnknown.Message)
+	default:
+		return TrueCondition(clusterv1.ReadyCondition)
+	}
+}
+
+// SetSummary sets the Ready condition on the object, summarizing the conditions of the given types.
+func SetSummary(to Setter, types ...clusterv1.ConditionType) {
+	Set(to, Summary(to, types...))
+}
+
+// lexicographicLess sorts the Ready condition first, then all the others by type.
+func lexicographicLess(i, j *clusterv1.Condition) bool {
+	return (i.Type == clusterv1.ReadyCondition || i.Type < j.Type) && j.Type != clusterv1.ReadyCondition
+}
+
+func severityRank(s clusterv1.ConditionSeverity) int {
+	switch s {
+	case clusterv1.ConditionSeverityError:
+		return 3
+	case clusterv1.ConditionSeverityWarning:
+		return 2
+	case clusterv1.ConditionSeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
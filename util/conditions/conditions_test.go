@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+)
+
+func TestSet(t *testing.T) {
+	m := &clusterv1.Machine{}
+
+	MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureReason, clusterv1.ConditionSeverityInfo, "waiting")
+	MarkTrue(m, clusterv1.BootstrapReadyCondition)
+	MarkTrue(m, clusterv1.ReadyCondition)
+
+	// Ready always comes first, then conditions are sorted by type.
+	expectedOrder := []clusterv1.ConditionType{
+		clusterv1.ReadyCondition,
+		clusterv1.BootstrapReadyCondition,
+		clusterv1.InfrastructureReadyCondition,
+	}
+	if len(m.Status.Conditions) != len(expectedOrder) {
+		t.Fatalf("expected %d conditions, got %d", len(expectedOrder), len(m.Status.Conditions))
+	}
+	for i, c := range m.Status.Conditions {
+		if c.Type != expectedOrder[i] {
+			t.Errorf("expected condition %d to be %q, got %q", i, expectedOrder[i], c.Type)
+		}
+	}
+
+	// The transition time is preserved as long as the status doesn't change.
+	past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	m.Status.Conditions[2].LastTransitionTime = past
+
+	MarkFalse(m, clusterv1.InfrastructureReadyCondition, clusterv1.WaitingForInfrastructureReason, clusterv1.ConditionSeverityInfo, "still waiting")
+	c := Get(m, clusterv1.InfrastructureReadyCondition)
+	if !c.LastTransitionTime.Equal(&past) {
+		t.Errorf("expected LastTransitionTime to be preserved, got %v", c.LastTransitionTime)
+	}
+	if c.Message != "still waiting" {
+		t.Errorf("expected message to be updated, got %q", c.Message)
+	}
+
+	MarkTrue(m, clusterv1.InfrastructureReadyCondition)
+	c = Get(m, clusterv1.InfrastructureReadyCondition)
+	if c.LastTransitionTime.Equal(&past) {
+		t.Error("expected LastTransitionTime to be updated")
+	}
+
+	Delete(m, clusterv1.BootstrapReadyCondition)
+	if Has(m, clusterv1.BootstrapReadyCondition) {
+		t.Error("expected condition to be deleted")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	testCases := []struct {
+		name           string
+		conditions     clusterv1.Conditions
+		expectedStatus corev1.ConditionStatus
+		expectedReason string
+		expectNil      bool
+	}{
+		{
+			name:      "no conditions",
+			expectNil: true,
+		},
+		{
+			name: "all true",
+			conditions: clusterv1.Conditions{
+				*TrueCondition(clusterv1.BootstrapReadyCondition),
+				*TrueCondition(clusterv1.InfrastructureReadyCondition),
+			},
+			expectedStatus: corev1.ConditionTrue,
+		},
+		{
+			name: "false with the highest severity wins",
+			conditions: clusterv1.Conditions{
+				*FalseCondition(clusterv1.BootstrapReadyCondition, "InfoReason", clusterv1.ConditionSeverityInfo, ""),
+				*FalseCondition(clusterv1.InfrastructureReadyCondition, "ErrorReason", clusterv1.ConditionSeverityError, ""),
+				*UnknownCondition(clusterv1.NodeRefAssignedCondition, "UnknownReason", ""),
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: "ErrorReason",
+		},
+		{
+			name: "unknown without false",
+			conditions: clusterv1.Conditions{
+				*TrueCondition(clusterv1.BootstrapReadyCondition),
+				*UnknownCondition(clusterv1.NodeRefAssignedCondition, "UnknownReason", ""),
+			},
+			expectedStatus: corev1.ConditionUnknown,
+			expectedReason: "UnknownReason",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &clusterv1.Machine{Status: clusterv1.MachineStatus{Conditions: tc.conditions}}
+
+			summary := Summary(m, clusterv1.BootstrapReadyCondition, clusterv1.InfrastructureReadyCondition, clusterv1.NodeRefAssignedCondition)
+			if tc.expectNil {
+				if summary != nil {
+					t.Fatalf("expected nil summary, got %v", summary)
+				}
+				return
+			}
+			if summary.Type != clusterv1.ReadyCondition {
+				t.Errorf("expected type %q, got %q", clusterv1.ReadyCondition, summary.Type)
+			}
+			if summary.Status != tc.expectedStatus {
+				t.Errorf("expected status %q, got %q", tc.expectedStatus, summary.Status)
+			}
+			if summary.Reason != tc.expectedReason {
+				t.Errorf("expected reason %q, got %q", tc.expectedReason, summary.Reason)
+			}
+		})
+	}
+}
+
+func TestSetMirror(t *testing.T) {
+	infra := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":     "Ready",
+						"status":   "False",
+						"severity": "Warning",
+						"reason":   "InstanceProvisionFailed",
+						"message":  "quota exceeded",
+					},
+				},
+			},
+		},
+	}
+
+	m := &clusterv1.Machine{}
+	if !SetMirror(m, clusterv1.InfrastructureReadyCondition, UnstructuredGetter(infra)) {
+		t.Fatal("expected the Ready condition to be mirrored")
+	}
+	if GetReason(m, clusterv1.InfrastructureReadyCondition) != "InstanceProvisionFailed" {
+		t.Errorf("unexpected reason %q", GetReason(m, clusterv1.InfrastructureReadyCondition))
+	}
+
+	if SetMirror(m, clusterv1.BootstrapReadyCondition, UnstructuredGetter(&unstructured.Unstructured{Object: map[string]interface{}{}})) {
+		t.Error("expected nothing to be mirrored from an object without conditions")
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util"
+)
+
+// UnstructuredGetter returns a Getter reading the status.conditions field of an
+// unstructured object, e.g. a bootstrap or infrastructure provider resource.
+func UnstructuredGetter(u *unstructured.Unstructured) Getter {
+	return &unstructuredWrapper{Unstructured: u}
+}
+
+type unstructuredWrapper struct {
+	*unstructured.Unstructured
+}
+
+// GetConditions returns the conditions of the unstructured object, or nil
+// if the object doesn't have conditions that follow the Cluster API contract.
+func (u *unstructuredWrapper) GetConditions() clusterv1.Conditions {
+	conditions := clusterv1.Conditions{}
+	if err := util.UnstructuredUnmarshalField(u.Unstructured, &conditions, "status", "conditions"); err != nil {
+		if err != util.ErrUnstructuredFieldNotFound {
+			klog.V(4).Infof("Failed to read conditions from %v %q: %v", u.GroupVersionKind(), u.GetName(), err)
+		}
+		return nil
+	}
+	return conditions
+}
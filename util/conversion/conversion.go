@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conversion implements the helpers used to convert Cluster API objects between
+// API versions without losing the fields that don't exist in the target version.
+package conversion
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// DataAnnotation is the annotation that conversion functions use to keep the data
+	// lost during the conversion of an object.
+	DataAnnotation = "cluster.x-k8s.io/conversion-data"
+)
+
+// MarshalData stores the spec and status of src in the DataAnnotation of the destination object,
+// so that they can be restored when converting the destination back. src should only hold the
+// fields that can't be represented in the version of the destination object.
+// The annotations of the source object are shared with the destination object by the
+// generated conversion functions and aren't modified.
+func MarshalData(src metav1.Object, dst metav1.Object) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert conversion data of %s to unstructured", dst.GetName())
+	}
+	delete(u, "metadata")
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal conversion data of %s", dst.GetName())
+	}
+
+	annotations := make(map[string]string, len(dst.GetAnnotations())+1)
+	for k, v := range dst.GetAnnotations() {
+		annotations[k] = v
+	}
+	annotations[DataAnnotation] = string(data)
+	dst.SetAnnotations(annotations)
+	return nil
+}
+
+// UnmarshalData restores the data stored by MarshalData in the DataAnnotation of from into to.
+// It returns false if from doesn't have any conversion data.
+func UnmarshalData(from metav1.Object, to interface{}) (bool, error) {
+	data, ok := from.GetAnnotations()[DataAnnotation]
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), to); err != nil {
+		return false, errors.Wrapf(err, "failed to unmarshal conversion data of %s", from.GetName())
+	}
+	return true, nil
+}
+
+// RemoveData removes the DataAnnotation from obj, once its data has been restored, so that it
+// doesn't outlive the conversion. The annotations are copied rather than modified, since they
+// are shared with the source object.
+func RemoveData(obj metav1.Object) {
+	if _, ok := obj.GetAnnotations()[DataAnnotation]; !ok {
+		return
+	}
+
+	var annotations map[string]string
+	for k, v := range obj.GetAnnotations() {
+		if k == DataAnnotation {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[k] = v
+	}
+	obj.SetAnnotations(annotations)
+}
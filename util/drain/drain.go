@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drain
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog"
+)
+
+// Helper cordons a Node and evicts the pods running on it through the eviction API,
+// so that PodDisruptionBudgets are honored.
+//
+// Pods owned by a DaemonSet and mirror (static) pods are never evicted, given that the
+// former would be immediately recreated on the same Node and the latter can't be
+// deleted through the API server.
+type Helper struct {
+	Client corev1client.CoreV1Interface
+
+	// GracePeriodSeconds is how long to wait for a pod to terminate.
+	// A negative value uses the pod's terminationGracePeriodSeconds.
+	GracePeriodSeconds int
+
+	// SkipWaitForDeleteTimeoutSeconds, if greater than zero, ignores pods whose deletion
+	// started more than this many seconds ago. Such pods are typically stuck terminating
+	// on an unreachable Node and would otherwise block the drain forever.
+	SkipWaitForDeleteTimeoutSeconds int
+}
+
+// Drain cordons the Node and requests the eviction of all the pods that run on it.
+//
+// It returns the number of pods still present on the Node, which includes pods that are
+// terminating and pods whose eviction was refused because of a PodDisruptionBudget.
+// Pods that have been terminating for longer than SkipWaitForDeleteTimeoutSeconds aren't counted.
+// Callers are expected to call Drain again later until it returns zero.
+func (h *Helper) Drain(node *corev1.Node) (int, error) {
+	if err := h.CordonNode(node); err != nil {
+		return 0, errors.Wrapf(err, "failed to cordon Node %q", node.Name)
+	}
+
+	pods, err := h.GetPodsForEviction(node.Name)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to list pods on Node %q", node.Name)
+	}
+
+	if err := h.EvictPods(pods); err != nil {
+		return 0, errors.Wrapf(err, "failed to evict pods from Node %q", node.Name)
+	}
+
+	return len(pods), nil
+}
+
+// CordonNode marks the Node as unschedulable, if it isn't already.
+func (h *Helper) CordonNode(node *corev1.Node) error {
+	if node.Spec.Unschedulable {
+		return nil
+	}
+
+	patch := []byte(`{"spec":{"unschedulable":true}}`)
+	if _, err := h.Client.Nodes().Patch(node.Name, types.StrategicMergePatchType, patch); err != nil {
+		return err
+	}
+
+	node.Spec.Unschedulable = true
+	return nil
+}
+
+// GetPodsForEviction returns the pods scheduled on the Node that need to be evicted.
+func (h *Helper) GetPodsForEviction(nodeName string) ([]corev1.Pod, error) {
+	listOpt := metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": nodeName}).String(),
+	}
+
+	pods := []corev1.Pod{}
+	for {
+		podList, err := h.Client.Pods(metav1.NamespaceAll).List(listOpt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pod := range podList.Items {
+			if pod.Spec.NodeName != nodeName || skipEviction(&pod) || h.skipWaitForDelete(&pod) {
+				continue
+			}
+			pods = append(pods, pod)
+		}
+
+		listOpt.Continue = podList.Continue
+		if listOpt.Continue == "" {
+			break
+		}
+	}
+
+	return pods, nil
+}
+
+// EvictPods issues an eviction request for each pod that isn't already terminating.
+// Evictions refused because they would violate a PodDisruptionBudget aren't considered errors.
+func (h *Helper) EvictPods(pods []corev1.Pod) error {
+	errs := []error{}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		err := h.Client.Pods(pod.Namespace).Evict(&policyv1beta1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+			DeleteOptions: h.deleteOptions(),
+		})
+
+		switch {
+		case err == nil:
+			klog.V(2).Infof("Evicted pod %q in namespace %q from Node %q", pod.Name, pod.Namespace, pod.Spec.NodeName)
+		case apierrors.IsNotFound(err):
+			// The pod is already gone.
+		case apierrors.IsTooManyRequests(err):
+			klog.V(2).Infof("Cannot evict pod %q in namespace %q as it would violate its disruption budget, will retry",
+				pod.Name, pod.Namespace)
+		default:
+			errs = append(errs, errors.Wrapf(err, "failed to evict pod %q in namespace %q", pod.Name, pod.Namespace))
+		}
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+func (h *Helper) deleteOptions() *metav1.DeleteOptions {
+	if h.GracePeriodSeconds < 0 {
+		return &metav1.DeleteOptions{}
+	}
+
+	gracePeriodSeconds := int64(h.GracePeriodSeconds)
+	return &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriodSeconds}
+}
+
+// skipWaitForDelete returns true if the pod has been terminating for longer than SkipWaitForDeleteTimeoutSeconds.
+func (h *Helper) skipWaitForDelete(pod *corev1.Pod) bool {
+	if h.SkipWaitForDeleteTimeoutSeconds <= 0 || pod.DeletionTimestamp == nil {
+		return false
+	}
+
+	timeout := time.Duration(h.SkipWaitForDeleteTimeoutSeconds) * time.Second
+	return time.Since(pod.DeletionTimestamp.Time) > timeout
+}
+
+// skipEviction returns true for pods that must not be evicted from a Node.
+func skipEviction(pod *corev1.Pod) bool {
+	// Mirror pods are managed by the kubelet and can't be evicted through the API server.
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+
+	// DaemonSet pods would be recreated on the same Node straight away.
+	if controllerRef := metav1.GetControllerOf(pod); controllerRef != nil && controllerRef.Kind == "DaemonSet" {
+		return true
+	}
+
+	return false
+}
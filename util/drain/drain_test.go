@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drain
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func newPod(name, nodeName string, mutate func(*corev1.Pod)) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+	if mutate != nil {
+		mutate(pod)
+	}
+	return pod
+}
+
+func TestDrain(t *testing.T) {
+	isController := true
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+
+	objects := []runtime.Object{
+		node,
+		newPod("app", "node-1", nil),
+		newPod("protected", "node-1", nil),
+		newPod("other-node", "node-2", nil),
+		newPod("mirror", "node-1", func(p *corev1.Pod) {
+			p.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "mirror"}
+		}),
+		newPod("daemon", "node-1", func(p *corev1.Pod) {
+			p.OwnerReferences = []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "ds", Controller: &isController},
+			}
+		}),
+	}
+
+	client := fakeclient.NewSimpleClientset(objects...)
+
+	evicted := []string{}
+	client.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(clienttesting.CreateAction).GetObject().(*policyv1beta1.Eviction)
+		if eviction.Name == "protected" {
+			return true, nil, apierrors.NewTooManyRequests("disruption budget", 10)
+		}
+		evicted = append(evicted, eviction.Name)
+		return true, nil, nil
+	})
+
+	h := &Helper{Client: client.CoreV1(), GracePeriodSeconds: -1}
+
+	remaining, err := h.Drain(node)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Both pods are still listed: the fake eviction doesn't delete them.
+	if remaining != 2 {
+		t.Errorf("expected 2 remaining pods, got %d", remaining)
+	}
+
+	sort.Strings(evicted)
+	if len(evicted) != 1 || evicted[0] != "app" {
+		t.Errorf("expected only pod %q to be evicted, got %v", "app", evicted)
+	}
+
+	updated, err := client.CoreV1().Nodes().Get("node-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated.Spec.Unschedulable {
+		t.Error("expected node to be cordoned")
+	}
+}
+
+func TestEvictPodsError(t *testing.T) {
+	client := fakeclient.NewSimpleClientset(newPod("app", "node-1", nil))
+	client.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "app", errors.New("denied"))
+	})
+
+	h := &Helper{Client: client.CoreV1()}
+	if err := h.EvictPods([]corev1.Pod{*newPod("app", "node-1", nil)}); err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
+
+func TestGetPodsForEvictionSkipWaitForDelete(t *testing.T) {
+	longAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+	recently := metav1.NewTime(time.Now())
+
+	client := fakeclient.NewSimpleClientset(
+		newPod("running", "node-1", nil),
+		newPod("stuck", "node-1", func(p *corev1.Pod) { p.DeletionTimestamp = &longAgo }),
+		newPod("terminating", "node-1", func(p *corev1.Pod) { p.DeletionTimestamp = &recently }),
+	)
+
+	testCases := []struct {
+		name     string
+		timeout  int
+		expected []string
+	}{
+		{name: "disabled", timeout: 0, expected: []string{"running", "stuck", "terminating"}},
+		{name: "enabled", timeout: 60, expected: []string{"running", "terminating"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &Helper{Client: client.CoreV1(), SkipWaitForDeleteTimeoutSeconds: tc.timeout}
+			pods, err := h.GetPodsForEviction("node-1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			names := []string{}
+			for _, pod := range pods {
+				names = append(names, pod.Name)
+			}
+			sort.Strings(names)
+
+			if len(names) != len(tc.expected) {
+				t.Fatalf("expected pods %v, got %v", tc.expected, names)
+			}
+			for i := range names {
+				if names[i] != tc.expected[i] {
+					t.Errorf("expected pods %v, got %v", tc.expected, names)
+				}
+			}
+		})
+	}
+}
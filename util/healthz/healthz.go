@@ -0,0 +1,227 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package healthz serves the liveness and readiness probe endpoints of controller managers.
+package healthz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// LivenessEndpoint is the path of the liveness probe endpoint.
+	LivenessEndpoint = "/healthz"
+
+	// ReadinessEndpoint is the path of the readiness probe endpoint.
+	ReadinessEndpoint = "/readyz"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// Checker checks the health of a component, returning an error if it isn't healthy.
+type Checker func(req *http.Request) error
+
+// Ping is a Checker that always succeeds, it checks that the probe server is responsive.
+func Ping(_ *http.Request) error {
+	return nil
+}
+
+// Handler serves the result of a set of named checks. It responds with 200 if all the checks pass, and
+// with 500 otherwise. The result of each check is listed in the response if the verbose query parameter
+// is set, or if any check fails.
+type Handler struct {
+	lock   sync.RWMutex
+	names  []string
+	checks map[string]Checker
+}
+
+// AddCheck adds a named check to the handler. Names must be unique.
+func (h *Handler) AddCheck(name string, check Checker) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.checks == nil {
+		h.checks = map[string]Checker{}
+	}
+	if _, ok := h.checks[name]; ok {
+		return errors.Errorf("check %q is already registered", name)
+	}
+	h.names = append(h.names, name)
+	h.checks[name] = check
+	return nil
+}
+
+// ServeHTTP runs the checks and writes their result.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	failed := false
+	var out bytes.Buffer
+	for _, name := range h.names {
+		if err := h.checks[name](req); err != nil {
+			failed = true
+			fmt.Fprintf(&out, "[-]%s failed: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(&out, "[+]%s ok\n", name)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if failed {
+		klog.V(2).Infof("%s check failed:\n%s", req.URL.Path, out.String())
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "%s%s check failed\n", out.String(), req.URL.Path)
+		return
+	}
+	if _, verbose := req.URL.Query()["verbose"]; verbose {
+		fmt.Fprintf(w, "%s%s check passed\n", out.String(), req.URL.Path)
+		return
+	}
+	fmt.Fprint(w, "ok")
+}
+
+// Server serves the liveness and readiness endpoints. It must be started independently of the manager,
+// since the manager doesn't start its Runnables until its caches have synced.
+type Server struct {
+	// Addr is the address the server listens on, e.g. ":9440".
+	Addr string
+
+	liveness  Handler
+	readiness Handler
+}
+
+// NewServer returns a Server listening on the given address. The liveness endpoint has a ping check,
+// the readiness endpoint has none until some are added.
+func NewServer(addr string) *Server {
+	s := &Server{Addr: addr}
+	_ = s.AddLivenessCheck("ping", Ping)
+	return s
+}
+
+// AddLivenessCheck adds a check to the liveness endpoint. A failed liveness check gets the manager restarted.
+func (s *Server) AddLivenessCheck(name string, check Checker) error {
+	return s.liveness.AddCheck(name, check)
+}
+
+// AddReadinessCheck adds a check to the readiness endpoint.
+func (s *Server) AddReadinessCheck(name string, check Checker) error {
+	return s.readiness.AddCheck(name, check)
+}
+
+// Start serves the endpoints until the stop channel is closed.
+func (s *Server) Start(stop <-chan struct{}) error {
+	mux := http.NewServeMux()
+	mux.Handle(LivenessEndpoint, &s.liveness)
+	mux.Handle(ReadinessEndpoint, &s.readiness)
+
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %q for the health probes", s.Addr)
+	}
+
+	server := &http.Server{Handler: mux}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+	klog.Infof("Serving health probes on %s", listener.Addr())
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return server.Shutdown(ctx)
+	}
+}
+
+// startedRunnable is a manager Runnable recording that the manager started it.
+type startedRunnable struct {
+	started            int32
+	needLeaderElection bool
+}
+
+func (r *startedRunnable) Start(stop <-chan struct{}) error {
+	atomic.StoreInt32(&r.started, 1)
+	<-stop
+	return nil
+}
+
+func (r *startedRunnable) NeedLeaderElection() bool {
+	return r.needLeaderElection
+}
+
+func (r *startedRunnable) check(err error) Checker {
+	return func(_ *http.Request) error {
+		if atomic.LoadInt32(&r.started) == 0 {
+			return err
+		}
+		return nil
+	}
+}
+
+// CacheSyncCheck returns a Checker that passes once the informer caches of the manager have synced.
+// The manager starts its Runnables once the caches have synced, the check adds one to find out.
+func CacheSyncCheck(mgr manager.Manager) (Checker, error) {
+	r := &startedRunnable{}
+	if err := mgr.Add(r); err != nil {
+		return nil, errors.Wrap(err, "failed to add cache sync check to the manager")
+	}
+	return r.check(errors.New("informer caches not synced")), nil
+}
+
+// LeaderElectionCheck returns a Checker that passes once the manager is the leader, or once its caches
+// have synced if leader election is disabled. The manager starts the Runnables that need leader election
+// once it's been elected, the check adds one to find out.
+func LeaderElectionCheck(mgr manager.Manager) (Checker, error) {
+	r := &startedRunnable{needLeaderElection: true}
+	if err := mgr.Add(r); err != nil {
+		return nil, errors.Wrap(err, "failed to add leader election check to the manager")
+	}
+	return r.check(errors.New("not the leader")), nil
+}
+
+// AddManagerReadinessChecks adds the cache sync and leader election checks of the manager to the
+// readiness endpoint of the server.
+func AddManagerReadinessChecks(s *Server, mgr manager.Manager) error {
+	cacheSynced, err := CacheSyncCheck(mgr)
+	if err != nil {
+		return err
+	}
+	if err := s.AddReadinessCheck("informer-sync", cacheSynced); err != nil {
+		return err
+	}
+
+	leader, err := LeaderElectionCheck(mgr)
+	if err != nil {
+		return err
+	}
+	return s.AddReadinessCheck("leader-election", leader)
+}
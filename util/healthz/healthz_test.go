@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package healthz
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestHandler(t *testing.T) {
+	h := &Handler{}
+	if err := h.AddCheck("ping", Ping); err != nil {
+		t.Fatalf("failed to add check: %v", err)
+	}
+	if err := h.AddCheck("ping", Ping); err == nil {
+		t.Fatal("expected adding a check twice to fail")
+	}
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	if rec := get("/readyz"); rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("expected 200 ok, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := get("/readyz?verbose"); !strings.Contains(rec.Body.String(), "[+]ping ok") {
+		t.Errorf("expected the verbose output to list the checks, got %q", rec.Body.String())
+	}
+
+	ready := false
+	if err := h.AddCheck("ready", func(_ *http.Request) error {
+		if !ready {
+			return errors.New("not ready yet")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to add check: %v", err)
+	}
+	rec := get("/readyz")
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "[-]ready failed: not ready yet") {
+		t.Errorf("expected the failed check to be listed, got %q", rec.Body.String())
+	}
+
+	ready = true
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+}
+
+func TestStartedRunnable(t *testing.T) {
+	r := &startedRunnable{needLeaderElection: true}
+	check := r.check(errors.New("not started"))
+	if err := check(nil); err == nil {
+		t.Error("expected the check to fail before the runnable is started")
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		_ = r.Start(stop)
+		close(done)
+	}()
+	close(stop)
+	<-done
+
+	if err := check(nil); err != nil {
+		t.Errorf("expected the check to pass once the runnable is started, got %v", err)
+	}
+	if !r.NeedLeaderElection() {
+		t.Error("expected the runnable to need leader election")
+	}
+}
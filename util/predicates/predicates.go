@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package predicates contains event filters shared by the Cluster API controllers and the providers.
+package predicates
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// ResourceNotPaused returns a predicate that filters out the events of the objects that have the paused annotation.
+// The objects of a paused Cluster aren't filtered out, the reconcilers still need to check util.IsPaused.
+func ResourceNotPaused() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return notPaused(e.Meta)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return notPaused(e.MetaNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return notPaused(e.Meta)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return notPaused(e.Meta)
+		},
+	}
+}
+
+// ResourceHasFilterLabel returns a predicate that only keeps the events of the objects that have the watch label
+// with the given value. All events are kept if the value is empty.
+func ResourceHasFilterLabel(labelValue string) predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return hasFilterLabel(e.Meta, labelValue)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return hasFilterLabel(e.MetaNew, labelValue)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return hasFilterLabel(e.Meta, labelValue)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return hasFilterLabel(e.Meta, labelValue)
+		},
+	}
+}
+
+// ClusterUnpaused returns a predicate that only keeps the update events of the Clusters that stop being paused,
+// so that the objects of a Cluster can be reconciled again as soon as it's unpaused.
+func ClusterUnpaused() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCluster, ok := e.ObjectOld.(*clusterv1.Cluster)
+			if !ok {
+				return false
+			}
+			newCluster, ok := e.ObjectNew.(*clusterv1.Cluster)
+			if !ok {
+				return false
+			}
+			return oldCluster.Spec.Paused && !newCluster.Spec.Paused
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func notPaused(o metav1.Object) bool {
+	return o == nil || !util.HasPausedAnnotation(o)
+}
+
+func hasFilterLabel(o metav1.Object, labelValue string) bool {
+	if o == nil {
+		return labelValue == ""
+	}
+	return util.HasWatchLabel(o, labelValue)
+}
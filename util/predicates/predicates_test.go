@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestResourceNotPaused(t *testing.T) {
+	paused := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{clusterv1.PausedAnnotation: ""}},
+	}
+	unpaused := &clusterv1.Machine{}
+
+	p := ResourceNotPaused()
+	if p.Create(event.CreateEvent{Meta: paused, Object: paused}) {
+		t.Error("expected the creation of a paused object to be filtered out")
+	}
+	if !p.Create(event.CreateEvent{Meta: unpaused, Object: unpaused}) {
+		t.Error("expected the creation of an object that isn't paused to be kept")
+	}
+	if !p.Update(event.UpdateEvent{MetaOld: paused, ObjectOld: paused, MetaNew: unpaused, ObjectNew: unpaused}) {
+		t.Error("expected the update removing the paused annotation to be kept")
+	}
+	if p.Update(event.UpdateEvent{MetaOld: unpaused, ObjectOld: unpaused, MetaNew: paused, ObjectNew: paused}) {
+		t.Error("expected the update of a paused object to be filtered out")
+	}
+}
+
+func TestResourceHasFilterLabel(t *testing.T) {
+	canary := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{clusterv1.WatchLabel: "canary"}},
+	}
+	unlabeled := &clusterv1.Machine{}
+
+	p := ResourceHasFilterLabel("canary")
+	if !p.Create(event.CreateEvent{Meta: canary, Object: canary}) {
+		t.Error("expected the creation of an object with the filter label to be kept")
+	}
+	if p.Create(event.CreateEvent{Meta: unlabeled, Object: unlabeled}) {
+		t.Error("expected the creation of an object without the filter label to be filtered out")
+	}
+	if p.Update(event.UpdateEvent{MetaOld: canary, ObjectOld: canary, MetaNew: unlabeled, ObjectNew: unlabeled}) {
+		t.Error("expected the update removing the filter label to be filtered out")
+	}
+	if p := ResourceHasFilterLabel("stable"); p.Delete(event.DeleteEvent{Meta: canary, Object: canary}) {
+		t.Error("expected the deletion of an object with another filter label to be filtered out")
+	}
+
+	p = ResourceHasFilterLabel("")
+	if !p.Create(event.CreateEvent{Meta: unlabeled, Object: unlabeled}) || !p.Create(event.CreateEvent{Meta: canary, Object: canary}) {
+		t.Error("expected an empty filter to keep every event")
+	}
+}
+
+func TestClusterUnpaused(t *testing.T) {
+	paused := &clusterv1.Cluster{Spec: clusterv1.ClusterSpec{Paused: true}}
+	unpaused := &clusterv1.Cluster{}
+
+	p := ClusterUnpaused()
+	if !p.Update(event.UpdateEvent{MetaOld: paused, ObjectOld: paused, MetaNew: unpaused, ObjectNew: unpaused}) {
+		t.Error("expected the update unpausing the Cluster to be kept")
+	}
+	if p.Update(event.UpdateEvent{MetaOld: unpaused, ObjectOld: unpaused, MetaNew: paused, ObjectNew: paused}) {
+		t.Error("expected the update pausing the Cluster to be filtered out")
+	}
+	if p.Update(event.UpdateEvent{MetaOld: unpaused, ObjectOld: unpaused, MetaNew: unpaused, ObjectNew: unpaused}) {
+		t.Error("expected the update of a Cluster that isn't paused to be filtered out")
+	}
+	if p.Create(event.CreateEvent{Meta: unpaused, Object: unpaused}) {
+		t.Error("expected the creation of a Cluster to be filtered out")
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// caCommonNames are the common names of the CA certificates, they match the ones generated by kubeadm.
+var caCommonNames = map[Purpose]string{
+	ClusterCA:    "kubernetes",
+	EtcdCA:       "etcd-ca",
+	FrontProxyCA: "front-proxy-ca",
+}
+
+// Certificate is a key pair of a Cluster, stored in the Secret named after its purpose.
+// The key pair of the ServiceAccount purpose holds a public key instead of a certificate.
+type Certificate struct {
+	Purpose Purpose
+	KeyPair *certs.KeyPair
+
+	// Generated is true if the key pair has been generated, rather than read from its Secret.
+	Generated bool
+}
+
+// Certificates are the key pairs of a Cluster.
+type Certificates []*Certificate
+
+// NewCertificates returns the cluster CA, etcd CA, front proxy CA and service account key pairs of a Cluster,
+// the certificates that every control plane of the Cluster must share.
+func NewCertificates() Certificates {
+	return Certificates{
+		{Purpose: ClusterCA},
+		{Purpose: EtcdCA},
+		{Purpose: FrontProxyCA},
+		{Purpose: ServiceAccount},
+	}
+}
+
+// GetByPurpose returns the certificate with the given purpose, or nil if there is none.
+func (c Certificates) GetByPurpose(purpose Purpose) *Certificate {
+	for _, cert := range c {
+		if cert.Purpose == purpose {
+			return cert
+		}
+	}
+	return nil
+}
+
+// Lookup reads the key pairs of the certificates from their Secrets, if they exist.
+// Users can supply their own CAs by creating the Secrets before the Cluster.
+// The reader should read from the API server rather than from a cache, so that a Secret
+// which isn't in the cache yet doesn't get generated again.
+func (c Certificates) Lookup(ctx context.Context, reader client.Reader, cluster *clusterv1.Cluster) error {
+	for _, cert := range c {
+		s := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: cluster.Namespace, Name: Name(cluster.Name, cert.Purpose)}
+		if err := reader.Get(ctx, key, s); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get Secret %q in namespace %q", key.Name, key.Namespace)
+		}
+
+		keyPair := &certs.KeyPair{
+			Cert: s.Data[TLSCrtDataName],
+			Key:  s.Data[TLSKeyDataName],
+		}
+		if !keyPair.IsValid() {
+			return errors.Errorf("Secret %q in namespace %q must have both the %q and %q keys",
+				key.Name, key.Namespace, TLSCrtDataName, TLSKeyDataName)
+		}
+		cert.KeyPair = keyPair
+		cert.Generated = false
+	}
+	return nil
+}
+
+// Missing returns the certificates whose key pair has been neither looked up nor generated.
+func (c Certificates) Missing() Certificates {
+	var missing Certificates
+	for _, cert := range c {
+		if cert.KeyPair == nil {
+			missing = append(missing, cert)
+		}
+	}
+	return missing
+}
+
+// Generate generates the key pairs of the certificates that haven't been looked up.
+func (c Certificates) Generate() error {
+	for _, cert := range c {
+		if cert.KeyPair != nil {
+			continue
+		}
+
+		var err error
+		if cert.Purpose == ServiceAccount {
+			cert.KeyPair, err = generateServiceAccountKeys()
+		} else {
+			cert.KeyPair, err = generateCACert(caCommonNames[cert.Purpose])
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate %q key pair", cert.Purpose)
+		}
+		cert.Generated = true
+	}
+	return nil
+}
+
+// SaveGenerated stores the generated key pairs in Secrets owned by the Cluster. If a Secret has been created
+// in the meantime, its key pair replaces the generated one, so that all the consumers use the same.
+func (c Certificates) SaveGenerated(ctx context.Context, ctrlclient client.Client, reader client.Reader, cluster *clusterv1.Cluster) error {
+	for _, cert := range c {
+		if !cert.Generated {
+			continue
+		}
+
+		s := cert.AsSecret(cluster)
+		if err := ctrlclient.Create(ctx, s); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				if err := (Certificates{cert}).Lookup(ctx, reader, cluster); err != nil {
+					return err
+				}
+				if cert.Generated {
+					return errors.Errorf("Secret %q in namespace %q has been created concurrently and can't be read yet", s.Name, s.Namespace)
+				}
+				continue
+			}
+			return errors.Wrapf(err, "failed to create Secret %q in namespace %q", s.Name, s.Namespace)
+		}
+	}
+	return nil
+}
+
+// LookupOrGenerate looks up the key pairs of the certificates with the reader, then generates the missing ones
+// and stores them with the client.
+func (c Certificates) LookupOrGenerate(ctx context.Context, ctrlclient client.Client, reader client.Reader, cluster *clusterv1.Cluster) error {
+	if err := c.Lookup(ctx, reader, cluster); err != nil {
+		return err
+	}
+	if err := c.Generate(); err != nil {
+		return err
+	}
+	return c.SaveGenerated(ctx, ctrlclient, reader, cluster)
+}
+
+// AsSecret returns the Secret storing the key pair of the certificate, controlled by the Cluster.
+func (c *Certificate) AsSecret(cluster *clusterv1.Cluster) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      Name(cluster.Name, c.Purpose),
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				clusterv1.MachineClusterLabelName: cluster.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: clusterv1.GroupVersion.String(),
+					Kind:       "Cluster",
+					Name:       cluster.Name,
+					UID:        cluster.UID,
+					Controller: pointer.BoolPtr(true),
+				},
+			},
+		},
+		Data: map[string][]byte{
+			TLSCrtDataName: c.KeyPair.Cert,
+			TLSKeyDataName: c.KeyPair.Key,
+		},
+	}
+}
+
+func generateCACert(commonName string) (*certs.KeyPair, error) {
+	key, err := certs.NewPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create private key")
+	}
+
+	cert, err := certs.NewSelfSignedCACert(key, commonName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certs.KeyPair{
+		Cert: certs.EncodeCertPEM(cert),
+		Key:  certs.EncodePrivateKeyPEM(key),
+	}, nil
+}
+
+func generateServiceAccountKeys() (*certs.KeyPair, error) {
+	key, err := certs.NewPrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create private key")
+	}
+
+	pub, err := certs.EncodePublicKeyPEM(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certs.KeyPair{
+		Cert: pub,
+		Key:  certs.EncodePrivateKeyPEM(key),
+	}, nil
+}
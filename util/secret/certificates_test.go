@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLookupOrGenerate(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test1", Namespace: "test"},
+	}
+
+	userCA := NewCertificates().GetByPurpose(ClusterCA)
+	if err := (Certificates{userCA}).Generate(); err != nil {
+		t.Fatalf("failed to generate the user CA: %v", err)
+	}
+	userCASecret := userCA.AsSecret(cluster)
+	userCASecret.OwnerReferences = nil
+
+	c := fake.NewFakeClient(userCASecret)
+	ctx := context.Background()
+
+	certificates := NewCertificates()
+	if err := certificates.LookupOrGenerate(ctx, c, c, cluster); err != nil {
+		t.Fatalf("failed to look up or generate the certificates: %v", err)
+	}
+
+	if ca := certificates.GetByPurpose(ClusterCA); ca.Generated || !bytes.Equal(ca.KeyPair.Cert, userCA.KeyPair.Cert) {
+		t.Error("expected the CA supplied by the user to be kept")
+	}
+	for _, purpose := range []Purpose{EtcdCA, FrontProxyCA, ServiceAccount} {
+		cert := certificates.GetByPurpose(purpose)
+		if !cert.Generated || !cert.KeyPair.IsValid() {
+			t.Errorf("expected the %q key pair to be generated", purpose)
+			continue
+		}
+
+		s := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: Name(cluster.Name, purpose)}, s); err != nil {
+			t.Errorf("expected the %q key pair to be saved: %v", purpose, err)
+			continue
+		}
+		if s.Labels[clusterv1.MachineClusterLabelName] != cluster.Name || len(s.OwnerReferences) != 1 {
+			t.Errorf("expected the %q Secret to be labeled with and owned by the Cluster", purpose)
+			continue
+		}
+		if controller := s.OwnerReferences[0].Controller; controller == nil || !*controller {
+			t.Errorf("expected the %q Secret to be controlled by the Cluster", purpose)
+		}
+	}
+
+	caCert, err := certs.DecodeCertPEM(certificates.GetByPurpose(EtcdCA).KeyPair.Cert)
+	if err != nil || caCert == nil {
+		t.Fatalf("failed to decode the etcd CA certificate: %v", err)
+	}
+	if !caCert.IsCA || caCert.Subject.CommonName != "etcd-ca" {
+		t.Errorf("expected a CA certificate with common name %q, got IsCA %t and %q", "etcd-ca", caCert.IsCA, caCert.Subject.CommonName)
+	}
+
+	again := NewCertificates()
+	if err := again.LookupOrGenerate(ctx, c, c, cluster); err != nil {
+		t.Fatalf("failed to look up the certificates: %v", err)
+	}
+	for _, cert := range again {
+		if cert.Generated || !bytes.Equal(cert.KeyPair.Key, certificates.GetByPurpose(cert.Purpose).KeyPair.Key) {
+			t.Errorf("expected the saved %q key pair to be looked up", cert.Purpose)
+		}
+	}
+}
+
+func TestLookupInvalidSecret(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test1", Namespace: "test"},
+	}
+	c := fake.NewFakeClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: Name(cluster.Name, EtcdCA), Namespace: cluster.Namespace},
+		Data:       map[string][]byte{TLSCrtDataName: []byte("cert")},
+	})
+
+	if err := NewCertificates().Lookup(context.Background(), c, cluster); err == nil {
+		t.Error("expected a Secret without a private key to fail the lookup")
+	}
+}
+
+func TestMissing(t *testing.T) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test1", Namespace: "test"},
+	}
+
+	userCA := NewCertificates().GetByPurpose(ClusterCA)
+	if err := (Certificates{userCA}).Generate(); err != nil {
+		t.Fatalf("failed to generate the user CA: %v", err)
+	}
+	c := fake.NewFakeClient(userCA.AsSecret(cluster))
+
+	certificates := NewCertificates()
+	if err := certificates.Lookup(context.Background(), c, cluster); err != nil {
+		t.Fatalf("failed to look up the certificates: %v", err)
+	}
+	missing := certificates.Missing()
+	if len(missing) != 3 || missing.GetByPurpose(ClusterCA) != nil {
+		t.Errorf("expected every certificate but the cluster CA to be missing, got %d", len(missing))
+	}
+}
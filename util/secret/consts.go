@@ -23,6 +23,9 @@ const (
 	// KubeconfigDataName is the key used to store a Kubeconfig in the secret's data field.
 	KubeconfigDataName = "value"
 
+	// BootstrapDataName is the key used to store the bootstrap data of a Machine in the secret's data field.
+	BootstrapDataName = "value"
+
 	// TLSKeyDataName is the key used to store a TLS private key in the secret's data field.
 	TLSKeyDataName = "tls.key"
 
@@ -34,4 +37,13 @@ const (
 
 	// ClusterCA is the secret name suffix for APIServer CA.
 	ClusterCA = Purpose("ca")
+
+	// EtcdCA is the secret name suffix for the Etcd CA.
+	EtcdCA = Purpose("etcd")
+
+	// FrontProxyCA is the secret name suffix for the Front Proxy CA.
+	FrontProxyCA = Purpose("proxy")
+
+	// ServiceAccount is the secret name suffix for the Service Account signing key pair.
+	ServiceAccount = Purpose("sa")
 )
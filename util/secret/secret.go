@@ -20,13 +20,15 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Get retrieves the specified Secret (if any) from the given
-// cluster name and namespace.
+// cluster name and namespace. The Secret is always read in the namespace
+// of the Cluster, which is watched by managers limited to some namespaces.
 func Get(c client.Client, cluster *clusterv1.Cluster, purpose Purpose) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
 	secretKey := client.ObjectKey{
@@ -45,3 +47,27 @@ func Get(c client.Client, cluster *clusterv1.Cluster, purpose Purpose) (*corev1.
 func Name(cluster string, suffix Purpose) string {
 	return fmt.Sprintf("%s-%s", cluster, suffix)
 }
+
+// GetBootstrapData returns the bootstrap data referenced by the given Bootstrap, reading it from
+// the Secret named by DataSecretName in the given namespace, or from the deprecated inline Data field.
+// Infrastructure providers use it to retrieve the bootstrap data of their Machines.
+func GetBootstrapData(ctx context.Context, c client.Client, namespace string, bootstrap *clusterv1.Bootstrap) ([]byte, error) {
+	if bootstrap.DataSecretName == nil {
+		if bootstrap.Data != nil {
+			return []byte(*bootstrap.Data), nil
+		}
+		return nil, errors.New("bootstrap data is not available yet")
+	}
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: namespace, Name: *bootstrap.DataSecretName}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve bootstrap data secret %q in namespace %q", key.Name, key.Namespace)
+	}
+
+	value, ok := secret.Data[BootstrapDataName]
+	if !ok {
+		return nil, errors.Errorf("missing key %q in bootstrap data secret %q in namespace %q", BootstrapDataName, key.Name, key.Namespace)
+	}
+	return value, nil
+}
@@ -31,8 +31,10 @@ import (
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog"
@@ -145,6 +147,42 @@ func IsControlPlaneMachine(machine *clusterv1.Machine) bool {
 	return machine.ObjectMeta.Labels[clusterv1.MachineControlPlaneLabelName] != ""
 }
 
+// IsPaused returns true if the Cluster is paused or the object has the paused annotation.
+func IsPaused(cluster *clusterv1.Cluster, o metav1.Object) bool {
+	if cluster != nil && cluster.Spec.Paused {
+		return true
+	}
+	return HasPausedAnnotation(o)
+}
+
+// HasPausedAnnotation returns true if the object has the paused annotation.
+func HasPausedAnnotation(o metav1.Object) bool {
+	_, ok := o.GetAnnotations()[clusterv1.PausedAnnotation]
+	return ok
+}
+
+// HasWatchLabel returns true if the object has the watch label with the given value.
+// Every object matches an empty value.
+func HasWatchLabel(o metav1.Object, labelValue string) bool {
+	if labelValue == "" {
+		return true
+	}
+	return o.GetLabels()[clusterv1.WatchLabel] == labelValue
+}
+
+// SetWatchLabel sets the watch label of the object to the given value, unless it's empty.
+func SetWatchLabel(o metav1.Object, labelValue string) {
+	if labelValue == "" {
+		return
+	}
+	labels := o.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[clusterv1.WatchLabel] = labelValue
+	o.SetLabels(labels)
+}
+
 // IsNodeReady returns true if a node is ready.
 func IsNodeReady(node *v1.Node) bool {
 	for _, condition := range node.Status.Conditions {
@@ -220,6 +258,44 @@ func ClusterToInfrastructureMapFunc(gvk schema.GroupVersionKind) handler.ToReque
 	}
 }
 
+// ClusterToObjectsMapFunc returns a handler.ToRequestsFunc that maps a Cluster to the objects of the given
+// list type that have its cluster name label, in the namespace of the Cluster.
+func ClusterToObjectsMapFunc(c client.Client, list runtime.Object) handler.ToRequestsFunc {
+	return func(o handler.MapObject) []reconcile.Request {
+		cluster, ok := o.Object.(*clusterv1.Cluster)
+		if !ok {
+			return nil
+		}
+
+		objs := list.DeepCopyObject()
+		if err := c.List(context.Background(), objs,
+			client.InNamespace(cluster.Namespace),
+			client.MatchingLabels{clusterv1.MachineClusterLabelName: cluster.Name},
+		); err != nil {
+			klog.Errorf("Failed to list objects of Cluster %q in namespace %q: %v", cluster.Name, cluster.Namespace, err)
+			return nil
+		}
+
+		items, err := meta.ExtractList(objs)
+		if err != nil {
+			klog.Errorf("Failed to extract objects of Cluster %q in namespace %q: %v", cluster.Name, cluster.Namespace, err)
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(items))
+		for _, item := range items {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKey{Namespace: accessor.GetNamespace(), Name: accessor.GetName()},
+			})
+		}
+		return requests
+	}
+}
+
 // GetOwnerMachine returns the Machine object owning the current resource.
 func GetOwnerMachine(ctx context.Context, c client.Client, obj metav1.ObjectMeta) (*clusterv1.Machine, error) {
 	for _, ref := range obj.OwnerReferences {
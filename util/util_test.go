@@ -184,6 +184,34 @@ func TestClusterToInfrastructureMapFunc(t *testing.T) {
 	}
 }
 
+func TestClusterToObjectsMapFunc(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme,
+		&clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "machine-1",
+			Labels:    map[string]string{clusterv1.MachineClusterLabelName: "test-1"},
+		}},
+		&clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "machine-2",
+			Labels:    map[string]string{clusterv1.MachineClusterLabelName: "test-2"},
+		}},
+	)
+
+	fn := ClusterToObjectsMapFunc(c, &clusterv1.MachineList{})
+	out := fn(handler.MapObject{
+		Object: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-1"}},
+	})
+	expected := []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: "default", Name: "machine-1"}}}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("Unexpected output. Got: %v, Want: %v", out, expected)
+	}
+}
+
 func TestHasOwner(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -260,6 +288,50 @@ func TestHasOwner(t *testing.T) {
 	}
 }
 
+func TestIsPaused(t *testing.T) {
+	tests := []struct {
+		name     string
+		cluster  *clusterv1.Cluster
+		object   metav1.Object
+		expected bool
+	}{
+		{
+			name:    "neither the cluster nor the object is paused",
+			cluster: &clusterv1.Cluster{},
+			object:  &clusterv1.Machine{},
+		},
+		{
+			name:     "paused cluster",
+			cluster:  &clusterv1.Cluster{Spec: clusterv1.ClusterSpec{Paused: true}},
+			object:   &clusterv1.Machine{},
+			expected: true,
+		},
+		{
+			name:    "paused object",
+			cluster: &clusterv1.Cluster{},
+			object: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{clusterv1.PausedAnnotation: ""}},
+			},
+			expected: true,
+		},
+		{
+			name: "paused object without cluster",
+			object: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{clusterv1.PausedAnnotation: "true"}},
+			},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := IsPaused(test.cluster, test.object); test.expected != result {
+				t.Errorf("expected IsPaused to be %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
 func TestPointsTo(t *testing.T) {
 	targetID := "fri3ndsh1p"
 
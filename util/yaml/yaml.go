@@ -34,11 +34,13 @@ import (
 )
 
 func ExtractClusterReferences(out *ParseOutput, c *clusterv1.Cluster) (res []*unstructured.Unstructured) {
-	if c.Spec.InfrastructureRef == nil {
-		return nil
-	}
-	if obj := out.FindUnstructuredReference(c.Spec.InfrastructureRef); obj != nil {
-		res = append(res, obj)
+	for _, ref := range []*corev1.ObjectReference{c.Spec.InfrastructureRef, c.Spec.ControlPlaneRef} {
+		if ref == nil {
+			continue
+		}
+		if obj := out.FindUnstructuredReference(ref); obj != nil {
+			res = append(res, obj)
+		}
 	}
 	return
 }